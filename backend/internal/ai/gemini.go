@@ -3,10 +3,13 @@ package ai
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
 )
 
 type Client struct {
@@ -28,7 +31,9 @@ func NewClient(apiKey string, modelName string) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) SegmentText(text string, maxLen int) ([]string, error) {
+// SegmentText 以 AI 斷句，結果會與原文逐字比對並修復 (見 segment.Segmenter.Verify)；
+// 偏離原文過多時回傳 DriftError，由呼叫端重試
+func (c *Client) SegmentText(text, locale string, maxLen int) ([]string, segment.Report, error) {
 	ctx := context.Background()
 	prompt := fmt.Sprintf(`
 You are a professional video subtitle editor.
@@ -62,20 +67,9 @@ Text to Segment:
 %s
 `, maxLen, text)
 
-	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+	resultText, err := c.generate(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("gemini generation error: %w", err)
-	}
-
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no response from gemini")
-	}
-
-	var resultText string
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if txt, ok := part.(genai.Text); ok {
-			resultText += string(txt)
-		}
+		return nil, segment.Report{}, err
 	}
 
 	// Split by custom separator
@@ -88,7 +82,130 @@ Text to Segment:
 		}
 	}
 
-	return segments, nil
+	// Never trust the model: diff against the source, restore dropped text and re-split overlong lines
+	fixed, report := segment.ForText(locale, text).Verify(text, segments, segment.Columns(maxLen, text))
+	if report.Drifted() {
+		return nil, report, &DriftError{Report: report}
+	}
+	return fixed, report, nil
+}
+
+// DriftError AI 斷句與原文差異過大
+type DriftError struct {
+	Report segment.Report
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("segmentation drifted from source text (similarity %.2f)", e.Report.Similarity)
+}
+
+// Translate 將腳本翻譯為目標語系 (source 空白表示自動偵測)；
+// glossary 為固定譯法 (原文 → 譯文，兩者相同表示保留原文)，方括號標記 (例如 [pause=500ms]) 原樣保留
+func (c *Client) Translate(text, source, target string, glossary map[string]string) (string, error) {
+	ctx := context.Background()
+	if source == "" {
+		source = "auto-detect"
+	}
+	prompt := fmt.Sprintf(`
+You are a professional translator localizing narration scripts for short videos.
+Translate the script from %s to %s.
+
+Constraints:
+1. Natural Speech: The translation will be read aloud by a text-to-speech voice. Use natural, spoken %s of similar length and tone.
+2. Glossary: Always use these fixed translations:
+%s3. Tags: Keep every tag in square brackets (e.g. [pause=500ms], [emphasis=strong]...[/emphasis]) exactly as written, placed around the corresponding translated words. Do NOT translate tag names or values.
+4. Structure: Keep sentence order, sentence-ending punctuation and paragraph breaks (blank lines).
+5. Output Format: Only the translated script. No markdown, no quotes, no explanations.
+
+Script:
+%s
+`, source, target, target, glossaryPrompt(glossary), text)
+
+	result, err := c.generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	result = strings.TrimSpace(result)
+	if result == "" {
+		return "", fmt.Errorf("gemini returned empty translation")
+	}
+	return result, nil
+}
+
+// TranslateLines 逐句翻譯字幕，回傳與 lines 相同句數的譯文 (雙語字幕使用)
+func (c *Client) TranslateLines(lines []string, source, target string, glossary map[string]string) ([]string, error) {
+	ctx := context.Background()
+	if source == "" {
+		source = "auto-detect"
+	}
+	prompt := fmt.Sprintf(`
+You are a professional subtitle translator for language-learning videos.
+Translate each subtitle line from %s to %s. The translation is shown under the original line.
+
+Constraints:
+1. Line Count: The input has %d lines separated by "|||". Output EXACTLY %d translated lines separated by "|||", in the same order. Never merge or split lines.
+2. Faithful: Keep the meaning of each line; a line may be a sentence fragment, translate it as a fragment.
+3. Glossary: Always use these fixed translations:
+%s4. Output Format: Pure text with separators. No numbering, no markdown, no explanations.
+
+Lines:
+%s
+`, source, target, len(lines), len(lines), glossaryPrompt(glossary), strings.Join(lines, "|||"))
+
+	result, err := c.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimSpace(result), "|||")
+	if len(parts) != len(lines) {
+		return nil, fmt.Errorf("gemini returned %d lines, expected %d", len(parts), len(lines))
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts, nil
+}
+
+// glossaryPrompt 將詞彙表列為 prompt 中的固定譯法，依原文排序讓 prompt 穩定；譯文與原文相同表示保留原文
+func glossaryPrompt(glossary map[string]string) string {
+	keys := make([]string, 0, len(glossary))
+	for term := range glossary {
+		keys = append(keys, term)
+	}
+	sort.Strings(keys)
+	var terms strings.Builder
+	for _, term := range keys {
+		translation := glossary[term]
+		if term == translation {
+			fmt.Fprintf(&terms, "- %q: keep as is, do NOT translate\n", term)
+		} else {
+			fmt.Fprintf(&terms, "- %q => %q\n", term, translation)
+		}
+	}
+	if terms.Len() == 0 {
+		terms.WriteString("(none)\n")
+	}
+	return terms.String()
+}
+
+// generate 送出 prompt 並串接回應中的文字
+func (c *Client) generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", fmt.Errorf("gemini generation error: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from gemini")
+	}
+
+	var resultText string
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if txt, ok := part.(genai.Text); ok {
+			resultText += string(txt)
+		}
+	}
+	return resultText, nil
 }
 
 func (c *Client) Close() {
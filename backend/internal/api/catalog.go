@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+)
+
+// TransitionResponse 轉場清單項目
+type TransitionResponse struct {
+	job.TransitionInfo
+	PreviewURL string `json:"preview_url"`
+}
+
+// ListTransitions 列出支援的轉場效果
+func (h *Handlers) ListTransitions(w http.ResponseWriter, r *http.Request) {
+	list := make([]TransitionResponse, 0, len(job.Transitions))
+	for _, t := range job.Transitions {
+		list = append(list, TransitionResponse{
+			TransitionInfo: t,
+			PreviewURL:     fmt.Sprintf("/api/v1/transitions/%s/preview", t.Name),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":        list,
+		"default_sec": job.DefaultTransitionSec,
+	})
+}
+
+// GetTransitionPreview 回傳轉場預覽縮圖 (首次請求時產生並快取)
+func (h *Handlers) GetTransitionPreview(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "none" || !job.IsTransition(name) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到轉場"})
+		return
+	}
+	path, err := media.TransitionPreview(filepath.Join(h.Config.StoragePath, "cache", "transitions"), name)
+	if err != nil {
+		log.Error().Err(err).Str("transition", name).Msg("產生轉場預覽失敗")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeFile(w, r, path)
+}
+
+// ListEffects 列出圖片素材支援的運鏡特效與可調參數
+func (h *Handlers) ListEffects(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":        job.Effects,
+		"easings":     job.Easings,
+		"focus_modes": job.FocusModes,
+	})
+}
@@ -28,9 +28,10 @@ import (
 )
 
 type Handlers struct {
-	Config *config.Config
-	Store  *storage.Store
-	Queue  *worker.Queue
+	Config  *config.Config
+	Store   *storage.Store
+	Queue   *worker.Queue
+	Catalog *tts.Catalog
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -49,11 +50,47 @@ func (h *Handlers) CreateJob(w http.ResponseWriter, r *http.Request) {
 	log.Info().Interface("request", req).Msg("收到建立任務請求")
 	log.Info().Interface("subtitle_style", req.SubtitleStyle).Msg("收到字幕樣式參數")
 
+	// 未指定 TTS provider 時挑選已設定可用的
+	if req.TTS.Provider == "" {
+		req.TTS.Provider = tts.DefaultProvider(h.Config)
+	}
+
 	if err := req.Validate(); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
+	// 匯入字幕時先解析一次，格式錯誤在建立任務時就回報
+	if req.Transcript.Provided() {
+		if _, err := media.LoadTranscript(req.Transcript); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// 指定的發音字典需已建立
+	if team := req.TTS.Lexicon; team != "" {
+		if !tts.ValidTeam(team) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "tts.lexicon 團隊名稱不正確"})
+			return
+		}
+		if _, err := h.Store.GetLexicon(team); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("找不到團隊 %s 的發音字典", team)})
+			return
+		}
+	}
+
+	// 語言版本指定的 provider 需已設定可用
+	for i, l := range req.Languages {
+		if l.Provider == "" {
+			continue
+		}
+		if ok, reason := tts.ProviderAvailable(h.Config, l.Provider); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("languages[%d].provider: %s", i, reason)})
+			return
+		}
+	}
+
 	// 處理隨機 BGM
 	if req.BGM.Source == "preset" && req.BGM.Path == "random" {
 		bgmList := utils.ListAudioFiles(h.Config.BgmPath)
@@ -144,6 +181,90 @@ func (h *Handlers) DownloadResult(w http.ResponseWriter, r *http.Request) {
 	_, _ = io.Copy(w, f)
 }
 
+// GetThumbnail 回傳任務封面 (預設，含標題) 或縮圖畫面 (?type=frame)
+func (h *Handlers) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	name := "cover.jpg"
+	switch r.URL.Query().Get("type") {
+	case "", "cover":
+	case "frame":
+		name = "thumbnail.jpg"
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "type must be cover or frame"})
+		return
+	}
+	fp := filepath.Join(rec.BasePath, name)
+	if _, err := os.Stat(fp); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "尚未產生縮圖"})
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, fp)
+}
+
+// exportContentTypes 額外輸出格式對應的 Content-Type
+var exportContentTypes = map[string]string{
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"mp3":  "audio/mpeg",
+	"m4a":  "audio/mp4",
+}
+
+// DownloadExport 下載額外輸出 (gif、webp、mp3、m4a)
+func (h *Handlers) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	format := mux.Vars(r)["format"]
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "不支援的輸出格式"})
+		return
+	}
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	fp := filepath.Join(rec.BasePath, "export."+format)
+	if _, err := os.Stat(fp); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "此任務沒有該格式的輸出"})
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", id, format))
+	http.ServeFile(w, r, fp)
+}
+
+// DownloadRendition 下載語言版本的成品
+func (h *Handlers) DownloadRendition(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	locale := mux.Vars(r)["locale"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	// 只接受任務實際輸出的語系，避免以路徑存取其他檔案
+	for _, rd := range rec.Renditions {
+		if rd.Locale != locale || rd.URL == "" {
+			continue
+		}
+		fp := filepath.Join(rec.BasePath, "lang", rd.Locale, "output.mp4")
+		if _, err := os.Stat(fp); err != nil {
+			break
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s.mp4\"", id, locale))
+		http.ServeFile(w, r, fp)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "此任務沒有該語言的輸出"})
+}
+
 func (h *Handlers) CancelJob(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	rec, err := h.Store.GetJob(id)
@@ -161,6 +282,82 @@ func (h *Handlers) CancelJob(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "canceled"})
 }
 
+// PromoteJob 以草稿預覽的設定建立正式輸出任務 (TTS 有快取時不必重新合成)
+func (h *Handlers) PromoteJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	if !rec.Request.Preview.Enabled {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "只有草稿預覽任務可以轉為正式輸出"})
+		return
+	}
+	// 審閱中的時間軸尚未定案，轉正式輸出會遺失之後的修改
+	if rec.Status == job.StatusReview {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "草稿預覽仍在審閱中，請先送出字幕時間軸"})
+		return
+	}
+	// 只輸出前幾秒的草稿，審閱過的時間軸不含其餘句子
+	if rec.Reviewed && rec.Request.Preview.MaxSeconds > 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "草稿預覽只輸出前段，審閱過的字幕時間軸不完整，無法轉為正式輸出"})
+		return
+	}
+	req := rec.Request
+	req.Preview = job.PreviewSetting{}
+	record, err := job.NewJobRecord(req)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if rec.Reviewed {
+		if err := carryOverTimeline(rec, record); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "複製審閱後的字幕時間軸失敗: " + err.Error()})
+			return
+		}
+	}
+	if err := h.Store.InsertJob(record); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	h.Queue.Push(record.ID)
+	log.Info().Str("preview", id).Str("job", record.ID).Msg("草稿預覽轉為正式輸出")
+	writeJSON(w, http.StatusCreated, map[string]string{"id": record.ID})
+}
+
+// carryOverTimeline 沿用草稿預覽審閱後的字幕時間軸：正式任務直接套用審閱結果，
+// 每句標記重新合成，以審閱後的文字重新產生語音 (TTS 快取命中時不必重新呼叫)
+func carryOverTimeline(preview, record *job.Record) error {
+	tl, err := media.LoadTimeline(preview.BasePath)
+	if err != nil {
+		return err
+	}
+	// 自備旁白沿用原旁白，不需重新合成
+	voiceover := preview.Request.Voiceover.Provided()
+	if !voiceover {
+		for i := range tl.Lines {
+			tl.Lines[i].Audio = ""
+			tl.Lines[i].Dirty = true
+		}
+	}
+	// 與草稿預覽放在同一個任務根目錄
+	record.BasePath = filepath.Join(filepath.Dir(preview.BasePath), record.ID)
+	if err := os.MkdirAll(record.BasePath, 0o755); err != nil {
+		return err
+	}
+	err = media.SaveTimeline(record.BasePath, tl)
+	if err == nil && voiceover {
+		err = utils.CopyFile(filepath.Join(preview.BasePath, "voice.wav"), filepath.Join(record.BasePath, "voice.wav"))
+	}
+	if err != nil {
+		_ = os.RemoveAll(record.BasePath)
+		return err
+	}
+	record.Reviewed = true
+	return nil
+}
+
 func (h *Handlers) DeleteJob(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	rec, err := h.Store.GetJob(id)
@@ -407,6 +604,7 @@ type PreviewSubtitleRequest struct {
 	Style      job.SubtitleStyle `json:"style"`
 	Background string            `json:"background"`
 	Resolution string            `json:"resolution"`
+	Overlays   []job.Overlay     `json:"overlays"` // 一併預覽的疊加層 (可選)
 }
 
 // PreviewSubtitle 生成字幕預覽
@@ -440,11 +638,19 @@ func (h *Handlers) PreviewSubtitle(w http.ResponseWriter, r *http.Request) {
 	if req.Style.YOffset == 0 {
 		req.Style.YOffset = 70
 	}
+	if err := req.Style.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := job.ValidateOverlays(req.Overlays); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 
 	// 使用 media service 生成圖片
 	// 輸出路徑使用系統預設暫存區
 	tmpBase := os.TempDir()
-	outPath, err := media.GeneratePreviewImage(tmpBase, req.Style, req.Text, req.Background, req.Resolution)
+	outPath, err := media.GeneratePreviewImage(tmpBase, req.Style, req.Text, req.Background, req.Resolution, req.Overlays)
 	if err != nil {
 		log.Error().Err(err).Msg("生成預覽圖失敗")
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -538,25 +744,3 @@ func (h *Handlers) CleanTempFiles(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, map[string]int{"deleted_count": deletedCount})
 }
-
-// ListVoices 列出 TTS 語音
-func (h *Handlers) ListVoices(w http.ResponseWriter, r *http.Request) {
-	providerName := r.URL.Query().Get("provider")
-	if providerName == "" {
-		providerName = "azure_v1"
-	}
-
-	p, err := tts.GetProvider(providerName, h.Config)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-		return
-	}
-
-	voices, err := p.ListVoices()
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		return
-	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{"data": voices})
-}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// GetLexicon 取得團隊發音字典，尚未建立時回傳空字典
+func (h *Handlers) GetLexicon(w http.ResponseWriter, r *http.Request) {
+	team := mux.Vars(r)["team"]
+	if !tts.ValidTeam(team) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "團隊名稱只允許英數、底線與連字號"})
+		return
+	}
+	lex, err := h.Store.GetLexicon(team)
+	if errors.Is(err, fs.ErrNotExist) {
+		lex = &tts.Lexicon{Team: team, Entries: []tts.LexiconEntry{}}
+	} else if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "讀取發音字典失敗"})
+		return
+	}
+	writeJSON(w, http.StatusOK, lex)
+}
+
+// PutLexicon 以請求內容整份取代團隊發音字典
+func (h *Handlers) PutLexicon(w http.ResponseWriter, r *http.Request) {
+	team := mux.Vars(r)["team"]
+	if !tts.ValidTeam(team) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "團隊名稱只允許英數、底線與連字號"})
+		return
+	}
+	var lex tts.Lexicon
+	if err := json.NewDecoder(r.Body).Decode(&lex); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "請提供有效JSON"})
+		return
+	}
+	if err := lex.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if lex.Entries == nil {
+		lex.Entries = []tts.LexiconEntry{}
+	}
+	lex.Team = team
+	lex.UpdatedAt = time.Now()
+	if err := h.Store.PutLexicon(&lex); err != nil {
+		log.Error().Err(err).Str("team", team).Msg("保存發音字典失敗")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "保存發音字典失敗"})
+		return
+	}
+	log.Info().Str("team", team).Int("entries", len(lex.Entries)).Msg("已更新發音字典")
+	writeJSON(w, http.StatusOK, lex)
+}
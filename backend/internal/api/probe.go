@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+)
+
+// ProbeRequest 素材探測請求
+type ProbeRequest struct {
+	PathOrURL string `json:"path_or_url"` // 上傳後回傳的路徑或 http(s) URL
+	Thumbnail string `json:"thumbnail"`   // sheet (預設，3x3 預覽表)、single (單張)、none
+}
+
+// ProbeResponse 素材探測結果
+type ProbeResponse struct {
+	*media.ProbeInfo
+	Thumbnail string `json:"thumbnail,omitempty"` // JPEG data URI
+}
+
+// ProbeMedia 以 ffprobe 讀取素材資訊並產生縮圖，讓前端送出工作前就能知道長度、尺寸與是否有音軌
+func (h *Handlers) ProbeMedia(w http.ResponseWriter, r *http.Request) {
+	var req ProbeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	req.PathOrURL = strings.TrimSpace(req.PathOrURL)
+	if req.PathOrURL == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "path_or_url is required"})
+		return
+	}
+	if req.Thumbnail == "" {
+		req.Thumbnail = "sheet"
+	}
+	if req.Thumbnail != "sheet" && req.Thumbnail != "single" && req.Thumbnail != "none" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "thumbnail must be one of sheet, single, none"})
+		return
+	}
+	isURL := strings.HasPrefix(req.PathOrURL, "http://") || strings.HasPrefix(req.PathOrURL, "https://")
+	if !isURL {
+		// 本機檔案只限上傳檔與素材目錄，避免被用來探查伺服器上任意路徑是否存在；
+		// 符號連結解析後需仍在允許的目錄內
+		if !h.mediaPathAllowed(req.PathOrURL) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "只能探測上傳的檔案或素材目錄內的檔案"})
+			return
+		}
+		real, err := filepath.EvalSymlinks(req.PathOrURL)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到素材檔案"})
+			return
+		}
+		if !h.mediaPathAllowed(real) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "只能探測上傳的檔案或素材目錄內的檔案"})
+			return
+		}
+	}
+
+	info, err := media.Probe(req.PathOrURL)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := ProbeResponse{ProbeInfo: info}
+	if req.Thumbnail != "none" && info.HasVideo {
+		cols, rows := 3, 3
+		if req.Thumbnail == "single" {
+			cols, rows = 1, 1
+		}
+		if data, err := media.ContactSheet(req.PathOrURL, info, cols, rows); err != nil {
+			// 縮圖失敗不影響探測結果
+			log.Warn().Err(err).Str("file", req.PathOrURL).Msg("產生素材縮圖失敗")
+		} else {
+			resp.Thumbnail = media.ThumbnailDataURI(data)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// mediaPathAllowed 本機路徑是否為上傳檔 (暫存目錄中的 upload_*)，或位於工單儲存目錄、BGM 目錄內；
+// 目錄本身是符號連結時 (例如 /tmp → /private/tmp)，解析前後的路徑都視為同一目錄
+func (h *Handlers) mediaPathAllowed(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, tmp := range dirAliases(os.TempDir()) {
+		if filepath.Dir(abs) == tmp && strings.HasPrefix(filepath.Base(abs), "upload_") {
+			return true
+		}
+	}
+	for _, dir := range []string{h.Config.StoragePath, h.Config.BgmPath} {
+		for _, root := range dirAliases(dir) {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dirAliases 目錄的絕對路徑與解析符號連結後的路徑
+func dirAliases(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	var out []string
+	if abs, err := filepath.Abs(dir); err == nil {
+		out = append(out, abs)
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if abs, err := filepath.Abs(real); err == nil {
+			out = append(out, abs)
+		}
+	}
+	return out
+}
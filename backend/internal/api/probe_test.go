@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+)
+
+func TestProbeMediaPathRestriction(t *testing.T) {
+	storage := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(storage, "link.mp4")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handlers{Config: &config.Config{StoragePath: storage}}
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/etc/passwd", http.StatusForbidden},
+		{outside, http.StatusForbidden},
+		{filepath.Join(storage, "..", filepath.Base(filepath.Dir(outside)), "secret.txt"), http.StatusForbidden},
+		// 連結到目錄外的檔案也拒絕
+		{link, http.StatusForbidden},
+		// 允許的目錄內才回報檔案不存在
+		{filepath.Join(storage, "missing.mp4"), http.StatusNotFound},
+		{filepath.Join(os.TempDir(), "upload_missing.mp4"), http.StatusNotFound},
+	}
+	for _, c := range cases {
+		body := `{"path_or_url":` + strconv.Quote(c.path) + `,"thumbnail":"none"}`
+		rr := httptest.NewRecorder()
+		h.ProbeMedia(rr, httptest.NewRequest(http.MethodPost, "/api/v1/media/probe", strings.NewReader(body)))
+		if rr.Code != c.want {
+			t.Errorf("%s 狀態碼 = %d，期望 %d", c.path, rr.Code, c.want)
+		}
+	}
+}
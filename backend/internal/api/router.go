@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
 	"github.com/Reggie-pan/go-shorts-generator/internal/storage"
 	"github.com/Reggie-pan/go-shorts-generator/internal/worker"
 )
@@ -18,7 +19,7 @@ import (
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -52,7 +53,7 @@ func NewRouter(cfg *config.Config, store *storage.Store, q *worker.Queue) http.H
 	r := mux.NewRouter()
 	api := r.PathPrefix("/api/v1").Subrouter()
 
-	h := &Handlers{Config: cfg, Store: store, Queue: q}
+	h := &Handlers{Config: cfg, Store: store, Queue: q, Catalog: tts.NewCatalog(cfg)}
 
 	// 中介軟體：添加 CORS 標頭
 	api.Use(corsMiddleware)
@@ -62,14 +63,30 @@ func NewRouter(cfg *config.Config, store *storage.Store, q *worker.Queue) http.H
 	api.HandleFunc("/jobs", h.DeleteAllJobs).Methods("DELETE")
 	api.HandleFunc("/jobs/{id}", h.GetJob).Methods("GET")
 	api.HandleFunc("/jobs/{id}/result", h.DownloadResult).Methods("GET")
+	api.HandleFunc("/jobs/{id}/thumbnail", h.GetThumbnail).Methods("GET")
+	api.HandleFunc("/jobs/{id}/exports/{format}", h.DownloadExport).Methods("GET")
+	api.HandleFunc("/jobs/{id}/renditions/{locale}", h.DownloadRendition).Methods("GET")
 	api.HandleFunc("/jobs/{id}/cancel", h.CancelJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/promote", h.PromoteJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/timeline", h.GetTimeline).Methods("GET")
+	api.HandleFunc("/jobs/{id}/timeline", h.PatchTimeline).Methods("PATCH")
+	api.HandleFunc("/jobs/{id}/timeline/{index}/audio", h.GetTimelineAudio).Methods("GET")
+	api.HandleFunc("/jobs/{id}/subtitles", h.GetSubtitles).Methods("GET")
 	api.HandleFunc("/jobs/{id}", h.DeleteJob).Methods("DELETE")
 	api.HandleFunc("/upload", h.UploadHandler).Methods("POST")
+	api.HandleFunc("/media/probe", h.ProbeMedia).Methods("POST")
 	api.HandleFunc("/tts/voices", h.ListVoices).Methods("GET")
+	api.HandleFunc("/tts/providers", h.ListTTSProviders).Methods("GET")
+	api.HandleFunc("/tts/preview", h.PreviewVoice).Methods("POST")
+	api.HandleFunc("/lexicons/{team}", h.GetLexicon).Methods("GET")
+	api.HandleFunc("/lexicons/{team}", h.PutLexicon).Methods("PUT")
 	api.HandleFunc("/temp", h.CleanTempFiles).Methods("DELETE")
 	api.HandleFunc("/presets/bgm", h.ListBGM).Methods("GET")
 	api.HandleFunc("/fonts", h.ListFonts).Methods("GET")
 	api.HandleFunc("/preview/subtitle", h.PreviewSubtitle).Methods("POST")
+	api.HandleFunc("/transitions", h.ListTransitions).Methods("GET")
+	api.HandleFunc("/transitions/{name}/preview", h.GetTransitionPreview).Methods("GET")
+	api.HandleFunc("/effects", h.ListEffects).Methods("GET")
 	api.HandleFunc("/swagger.json", h.Swagger).Methods("GET")
 
 	// 靜態資源：BGM
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+	"github.com/Reggie-pan/go-shorts-generator/internal/storage"
+)
+
+// TimelineLineResponse 回傳給前端的字幕行 (隱藏伺服器內部路徑)
+type TimelineLineResponse struct {
+	Index     int     `json:"index"`
+	Text      string  `json:"text"`
+	Start     int     `json:"start_ms"`
+	End       int     `json:"end_ms"`
+	AudioSec  float64 `json:"audio_sec"`
+	AudioURL  string  `json:"audio_url"`
+	Speech    string  `json:"speech,omitempty"`    // 含行內標記的合成文字
+	Secondary string  `json:"secondary,omitempty"` // 雙語字幕的第二行
+}
+
+// TimelinePatchLine 單句修改內容，未提供的欄位保持不變
+type TimelinePatchLine struct {
+	Index     int     `json:"index"`
+	Text      *string `json:"text"`
+	Secondary *string `json:"secondary"` // 雙語字幕的第二行，只影響字幕不需重新合成
+	Start     *int    `json:"start_ms"`
+	End       *int    `json:"end_ms"`
+}
+
+// TimelinePatchRequest 審閱送出的修改
+type TimelinePatchRequest struct {
+	Lines []TimelinePatchLine `json:"lines"`
+}
+
+// GetTimeline 取得任務的字幕時間軸
+func (h *Handlers) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "字幕時間軸尚未產生"})
+		return
+	}
+
+	lines := make([]TimelineLineResponse, 0, len(tl.Lines))
+	for i, l := range tl.Lines {
+		item := TimelineLineResponse{
+			Index:     i,
+			Text:      l.Text,
+			Start:     l.Start,
+			End:       l.End,
+			AudioSec:  l.AudioSec,
+			Speech:    l.Speech,
+			Secondary: l.Secondary,
+		}
+		// 自備旁白沒有單句語音
+		if l.Audio != "" {
+			item.AudioURL = fmt.Sprintf("/api/v1/jobs/%s/timeline/%d/audio", id, i)
+		}
+		lines = append(lines, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": rec.Status,
+		"data":   lines,
+	})
+}
+
+// GetTimelineAudio 試聽單句語音
+func (h *Handlers) GetTimelineAudio(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rec, err := h.Store.GetJob(vars["id"])
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "字幕時間軸尚未產生"})
+		return
+	}
+	idx, err := strconv.Atoi(vars["index"])
+	if err != nil || idx < 0 || idx >= len(tl.Lines) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到字幕行"})
+		return
+	}
+
+	f, err := os.Open(tl.Lines[idx].Audio)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "無法開啟語音檔"})
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "audio/wav")
+	_, _ = io.Copy(w, f)
+}
+
+// PatchTimeline 送出審閱修改並繼續合成影片
+func (h *Handlers) PatchTimeline(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	if rec.Status != job.StatusReview {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "任務不在審閱狀態"})
+		return
+	}
+
+	var req TimelinePatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "請提供有效JSON"})
+		return
+	}
+
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "讀取字幕時間軸失敗"})
+		return
+	}
+
+	for _, p := range req.Lines {
+		if p.Index < 0 || p.Index >= len(tl.Lines) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("字幕行 %d 不存在", p.Index)})
+			return
+		}
+		line := &tl.Lines[p.Index]
+		if p.Text != nil {
+			text := strings.TrimSpace(*p.Text)
+			if text == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("字幕行 %d 不可空白", p.Index)})
+				return
+			}
+			// 開啟行內標記時可直接編輯含標記的文字 (未開啟時只有停頓標記有效)，字幕顯示移除標記後的內容
+			speech := ""
+			markup := text
+			if !rec.Request.TTS.Markup {
+				markup = tts.PauseMarkup(text)
+			}
+			if stripped := tts.StripMarkup(markup); stripped != text {
+				speech, text = text, stripped
+			}
+			if text != line.Text || speech != line.Speech {
+				line.Text = text
+				line.Speech = speech
+				line.Dirty = true
+			}
+		}
+		if p.Secondary != nil {
+			line.Secondary = strings.TrimSpace(*p.Secondary)
+		}
+		if p.Start != nil {
+			line.Start = *p.Start
+		}
+		if p.End != nil {
+			line.End = *p.End
+		}
+	}
+
+	// 檢查時間軸：每句需有正長度，且開始時間不可早於上一句
+	for i, l := range tl.Lines {
+		if l.Start < 0 || l.End <= l.Start {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("字幕行 %d 時間不正確", i)})
+			return
+		}
+		if i > 0 && l.Start < tl.Lines[i-1].Start {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("字幕行 %d 早於前一句", i)})
+			return
+		}
+	}
+
+	// 狀態轉換與保存時間軸需原子化，避免同時送出的兩次審閱都通過檢查而重複排入佇列
+	_, err = h.Store.UpdateJobIf(id, job.StatusReview, func(r *job.Record) error {
+		if err := media.SaveTimeline(r.BasePath, tl); err != nil {
+			return fmt.Errorf("保存字幕時間軸失敗: %w", err)
+		}
+		r.Reviewed = true
+		r.Status = job.StatusPending
+		r.UpdatedAt = time.Now()
+		return nil
+	})
+	if errors.Is(err, storage.ErrStatusChanged) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "任務不在審閱狀態"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	log.Info().Str("job", id).Int("edits", len(req.Lines)).Msg("審閱完成，繼續合成")
+	h.Queue.Push(id)
+	writeJSON(w, http.StatusOK, map[string]string{"status": string(job.StatusPending)})
+}
+
+// GetSubtitles 下載字幕檔 (srt、vtt、ass)，內容依目前的字幕時間軸產生
+func (h *Handlers) GetSubtitles(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	rec, err := h.Store.GetJob(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "找不到任務"})
+		return
+	}
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "字幕時間軸尚未產生"})
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "srt"
+	}
+	var content, contentType string
+	switch format {
+	case "srt":
+		content = media.BuildSRT(tl.SubtitleLines())
+		contentType = "application/x-subrip"
+	case "vtt":
+		content = media.BuildVTT(tl.SubtitleLines())
+		contentType = "text/vtt"
+	case "ass":
+		// BuildASS 會寫檔，使用暫存目錄避免覆寫合成中的字幕檔
+		tmpDir, err := os.MkdirTemp("", "subtitle_")
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "建立暫存目錄失敗"})
+			return
+		}
+		defer os.RemoveAll(tmpDir)
+		assPath, _, err := media.BuildASS(tmpDir, rec.Request.SubtitleStyle, tl.SubtitleLines(), rec.Request.Video.Resolution)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		b, err := os.ReadFile(assPath)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "無法讀取字幕檔"})
+			return
+		}
+		content = string(b)
+		contentType = "text/x-ssa"
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format 必須為 srt、vtt 或 ass"})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", id, format))
+	_, _ = io.WriteString(w, content)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/storage"
+	"github.com/Reggie-pan/go-shorts-generator/internal/worker"
+)
+
+// reviewJob 建立一個等待審閱、已有字幕時間軸的任務
+func reviewJob(t *testing.T) (*Handlers, *worker.Queue, *job.Record) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := storage.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &job.Record{ID: "job1", Status: job.StatusReview, BasePath: dir}
+	if err := store.InsertJob(rec); err != nil {
+		t.Fatal(err)
+	}
+	tl := &media.Timeline{Lines: []media.TimelineLine{
+		{SubtitleLine: media.SubtitleLine{Text: "第一句", Start: 0, End: 1000}},
+		{SubtitleLine: media.SubtitleLine{Text: "第二句", Start: 1000, End: 2000}},
+	}}
+	if err := media.SaveTimeline(dir, tl); err != nil {
+		t.Fatal(err)
+	}
+	q := worker.NewQueue(10)
+	return &Handlers{Store: store, Queue: q}, q, rec
+}
+
+func patchTimeline(h *Handlers, body string) int {
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/jobs/job1/timeline", strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+	rr := httptest.NewRecorder()
+	h.PatchTimeline(rr, req)
+	return rr.Code
+}
+
+func TestPatchTimeline(t *testing.T) {
+	h, q, rec := reviewJob(t)
+	if code := patchTimeline(h, `{"lines":[{"index":1,"text":"改過的第二句","end_ms":2500}]}`); code != http.StatusOK {
+		t.Fatalf("狀態碼 = %d，期望 200", code)
+	}
+	if rec.Status != job.StatusPending || !rec.Reviewed {
+		t.Errorf("任務狀態 = %s reviewed=%v，期望 pending 且已審閱", rec.Status, rec.Reviewed)
+	}
+	if id := q.Pop(); id != "job1" {
+		t.Errorf("佇列中的任務 = %q", id)
+	}
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := tl.Lines[1]; l.Text != "改過的第二句" || !l.Dirty || l.End != 2500 {
+		t.Errorf("第二句 = %+v，期望已修改並標記重新合成", l)
+	}
+	if tl.Lines[0].Dirty {
+		t.Error("未修改的句子不應標記重新合成")
+	}
+
+	// 已離開審閱狀態後不可再送出
+	if code := patchTimeline(h, `{"lines":[]}`); code != http.StatusConflict {
+		t.Errorf("重複送出的狀態碼 = %d，期望 409", code)
+	}
+}
+
+func TestPatchTimelineInvalid(t *testing.T) {
+	h, _, rec := reviewJob(t)
+	if code := patchTimeline(h, `{"lines":[{"index":1,"start_ms":3000}]}`); code != http.StatusBadRequest {
+		t.Errorf("時間不正確的狀態碼 = %d，期望 400", code)
+	}
+	if rec.Status != job.StatusReview {
+		t.Errorf("修改被拒絕時任務狀態 = %s，期望維持 review", rec.Status)
+	}
+}
+
+func TestPatchTimelineConcurrent(t *testing.T) {
+	h, q, _ := reviewJob(t)
+	const n = 8
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- patchTimeline(h, `{"lines":[{"index":0,"text":"同時送出"}]}`)
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	ok := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusConflict:
+		default:
+			t.Errorf("非預期的狀態碼 %d", code)
+		}
+	}
+	if ok != 1 {
+		t.Errorf("成功的請求 = %d，期望只有 1 個", ok)
+	}
+	q.Push("sentinel")
+	if id := q.Pop(); id != "job1" {
+		t.Errorf("佇列第一個任務 = %q，期望 job1", id)
+	}
+	if id := q.Pop(); id != "sentinel" {
+		t.Errorf("任務重複排入佇列，第二個為 %q", id)
+	}
+}
+
+func promote(h *Handlers) (int, string) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/job1/promote", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "job1"})
+	rr := httptest.NewRecorder()
+	h.PromoteJob(rr, req)
+	var body map[string]string
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	return rr.Code, body["id"]
+}
+
+func TestPromoteReviewedPreview(t *testing.T) {
+	h, q, rec := reviewJob(t)
+	rec.Request.Preview.Enabled = true
+	rec.Request.Review = true
+	if code, _ := promote(h); code != http.StatusConflict {
+		t.Errorf("審閱中的草稿轉正式輸出狀態碼 = %d，期望 409", code)
+	}
+
+	if code := patchTimeline(h, `{"lines":[{"index":1,"text":"改過的第二句"}]}`); code != http.StatusOK {
+		t.Fatalf("送出審閱狀態碼 = %d", code)
+	}
+	q.Pop()
+	rec.Status = job.StatusSuccess
+	code, id := promote(h)
+	if code != http.StatusCreated {
+		t.Fatalf("轉正式輸出狀態碼 = %d，期望 201", code)
+	}
+	promoted, err := h.Store.GetJob(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !promoted.Reviewed || promoted.Request.Preview.Enabled {
+		t.Errorf("正式任務應沿用審閱結果且不是草稿: reviewed=%v preview=%v", promoted.Reviewed, promoted.Request.Preview.Enabled)
+	}
+	// 審閱修改隨時間軸帶到正式任務，不會重新斷句或再次等待審閱
+	tl, err := media.LoadTimeline(promoted.BasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Lines[1].Text != "改過的第二句" || !tl.Lines[0].Dirty || !tl.Lines[1].Dirty {
+		t.Errorf("正式任務的時間軸 = %+v", tl.Lines)
+	}
+	if id := q.Pop(); id != promoted.ID {
+		t.Errorf("佇列中的任務 = %q，期望 %q", id, promoted.ID)
+	}
+}
+
+func TestPromoteTruncatedReviewedPreview(t *testing.T) {
+	h, _, rec := reviewJob(t)
+	rec.Request.Preview = job.PreviewSetting{Enabled: true, MaxSeconds: 10}
+	rec.Reviewed = true
+	rec.Status = job.StatusSuccess
+	if code, _ := promote(h); code != http.StatusConflict {
+		t.Errorf("只輸出前段的草稿轉正式輸出狀態碼 = %d，期望 409", code)
+	}
+}
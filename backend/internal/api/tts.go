@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// maxPreviewRunes 試聽文字的長度上限
+const maxPreviewRunes = 200
+
+// previewSamples 各語言的預設試聽句
+var previewSamples = map[string]string{
+	"zh": "歡迎使用短影音產生器，這是語音試聽。",
+	"ja": "こんにちは。これは音声のサンプルです。",
+	"ko": "안녕하세요. 음성 미리 듣기입니다.",
+	"en": "Hello! This is a preview of the selected voice.",
+}
+
+// VoicePreviewRequest 語音試聽參數
+type VoicePreviewRequest struct {
+	Provider string  `json:"provider"`
+	Voice    string  `json:"voice"`
+	Locale   string  `json:"locale"`
+	Speed    float64 `json:"speed"`
+	Pitch    float64 `json:"pitch"`
+	Text     string  `json:"text"`   // 未填依語系使用預設句子
+	Markup   bool    `json:"markup"` // text 使用行內標記
+}
+
+// ListVoices 列出 TTS 語音，可依 provider、locale、gender 篩選；refresh=true 略過快取
+func (h *Handlers) ListVoices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := tts.VoiceFilter{
+		Provider: q.Get("provider"),
+		Locale:   q.Get("locale"),
+		Gender:   q.Get("gender"),
+	}
+	if filter.Provider != "" {
+		if ok, reason := tts.ProviderAvailable(h.Config, filter.Provider); !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": reason})
+			return
+		}
+	}
+
+	voices, errs := h.Catalog.Search(filter, q.Get("refresh") == "true")
+	if filter.Provider != "" && len(errs) > 0 {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": errs[filter.Provider]})
+		return
+	}
+	resp := map[string]interface{}{
+		"data":             voices,
+		"providers":        tts.Providers(h.Config),
+		"default_provider": tts.DefaultProvider(h.Config),
+	}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListTTSProviders 列出 TTS provider 與是否已設定可用
+func (h *Handlers) ListTTSProviders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":             tts.Providers(h.Config),
+		"default_provider": tts.DefaultProvider(h.Config),
+	})
+}
+
+// PreviewVoice 以指定語音、語速與音高合成一小段試聽音訊
+func (h *Handlers) PreviewVoice(w http.ResponseWriter, r *http.Request) {
+	var req VoicePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "請提供有效JSON"})
+		return
+	}
+	if req.Provider == "" {
+		req.Provider = tts.DefaultProvider(h.Config)
+	}
+	if ok, reason := tts.ProviderAvailable(h.Config, req.Provider); !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": reason})
+		return
+	}
+	if req.Locale == "" {
+		req.Locale = voiceLocale(req.Voice)
+	}
+	if req.Speed == 0 {
+		req.Speed = 1.0
+	}
+	if req.Speed < 0.5 || req.Speed > 2 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "speed 需介於 0.5 與 2 之間"})
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		req.Text = previewSample(req.Locale)
+	}
+	if utf8.RuneCountInString(req.Text) > maxPreviewRunes {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("試聽文字不可超過 %d 字", maxPreviewRunes)})
+		return
+	}
+	text := req.Text
+	if !req.Markup {
+		text = tts.EscapeMarkup(text)
+	}
+
+	// 不使用備援：試聽的就是指定的 provider 與語音
+	chain, err := tts.NewChain(h.Config, req.Provider, nil, "")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	res, err := chain.SynthesizeLine(text, req.Voice, req.Locale, req.Speed, req.Pitch)
+	if err != nil {
+		log.Error().Err(err).Str("provider", req.Provider).Str("voice", req.Voice).Msg("語音試聽合成失敗")
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	// 快取中的檔案由快取管理；未快取 (快取關閉或寫入失敗) 時是 provider 的暫存檔，回應後刪除
+	if !tts.IsCached(h.Config, res.Path) {
+		defer os.Remove(res.Path)
+	}
+
+	contentType := "audio/wav"
+	if strings.EqualFold(filepath.Ext(res.Path), ".mp3") {
+		contentType = "audio/mpeg"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-TTS-Provider", res.Provider)
+	http.ServeFile(w, r, res.Path)
+}
+
+// voiceLocale 由語音名稱推得語系，例如 zh-TW-HsiaoChenNeural → zh-TW
+func voiceLocale(voice string) string {
+	parts := strings.SplitN(voice, "-", 3)
+	if len(parts) == 3 {
+		return parts[0] + "-" + parts[1]
+	}
+	return "zh-TW"
+}
+
+// previewSample 依語系挑選預設試聽句
+func previewSample(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	if s, ok := previewSamples[lang]; ok {
+		return s
+	}
+	return previewSamples["en"]
+}
@@ -2,19 +2,58 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port        string
-	StoragePath string
-	AzureKey    string
-	AzureRegion string
-	FreeTTSPath string
-	BgmPath     string
-	GeminiKey   string
-	AIModel     string
+	Port         string
+	StoragePath  string
+	AzureKey     string
+	AzureRegion  string
+	GoogleTTSKey string // Google Cloud Text-to-Speech API key
+	// Amazon Polly 憑證與區域
+	PollyAccessKey    string
+	PollySecretKey    string
+	PollySessionToken string
+	PollyRegion       string
+	PollyEngine       string
+	ElevenLabsKey     string
+	ElevenLabsModel   string
+	// OpenAI 相容的 /audio/speech 服務
+	OpenAITTSKey     string
+	OpenAITTSBaseURL string
+	OpenAITTSModel   string
+	OpenAITTSVoices  []string
+	FreeTTSPath      string
+	BgmPath          string
+	GeminiKey        string
+	AIModel          string
+	WhisperBin       string  // whisper.cpp 執行檔，用於自備旁白的字幕對齊 (可選)
+	WhisperModel     string  // whisper.cpp 模型檔路徑
+	LoudnessLUFS     float64 // 最終混音的預設響度目標 (EBU R128)
+	TruePeak         float64 // 響度正規化的最大真峰值 (dBTP)
+	TTSCache         bool    // 快取 TTS 合成結果，相同文字與語音重複使用
+	// TTSCacheMaxMB 快取總大小上限 (MB)，超過時由最久未使用的開始清除，0 表示不限
+	TTSCacheMaxMB int
+	// TTSCacheMaxAge 快取結果超過此時間未使用即清除，0 表示不限
+	TTSCacheMaxAge time.Duration
+	// TTSFallback 主要 provider 失敗時依序改用的 provider；
+	// local (espeak-ng) 音質較差，需明確加入才會使用
+	TTSFallback []string
+	// TTSRateLimits 各 provider 每秒最多請求數，未設定表示不限流
+	TTSRateLimits map[string]float64
+	// TTSMaxRetries 暫時性錯誤 (429、5xx、逾時) 的重試次數
+	TTSMaxRetries int
+	// TTSConcurrency 各 provider 同時合成的句數上限，未設定為 2
+	TTSConcurrency map[string]int
+	// TTSDefaultProvider 未指定 provider 時使用，空白表示自動挑選第一個可用的
+	TTSDefaultProvider string
+	// TTSVoiceCacheTTL 語音清單快取時間
+	TTSVoiceCacheTTL time.Duration
 }
 
 func Load() (*Config, error) {
@@ -22,18 +61,63 @@ func Load() (*Config, error) {
 	viper.SetDefault("STORAGE_PATH", "/data")
 	viper.SetDefault("BGM_PATH", "/assets/bgm")
 	viper.SetDefault("AI_MODEL", "gemini-2.0-flash")
+	viper.SetDefault("LOUDNESS_TARGET", -14.0)
+	viper.SetDefault("LOUDNESS_TRUE_PEAK", -1.5)
+	viper.SetDefault("TTS_CACHE", true)
+	viper.SetDefault("TTS_CACHE_MAX_MB", 2048)
+	viper.SetDefault("TTS_CACHE_MAX_AGE", "720h")
+	viper.SetDefault("TTS_FALLBACK", "edge_tts")
+	viper.SetDefault("TTS_RATE_LIMIT", "azure_v1=10,azure_v2=10,edge_tts=3")
+	viper.SetDefault("TTS_MAX_RETRIES", 3)
+	viper.SetDefault("TTS_CONCURRENCY", "azure_v1=4,azure_v2=4,edge_tts=2,local=2")
+	viper.SetDefault("TTS_VOICE_CACHE_TTL", "6h")
+	viper.SetDefault("POLLY_REGION", "us-east-1")
+	viper.SetDefault("POLLY_ENGINE", "neural")
+	viper.SetDefault("ELEVENLABS_MODEL", "eleven_multilingual_v2")
+	viper.SetDefault("OPENAI_TTS_BASE_URL", "https://api.openai.com/v1")
+	viper.SetDefault("OPENAI_TTS_MODEL", "tts-1")
 
 	viper.AutomaticEnv()
 
 	cfg := &Config{
-		Port:        viper.GetString("PORT"),
-		StoragePath: viper.GetString("STORAGE_PATH"),
-		AzureKey:    viper.GetString("AZURE_TTS_KEY"),
-		AzureRegion: viper.GetString("AZURE_TTS_REGION"),
-		FreeTTSPath: viper.GetString("FREE_TTS_MODEL_PATH"),
-		BgmPath:     viper.GetString("BGM_PATH"),
-		GeminiKey:   viper.GetString("GEMINI_API_KEY"),
-		AIModel:     viper.GetString("AI_MODEL"),
+		Port:          viper.GetString("PORT"),
+		StoragePath:   viper.GetString("STORAGE_PATH"),
+		AzureKey:      viper.GetString("AZURE_TTS_KEY"),
+		AzureRegion:   viper.GetString("AZURE_TTS_REGION"),
+		GoogleTTSKey:  viper.GetString("GOOGLE_TTS_KEY"),
+		FreeTTSPath:   viper.GetString("FREE_TTS_MODEL_PATH"),
+		BgmPath:       viper.GetString("BGM_PATH"),
+		GeminiKey:     viper.GetString("GEMINI_API_KEY"),
+		AIModel:       viper.GetString("AI_MODEL"),
+		WhisperBin:    viper.GetString("WHISPER_BIN"),
+		WhisperModel:  viper.GetString("WHISPER_MODEL"),
+		LoudnessLUFS:  viper.GetFloat64("LOUDNESS_TARGET"),
+		TruePeak:      viper.GetFloat64("LOUDNESS_TRUE_PEAK"),
+		TTSCache:      viper.GetBool("TTS_CACHE"),
+		TTSFallback:   splitList(viper.GetString("TTS_FALLBACK")),
+		TTSRateLimits: parseRates(viper.GetString("TTS_RATE_LIMIT")),
+		TTSMaxRetries: viper.GetInt("TTS_MAX_RETRIES"),
+
+		TTSCacheMaxMB:      viper.GetInt("TTS_CACHE_MAX_MB"),
+		TTSCacheMaxAge:     viper.GetDuration("TTS_CACHE_MAX_AGE"),
+		TTSDefaultProvider: viper.GetString("TTS_DEFAULT_PROVIDER"),
+		TTSVoiceCacheTTL:   viper.GetDuration("TTS_VOICE_CACHE_TTL"),
+
+		PollyAccessKey:    viper.GetString("AWS_ACCESS_KEY_ID"),
+		PollySecretKey:    viper.GetString("AWS_SECRET_ACCESS_KEY"),
+		PollySessionToken: viper.GetString("AWS_SESSION_TOKEN"),
+		PollyRegion:       viper.GetString("POLLY_REGION"),
+		PollyEngine:       viper.GetString("POLLY_ENGINE"),
+		ElevenLabsKey:     viper.GetString("ELEVENLABS_API_KEY"),
+		ElevenLabsModel:   viper.GetString("ELEVENLABS_MODEL"),
+		OpenAITTSKey:      viper.GetString("OPENAI_TTS_KEY"),
+		OpenAITTSBaseURL:  viper.GetString("OPENAI_TTS_BASE_URL"),
+		OpenAITTSModel:    viper.GetString("OPENAI_TTS_MODEL"),
+		OpenAITTSVoices:   splitList(viper.GetString("OPENAI_TTS_VOICES")),
+	}
+	cfg.TTSConcurrency = map[string]int{}
+	for name, n := range parseRates(viper.GetString("TTS_CONCURRENCY")) {
+		cfg.TTSConcurrency[name] = int(n)
 	}
 
 	if err := os.MkdirAll(cfg.StoragePath, 0o755); err != nil {
@@ -41,3 +125,37 @@ func Load() (*Config, error) {
 	}
 	return cfg, nil
 }
+
+// splitList 解析逗號分隔的清單
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseRates 解析 "name=value,name=value" 格式的 provider 設定
+func parseRates(s string) map[string]float64 {
+	rates := map[string]float64{}
+	for _, item := range splitList(s) {
+		name, v, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if rate, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			rates[strings.TrimSpace(name)] = rate
+		}
+	}
+	return rates
+}
+
+// Concurrency 取得 provider 的並行上限
+func (c *Config) Concurrency(provider string) int {
+	if n := c.TTSConcurrency[provider]; n > 0 {
+		return n
+	}
+	return 2
+}
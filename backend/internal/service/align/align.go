@@ -0,0 +1,147 @@
+package align
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+)
+
+// Interval 一段有人聲的區間 (秒)
+type Interval struct {
+	Start float64
+	End   float64
+}
+
+// Aligner 將字幕行對齊到旁白音檔，回傳每行的顯示長度 (秒)
+// 長度依序相加等於音檔總長，可直接交給 utils.BuildTimelineFloat
+type Aligner interface {
+	Align(audioPath string, lines []string) ([]float64, error)
+}
+
+func GetAligner(name string, cfg *config.Config) (Aligner, error) {
+	switch name {
+	case "", "energy":
+		return &EnergyAligner{}, nil
+	case "whisper":
+		if cfg.WhisperBin == "" || cfg.WhisperModel == "" {
+			return nil, fmt.Errorf("whisper aligner 需要設定 WHISPER_BIN 與 WHISPER_MODEL")
+		}
+		return &WhisperAligner{Bin: cfg.WhisperBin, Model: cfg.WhisperModel}, nil
+	default:
+		return nil, fmt.Errorf("未知的 aligner: %s", name)
+	}
+}
+
+// snapTolerance 分界點距離靜音區間多近 (秒) 時吸附到靜音中點
+const snapTolerance = 0.6
+
+// minLineSec 每行最短顯示時間
+const minLineSec = 0.3
+
+// alignByWeight 依各行字數比例把人聲時間分配給字幕行，再把分界點吸附到最近的停頓
+func alignByWeight(lines []string, speech []Interval, total float64) []float64 {
+	n := len(lines)
+	if n == 0 {
+		return nil
+	}
+	if len(speech) == 0 {
+		speech = []Interval{{Start: 0, End: total}}
+	}
+
+	weights := make([]float64, n)
+	var sumWeight float64
+	for i, l := range lines {
+		w := 0
+		for _, r := range l {
+			if !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+				w++
+			}
+		}
+		if w == 0 {
+			w = 1
+		}
+		weights[i] = float64(w)
+		sumWeight += weights[i]
+	}
+
+	var speechTotal float64
+	for _, s := range speech {
+		speechTotal += s.End - s.Start
+	}
+
+	// 靜音區間 = 相鄰人聲區間之間的空隙
+	var gaps []Interval
+	for i := 1; i < len(speech); i++ {
+		gaps = append(gaps, Interval{Start: speech[i-1].End, End: speech[i].Start})
+	}
+
+	bounds := make([]float64, n+1)
+	bounds[n] = total
+	var cum float64
+	for i := 0; i < n-1; i++ {
+		cum += weights[i]
+		t := speechTimeToAbsolute(speech, cum/sumWeight*speechTotal)
+		t = snapToGap(t, gaps)
+		if t < bounds[i]+minLineSec {
+			t = bounds[i] + minLineSec
+		}
+		bounds[i+1] = t
+	}
+
+	durations := make([]float64, n)
+	for i := 0; i < n; i++ {
+		d := bounds[i+1] - bounds[i]
+		if d < 0 {
+			d = 0
+		}
+		durations[i] = d
+	}
+	return durations
+}
+
+// speechTimeToAbsolute 將「只計算人聲」的時間位置換算為音檔上的絕對時間
+func speechTimeToAbsolute(speech []Interval, pos float64) float64 {
+	for _, s := range speech {
+		l := s.End - s.Start
+		if pos <= l {
+			return s.Start + pos
+		}
+		pos -= l
+	}
+	return speech[len(speech)-1].End
+}
+
+// snapToGap 若時間點落在停頓附近，吸附到停頓中點，讓字幕在換氣時切換
+func snapToGap(t float64, gaps []Interval) float64 {
+	best := t
+	bestDist := snapTolerance
+	for _, g := range gaps {
+		mid := (g.Start + g.End) / 2
+		var dist float64
+		switch {
+		case t < g.Start:
+			dist = g.Start - t
+		case t > g.End:
+			dist = t - g.End
+		}
+		if dist <= bestDist {
+			best = mid
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// normalizeText 移除空白與標點，供比對字數使用
+func normalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
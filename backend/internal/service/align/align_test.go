@@ -0,0 +1,54 @@
+package align
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpeechFromSilences(t *testing.T) {
+	out := `[silencedetect @ 0x1] silence_start: 0
+[silencedetect @ 0x1] silence_end: 0.4 | silence_duration: 0.4
+[silencedetect @ 0x1] silence_start: 2.1
+[silencedetect @ 0x1] silence_end: 2.6 | silence_duration: 0.5
+[silencedetect @ 0x1] silence_start: 5.5`
+	got := speechFromSilences(out, 6.0)
+	want := []Interval{{0.4, 2.1}, {2.6, 5.5}}
+	if len(got) != len(want) {
+		t.Fatalf("期望 %v 得到 %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("期望 %v 得到 %v", want, got)
+		}
+	}
+}
+
+func TestAlignByWeightSnapsToPause(t *testing.T) {
+	// 兩句字數相同，人聲 0.4~2.1 與 2.6~4.3，分界點應吸附到停頓中點 2.35
+	lines := []string{"第一句話啊", "第二句話啊"}
+	speech := []Interval{{0.4, 2.1}, {2.6, 4.3}}
+	durs := alignByWeight(lines, speech, 5.0)
+	if len(durs) != 2 {
+		t.Fatalf("應有 2 個長度，得到 %v", durs)
+	}
+	if math.Abs(durs[0]-2.35) > 1e-6 {
+		t.Errorf("第一句長度應為 2.35，得到 %v", durs[0])
+	}
+	if math.Abs(durs[0]+durs[1]-5.0) > 1e-6 {
+		t.Errorf("總長度應等於音檔長度，得到 %v", durs[0]+durs[1])
+	}
+}
+
+func TestAlignByTranscript(t *testing.T) {
+	segs := []whisperSegment{
+		{Interval{0.5, 2.0}, "hello world"},
+		{Interval{3.0, 4.0}, "bye now"},
+	}
+	durs := alignByTranscript([]string{"Hello, world!", "Bye now."}, segs, 4.5)
+	if math.Abs(durs[0]-2.5) > 1e-6 {
+		t.Errorf("第一句應在停頓中點 2.5 結束，得到 %v", durs[0])
+	}
+	if math.Abs(durs[1]-2.0) > 1e-6 {
+		t.Errorf("第二句長度應為 2.0，得到 %v", durs[1])
+	}
+}
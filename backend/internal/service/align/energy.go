@@ -0,0 +1,71 @@
+package align
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// EnergyAligner 以 ffmpeg silencedetect 找出停頓，依字數比例把字幕分配到人聲區間
+type EnergyAligner struct {
+	NoiseDB     float64 // 低於此音量視為靜音，預設 -35dB
+	MinSilenceS float64 // 停頓最短長度，預設 0.25 秒
+}
+
+var (
+	silenceStartRe = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndRe   = regexp.MustCompile(`silence_end:\s*(-?[\d.]+)`)
+)
+
+func (e *EnergyAligner) Align(audioPath string, lines []string) ([]float64, error) {
+	total, err := utils.AudioDurationSeconds(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("無法取得旁白長度: %w", err)
+	}
+	noise := e.NoiseDB
+	if noise == 0 {
+		noise = -35
+	}
+	minSilence := e.MinSilenceS
+	if minSilence == 0 {
+		minSilence = 0.25
+	}
+
+	filter := fmt.Sprintf("silencedetect=noise=%.0fdB:d=%.2f", noise, minSilence)
+	out, err := utils.RunCmdTimeout(2*time.Minute, "ffmpeg", "-i", audioPath, "-af", filter, "-f", "null", "-")
+	if err != nil {
+		return nil, fmt.Errorf("偵測旁白停頓失敗: %v / %s", err, out)
+	}
+	speech := speechFromSilences(out, total)
+	return alignByWeight(lines, speech, total), nil
+}
+
+// speechFromSilences 解析 silencedetect 輸出，回傳靜音之間的人聲區間
+func speechFromSilences(output string, total float64) []Interval {
+	starts := silenceStartRe.FindAllStringSubmatch(output, -1)
+	ends := silenceEndRe.FindAllStringSubmatch(output, -1)
+
+	var speech []Interval
+	cursor := 0.0
+	for i, m := range starts {
+		s, _ := strconv.ParseFloat(m[1], 64)
+		if s < 0 {
+			s = 0
+		}
+		if s > cursor {
+			speech = append(speech, Interval{Start: cursor, End: s})
+		}
+		// 檔尾的靜音可能沒有 silence_end
+		cursor = total
+		if i < len(ends) {
+			cursor, _ = strconv.ParseFloat(ends[i][1], 64)
+		}
+	}
+	if cursor < total {
+		speech = append(speech, Interval{Start: cursor, End: total})
+	}
+	return speech
+}
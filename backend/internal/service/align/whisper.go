@@ -0,0 +1,155 @@
+package align
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// WhisperAligner 以本機 whisper.cpp 辨識旁白，依辨識文字的位置對齊字幕
+type WhisperAligner struct {
+	Bin   string
+	Model string
+}
+
+// whisperSegment 辨識結果中的一段
+type whisperSegment struct {
+	Interval
+	Text string
+}
+
+// whisper.cpp -oj 輸出格式
+type whisperOutput struct {
+	Transcription []struct {
+		Offsets struct {
+			From int `json:"from"`
+			To   int `json:"to"`
+		} `json:"offsets"`
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+func (a *WhisperAligner) Align(audioPath string, lines []string) ([]float64, error) {
+	total, err := utils.AudioDurationSeconds(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("無法取得旁白長度: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "whisper_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// whisper.cpp 只接受 16kHz wav
+	wav := filepath.Join(tmpDir, "input.wav")
+	if out, err := utils.RunCmd("ffmpeg", "-y", "-i", audioPath, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav); err != nil {
+		return nil, fmt.Errorf("轉換旁白格式失敗: %v / %s", err, out)
+	}
+	prefix := filepath.Join(tmpDir, "result")
+	if out, err := utils.RunCmdTimeout(10*time.Minute, a.Bin, "-m", a.Model, "-f", wav, "-oj", "-of", prefix); err != nil {
+		return nil, fmt.Errorf("whisper 辨識失敗: %v / %s", err, out)
+	}
+	b, err := os.ReadFile(prefix + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("讀取 whisper 結果失敗: %w", err)
+	}
+	segs, err := parseWhisperJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return alignByTranscript(lines, segs, total), nil
+}
+
+func parseWhisperJSON(b []byte) ([]whisperSegment, error) {
+	var out whisperOutput
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("解析 whisper 結果失敗: %w", err)
+	}
+	var segs []whisperSegment
+	for _, t := range out.Transcription {
+		text := strings.TrimSpace(t.Text)
+		if text == "" {
+			continue
+		}
+		segs = append(segs, whisperSegment{
+			Interval: Interval{Start: float64(t.Offsets.From) / 1000, End: float64(t.Offsets.To) / 1000},
+			Text:     text,
+		})
+	}
+	return segs, nil
+}
+
+// alignByTranscript 以辨識文字的字數位置換算時間：字幕第 k 行結束於全文第 p 個字，
+// 找出辨識結果中第 p 個字所在的段落並於段內線性插值
+func alignByTranscript(lines []string, segs []whisperSegment, total float64) []float64 {
+	if len(segs) == 0 {
+		return alignByWeight(lines, nil, total)
+	}
+
+	segChars := make([]int, len(segs))
+	transcriptChars := 0
+	for i, s := range segs {
+		segChars[i] = len([]rune(normalizeText(s.Text)))
+		transcriptChars += segChars[i]
+	}
+	lineChars := 0
+	for _, l := range lines {
+		lineChars += len([]rune(normalizeText(l)))
+	}
+	if transcriptChars == 0 || lineChars == 0 {
+		speech := make([]Interval, 0, len(segs))
+		for _, s := range segs {
+			speech = append(speech, s.Interval)
+		}
+		return alignByWeight(lines, speech, total)
+	}
+
+	var gaps []Interval
+	for i := 1; i < len(segs); i++ {
+		if segs[i].Start > segs[i-1].End {
+			gaps = append(gaps, Interval{Start: segs[i-1].End, End: segs[i].Start})
+		}
+	}
+
+	n := len(lines)
+	bounds := make([]float64, n+1)
+	bounds[n] = total
+	cum := 0
+	for i := 0; i < n-1; i++ {
+		cum += len([]rune(normalizeText(lines[i])))
+		pos := float64(cum) / float64(lineChars) * float64(transcriptChars)
+
+		t := segs[len(segs)-1].End
+		for j, s := range segs {
+			c := float64(segChars[j])
+			if pos <= c {
+				if c > 0 {
+					t = s.Start + pos/c*(s.End-s.Start)
+				} else {
+					t = s.Start
+				}
+				break
+			}
+			pos -= c
+		}
+		t = snapToGap(t, gaps)
+		if t < bounds[i]+minLineSec {
+			t = bounds[i] + minLineSec
+		}
+		bounds[i+1] = t
+	}
+
+	durations := make([]float64, n)
+	for i := range durations {
+		if d := bounds[i+1] - bounds[i]; d > 0 {
+			durations[i] = d
+		}
+	}
+	return durations
+}
@@ -0,0 +1,71 @@
+package job
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BilingualSetting 雙語字幕：每句字幕下方加上第二行 (譯文或使用者提供的對照稿)，
+// 第二行樣式見 subtitle_style.secondary
+type BilingualSetting struct {
+	Locale string `json:"locale"` // 第二行的語系，未提供對照稿時以 AI 逐句翻譯成此語系
+	Script string `json:"script"` // 使用者提供的對照稿，依句子與字幕對齊；提供時不使用 AI 翻譯
+}
+
+// Enabled 是否輸出雙語字幕
+func (b BilingualSetting) Enabled() bool {
+	return strings.TrimSpace(b.Locale) != "" || strings.TrimSpace(b.Script) != ""
+}
+
+func (b *BilingualSetting) validate() error {
+	b.Locale = strings.TrimSpace(b.Locale)
+	if b.Locale != "" && !localePattern.MatchString(b.Locale) {
+		return fmt.Errorf("bilingual.locale 格式錯誤，例如 en-US")
+	}
+	return nil
+}
+
+// SecondaryStyle 雙語字幕第二行的樣式，未填的欄位沿用主要字幕
+type SecondaryStyle struct {
+	Font         string  `json:"font"`
+	Size         int     `json:"size"`  // 預設為主要字幕的 75%
+	Color        string  `json:"color"` // 文字顏色 (Hex)
+	OutlineWidth float64 `json:"outline_width"`
+	OutlineColor string  `json:"outline_color"`
+	Bold         bool    `json:"bold"`
+	Italic       bool    `json:"italic"`
+	MaxLineWidth int     `json:"max_line_width"` // 每行字數上限，未填依安全區寬度換行
+}
+
+// SecondaryLine 回傳第二行使用的完整樣式：以主要字幕為基礎套用 secondary 的設定，
+// 關鍵字強調只用於主要字幕
+func (s SubtitleStyle) SecondaryLine() SubtitleStyle {
+	out := s
+	out.Secondary = nil
+	out.Keywords = nil
+	out.Size = s.Size * 3 / 4
+	out.MaxLineWidth = 0
+	out.Bold, out.Italic = false, false
+	sec := s.Secondary
+	if sec == nil {
+		return out
+	}
+	if sec.Font != "" {
+		out.Font = sec.Font
+	}
+	if sec.Size > 0 {
+		out.Size = sec.Size
+	}
+	if sec.Color != "" {
+		out.Color = sec.Color
+	}
+	if sec.OutlineWidth > 0 {
+		out.OutlineWidth = sec.OutlineWidth
+	}
+	if sec.OutlineColor != "" {
+		out.OutlineColor = sec.OutlineColor
+	}
+	out.Bold, out.Italic = sec.Bold, sec.Italic
+	out.MaxLineWidth = sec.MaxLineWidth
+	return out
+}
@@ -0,0 +1,36 @@
+package job
+
+import "fmt"
+
+// CoverSetting 封面設定：任務成功後自動挑選最佳畫面，並以字幕樣式加上標題
+type CoverSetting struct {
+	Disabled bool           `json:"disabled"` // 不產生縮圖與封面
+	Title    string         `json:"title"`    // 封面標題，未填使用第一句字幕
+	NoTitle  bool           `json:"no_title"` // 封面只放畫面，不加標題
+	Style    *SubtitleStyle `json:"style"`    // 標題樣式，未填沿用字幕樣式並放大置中
+}
+
+// validate 檢查封面標題樣式
+func (c *CoverSetting) validate() error {
+	if c.Style == nil {
+		return nil
+	}
+	if err := c.Style.Validate(); err != nil {
+		return fmt.Errorf("cover.style: %w", err)
+	}
+	return nil
+}
+
+// CoverStyle 回傳封面標題使用的樣式
+func (r *JobCreateRequest) CoverStyle() SubtitleStyle {
+	if r.Cover.Style != nil {
+		return *r.Cover.Style
+	}
+	s := r.SubtitleStyle
+	s.Size = s.Size * 9 / 5
+	s.Bold = true
+	s.Position = "middle"
+	s.Align = "center"
+	s.Animation = "none"
+	return s
+}
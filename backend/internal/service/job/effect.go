@@ -0,0 +1,85 @@
+package job
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EffectInfo 運鏡特效說明
+type EffectInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parametric  bool   `json:"parametric"` // 是否支援 effect_params (縮放、焦點、緩動)
+}
+
+// Effects 圖片素材支援的運鏡特效
+var Effects = []EffectInfo{
+	{"none", "靜止", false},
+	{"ken_burns", "緩慢推近焦點 (預設自動偵測畫面重點)", true},
+	{"zoom_in", "由中心放大", true},
+	{"zoom_out", "由中心縮小", true},
+	{"pan_left", "向左平移", true},
+	{"pan_right", "向右平移", true},
+	{"pan_up", "向上平移", true},
+	{"pan_down", "向下平移", true},
+	{"diagonal_pan", "對角平移", true},
+	{"rotate", "輕微搖擺", false},
+	{"shake", "晃動", false},
+	{"random", "每張圖片隨機挑選不重複的運鏡", true},
+}
+
+// Easings 運鏡緩動曲線
+var Easings = []string{"linear", "ease_in", "ease_out", "ease_in_out"}
+
+// FocusModes 運鏡焦點模式
+var FocusModes = []string{"center", "auto", "manual"}
+
+// EffectParams 運鏡參數，未填的欄位使用各特效的預設值
+type EffectParams struct {
+	ZoomStart float64 `json:"zoom_start"` // 起始縮放倍率 (1~3)
+	ZoomEnd   float64 `json:"zoom_end"`   // 結束縮放倍率 (1~3)
+	Focus     string  `json:"focus"`      // center、auto (偵測畫面重點)、manual；ken_burns 預設 auto，其餘預設 center
+	FocusX    float64 `json:"focus_x"`    // manual 焦點水平位置 0~1
+	FocusY    float64 `json:"focus_y"`    // manual 焦點垂直位置 0~1
+	Easing    string  `json:"easing"`     // linear (預設)、ease_in、ease_out、ease_in_out
+}
+
+// IsEffect 檢查運鏡特效名稱，空字串視為 none
+func IsEffect(name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, e := range Effects {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEffect 檢查素材的運鏡設定
+func validateEffect(i int, m *Material) error {
+	if !IsEffect(m.Effect) {
+		return fmt.Errorf("materials[%d].effect %q 不支援，請參考 GET /api/v1/effects", i, m.Effect)
+	}
+	p := &m.EffectParams
+	for _, z := range []float64{p.ZoomStart, p.ZoomEnd} {
+		if z != 0 && (z < 1 || z > 3) {
+			return fmt.Errorf("materials[%d].effect_params 的縮放倍率必須介於 1 到 3", i)
+		}
+	}
+	if p.Focus != "" && !slices.Contains(FocusModes, p.Focus) {
+		return fmt.Errorf("materials[%d].effect_params.focus must be one of %s", i, strings.Join(FocusModes, ", "))
+	}
+	if p.Focus == "manual" && (p.FocusX < 0 || p.FocusX > 1 || p.FocusY < 0 || p.FocusY > 1) {
+		return fmt.Errorf("materials[%d].effect_params.focus_x/focus_y must be between 0 and 1", i)
+	}
+	if p.Easing == "" {
+		p.Easing = "linear"
+	}
+	if !slices.Contains(Easings, p.Easing) {
+		return fmt.Errorf("materials[%d].effect_params.easing must be one of %s", i, strings.Join(Easings, ", "))
+	}
+	return nil
+}
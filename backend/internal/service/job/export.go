@@ -0,0 +1,75 @@
+package job
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExportFormats 除 MP4 以外可額外輸出的格式
+var ExportFormats = []string{"gif", "webp", "mp3", "m4a"}
+
+// maxTeaserSec 動圖預告最長秒數，避免檔案過大
+const maxTeaserSec = 15.0
+
+// ExportSetting 額外輸出：gif/webp 為循環播放的預告動圖，mp3/m4a 為含章節的純音訊 (旁白 + BGM)
+type ExportSetting struct {
+	Format      string  `json:"format"`
+	StartSec    float64 `json:"start_sec"`    // gif/webp：從第幾秒開始
+	DurationSec float64 `json:"duration_sec"` // gif/webp：長度，預設 5，最長 15
+	Width       int     `json:"width"`        // gif/webp：寬度 (px)，預設 360
+	FPS         int     `json:"fps"`          // gif/webp：幀率，預設 12
+}
+
+// IsAnimated 是否為動圖輸出
+func (e ExportSetting) IsAnimated() bool {
+	return e.Format == "gif" || e.Format == "webp"
+}
+
+// ExportResult 額外輸出的結果
+type ExportResult struct {
+	Format string `json:"format"`
+	URL    string `json:"url,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// validateExports 檢查額外輸出並補上預設值
+func validateExports(exports []ExportSetting) error {
+	seen := map[string]bool{}
+	for i := range exports {
+		e := &exports[i]
+		e.Format = strings.ToLower(e.Format)
+		if !slices.Contains(ExportFormats, e.Format) {
+			return fmt.Errorf("exports[%d].format must be one of %s", i, strings.Join(ExportFormats, ", "))
+		}
+		if seen[e.Format] {
+			return fmt.Errorf("exports[%d].format %s 重複", i, e.Format)
+		}
+		seen[e.Format] = true
+		if !e.IsAnimated() {
+			continue
+		}
+		if e.StartSec < 0 {
+			return fmt.Errorf("exports[%d].start_sec must not be negative", i)
+		}
+		if e.DurationSec == 0 {
+			e.DurationSec = 5
+		}
+		if e.DurationSec < 0 || e.DurationSec > maxTeaserSec {
+			return fmt.Errorf("exports[%d].duration_sec must be between 0 and %.0f", i, maxTeaserSec)
+		}
+		if e.Width == 0 {
+			e.Width = 360
+		}
+		if e.Width < 64 || e.Width > 1080 {
+			return fmt.Errorf("exports[%d].width must be between 64 and 1080", i)
+		}
+		if e.FPS == 0 {
+			e.FPS = 12
+		}
+		if e.FPS < 1 || e.FPS > 30 {
+			return fmt.Errorf("exports[%d].fps must be between 1 and 30", i)
+		}
+	}
+	return nil
+}
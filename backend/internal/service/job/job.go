@@ -3,10 +3,13 @@ package job
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
 )
 
 type Material struct {
@@ -17,6 +20,21 @@ type Material struct {
 	Mute        bool    `json:"mute"`   // 是否靜音 (僅 video 有效)
 	Volume      float64 `json:"volume"` // 音量 0~1 (僅 video 且不靜音有效)
 	Effect      string  `json:"effect"` // 運鏡特效 (僅 image 有效)
+	// 運鏡參數 (僅 image 且為可調參數的特效有效)
+	EffectParams EffectParams `json:"effect_params"`
+	// 影片入出點、播放速度與倒轉 (僅 video 有效)
+	StartSec float64 `json:"start_sec"` // 入點 (秒)
+	EndSec   float64 `json:"end_sec"`   // 出點 (秒)，0 表示到素材結尾
+	Speed    float64 `json:"speed"`     // 播放速度 0.25~4，預設 1
+	Reverse  bool    `json:"reverse"`   // 倒轉播放
+	// 片段比時間槽短時：freeze (預設，停在最後一幀) 或 loop (重複播放)
+	Shortfall string `json:"shortfall"`
+	// 填滿模式：contain (加邊框)、cover (裁切填滿)、blur (模糊背景)、smart (跟隨畫面重點裁切)
+	// 空字串依 video.blur_background 決定 blur 或 contain
+	Fit string `json:"fit"`
+	// 切換到下一個素材時的轉場，空字串沿用 video.transition，none 為直接切換
+	Transition    string  `json:"transition"`
+	TransitionSec float64 `json:"transition_sec"` // 轉場長度 (秒)，0 使用預設 1 秒
 }
 
 type TTSSetting struct {
@@ -25,6 +43,12 @@ type TTSSetting struct {
 	Locale   string  `json:"locale"`
 	Speed    float64 `json:"speed"`
 	Pitch    float64 `json:"pitch"`
+	Gender   string  `json:"gender"` // Female 或 Male，改用備援 provider 時挑選語音用；未填依 voice 查詢
+	// 主要 provider 失敗時依序改用的 provider，未填使用 TTS_FALLBACK 設定
+	Fallback   []string `json:"fallback"`
+	NoFallback bool     `json:"no_fallback"` // 停用備援，失敗直接回報
+	Markup     bool     `json:"markup"`      // 腳本使用行內標記 ([pause=500ms]、[emphasis]…[/emphasis] 等)
+	Lexicon    string   `json:"lexicon"`     // 套用的團隊發音字典 (見 /lexicons/{team})
 }
 
 type VideoSetting struct {
@@ -36,11 +60,50 @@ type VideoSetting struct {
 }
 
 type BGMSetting struct {
-	Source string  `json:"source"`
-	Path   string  `json:"path"`
-	Volume float64 `json:"volume"`
+	Source    string  `json:"source"`
+	Path      string  `json:"path"`
+	Volume    float64 `json:"volume"`
+	FadeIn    float64 `json:"fade_in"`    // 淡入秒數，0 表示不淡入
+	FadeOut   float64 `json:"fade_out"`   // 淡出秒數，0 表示不淡出
+	FadeCurve string  `json:"fade_curve"` // ffmpeg afade 曲線，例如 tri、qsin、exp、log
+	DuckRatio float64 `json:"duck_ratio"` // 人聲出現時壓低 BGM 的壓縮比 (sidechain)，1 表示不壓低
+}
+
+// AudioSetting 最終混音設定
+type AudioSetting struct {
+	TargetLUFS    float64 `json:"target_lufs"`    // EBU R128 目標響度，0 使用伺服器預設
+	SkipNormalize bool    `json:"skip_normalize"` // 不做響度正規化
 }
 
+// FitModes 素材填滿畫面的方式
+var FitModes = []string{"contain", "cover", "blur", "smart"}
+
+// validateClip 檢查影片入出點與播放設定 (不含素材長度，下載素材後由 media.CheckMaterialRanges 檢查)
+func validateClip(i int, m *Material) error {
+	if m.StartSec < 0 || m.EndSec < 0 {
+		return fmt.Errorf("materials[%d].start_sec/end_sec must not be negative", i)
+	}
+	if m.EndSec != 0 && m.EndSec <= m.StartSec {
+		return fmt.Errorf("materials[%d].end_sec must be greater than start_sec", i)
+	}
+	if m.Speed == 0 {
+		m.Speed = 1
+	}
+	if m.Speed < 0.25 || m.Speed > 4 {
+		return fmt.Errorf("materials[%d].speed must be between 0.25 and 4", i)
+	}
+	if m.Shortfall == "" {
+		m.Shortfall = "freeze"
+	}
+	if m.Shortfall != "freeze" && m.Shortfall != "loop" {
+		return fmt.Errorf("materials[%d].shortfall must be freeze or loop", i)
+	}
+	return nil
+}
+
+// FadeCurves ffmpeg afade 支援的曲線
+var FadeCurves = []string{"tri", "qsin", "hsin", "esin", "log", "ipar", "qua", "cub", "squ", "cbr", "par", "exp", "iqsin", "ihsin", "dese", "desi", "losi", "sinc", "isinc", "nofade"}
+
 type SubtitleStyle struct {
 	Font         string  `json:"font"`
 	Size         int     `json:"size"`
@@ -49,50 +112,208 @@ type SubtitleStyle struct {
 	OutlineWidth float64 `json:"outline_width"`
 	OutlineColor string  `json:"outline_color"`
 	MaxLineWidth int     `json:"max_line_width"`
+
+	Bold              bool     `json:"bold"`
+	Italic            bool     `json:"italic"`
+	Shadow            float64  `json:"shadow"`             // 陰影距離
+	ShadowColor       string   `json:"shadow_color"`       // 陰影顏色 (Hex)
+	BackgroundBox     bool     `json:"background_box"`     // 文字底下加不透明底框 (ASS BorderStyle 3)
+	BackgroundColor   string   `json:"background_color"`   // 底框顏色 (Hex)，預設黑色
+	BackgroundOpacity float64  `json:"background_opacity"` // 底框不透明度 0~1，預設 0.6
+	Position          string   `json:"position"`           // bottom (預設)、middle、top
+	Align             string   `json:"align"`              // center (預設)、left、right
+	LetterSpacing     float64  `json:"letter_spacing"`     // 字距
+	Animation         string   `json:"animation"`          // 進場動畫：none (預設)、pop、fade、slide
+	AnimationMs       int      `json:"animation_ms"`       // 動畫長度 (毫秒)，預設 250
+	Keywords          []string `json:"keywords"`           // 以強調色標示的關鍵字
+	EmphasisColor     string   `json:"emphasis_color"`     // 關鍵字顏色 (Hex)，預設 FFD700
+
+	Secondary *SecondaryStyle `json:"secondary,omitempty"` // 雙語字幕第二行的樣式
+}
+
+// SubtitleAnimations 支援的字幕進場動畫
+var SubtitleAnimations = []string{"none", "pop", "fade", "slide"}
+
+// Validate 檢查字幕樣式的列舉值並補上預設值
+func (s *SubtitleStyle) Validate() error {
+	if s.Position == "" {
+		s.Position = "bottom"
+	}
+	if s.Position != "bottom" && s.Position != "middle" && s.Position != "top" {
+		return fmt.Errorf("subtitle_style.position must be bottom, middle or top")
+	}
+	if s.Align == "" {
+		s.Align = "center"
+	}
+	if s.Align != "center" && s.Align != "left" && s.Align != "right" {
+		return fmt.Errorf("subtitle_style.align must be center, left or right")
+	}
+	if s.Animation == "" {
+		s.Animation = "none"
+	}
+	if !slices.Contains(SubtitleAnimations, s.Animation) {
+		return fmt.Errorf("subtitle_style.animation must be one of %s", strings.Join(SubtitleAnimations, ", "))
+	}
+	if s.AnimationMs < 0 {
+		return fmt.Errorf("subtitle_style.animation_ms must not be negative")
+	}
+	if s.AnimationMs == 0 {
+		s.AnimationMs = 250
+	}
+	if s.BackgroundOpacity < 0 || s.BackgroundOpacity > 1 {
+		return fmt.Errorf("subtitle_style.background_opacity must be between 0 and 1")
+	}
+	if s.BackgroundOpacity == 0 {
+		s.BackgroundOpacity = 0.6
+	}
+	if s.Shadow < 0 {
+		return fmt.Errorf("subtitle_style.shadow must not be negative")
+	}
+	if sec := s.Secondary; sec != nil {
+		if sec.Size < 0 || sec.MaxLineWidth < 0 || sec.OutlineWidth < 0 {
+			return fmt.Errorf("subtitle_style.secondary size, max_line_width and outline_width must not be negative")
+		}
+	}
+	return nil
+}
+
+// TranscriptSetting 匯入已對好時間的字幕 (SRT/WebVTT) 作為腳本與時間軸
+type TranscriptSetting struct {
+	Path    string `json:"path"`    // 上傳後的字幕檔路徑
+	Content string `json:"content"` // 或直接提供字幕內容
+	Fit     string `json:"fit"`     // stretch (預設，語音過長時以 atempo 加速塞進時間窗) 或 pad (只補靜音，過長截斷)
+}
+
+// Provided 是否有提供字幕檔
+func (t TranscriptSetting) Provided() bool {
+	return strings.TrimSpace(t.Path) != "" || strings.TrimSpace(t.Content) != ""
+}
+
+// VoiceoverSetting 使用自備旁白取代 TTS，字幕以本機對齊器對齊到旁白
+type VoiceoverSetting struct {
+	Source  string `json:"source"`  // "upload" 或 "url"
+	Path    string `json:"path"`    // 上傳後的路徑或 URL
+	Aligner string `json:"aligner"` // energy (預設，依靜音切分) 或 whisper (需設定 WHISPER_BIN)
+}
+
+// Provided 是否使用自備旁白
+func (v VoiceoverSetting) Provided() bool {
+	return strings.TrimSpace(v.Path) != ""
 }
 
 type JobCreateRequest struct {
-	Script        string        `json:"script"`
-	Materials     []Material    `json:"materials"`
-	TTS           TTSSetting    `json:"tts"`
-	Video         VideoSetting  `json:"video"`
-	BGM           BGMSetting    `json:"bgm"`
-	SubtitleStyle SubtitleStyle `json:"subtitle_style"`
+	Script        string            `json:"script"`
+	Transcript    TranscriptSetting `json:"transcript"` // 提供時取代 Script，略過斷句並依字幕時間配音
+	Voiceover     VoiceoverSetting  `json:"voiceover"`  // 提供時不使用 TTS，字幕對齊到自備旁白
+	Materials     []Material        `json:"materials"`
+	TTS           TTSSetting        `json:"tts"`
+	Video         VideoSetting      `json:"video"`
+	BGM           BGMSetting        `json:"bgm"`
+	Audio         AudioSetting      `json:"audio"`
+	Overlays      []Overlay         `json:"overlays"`
+	SubtitleStyle SubtitleStyle     `json:"subtitle_style"`
+	SubtitleMode  string            `json:"subtitle_mode"` // burn (燒錄, 預設)、soft (mov_text 軟字幕軌)、none (不放入影片，僅提供字幕檔)
+	Review        bool              `json:"review"`        // 審閱模式：TTS 與字幕時間軸完成後暫停，待確認後再合成影片
+	Preview       PreviewSetting    `json:"preview"`       // 草稿預覽：低解析度快速算圖
+	Cover         CoverSetting      `json:"cover"`         // 縮圖與封面
+	Exports       []ExportSetting   `json:"exports"`       // 額外輸出 (gif、webp、mp3、m4a)
+	Pacing        PacingSetting     `json:"pacing"`        // 句間停頓與語速
+	Languages     []LanguageSetting `json:"languages"`     // 額外輸出的語言版本 (AI 翻譯後重新配音)
+	Glossary      []GlossaryTerm    `json:"glossary"`      // 翻譯時固定譯法或保留原文的詞彙
+	Bilingual     BilingualSetting  `json:"bilingual"`     // 雙語字幕 (原文 + 譯文兩行)
 }
 
+const (
+	SubtitleModeBurn = "burn"
+	SubtitleModeSoft = "soft"
+	SubtitleModeNone = "none"
+)
+
 type Status string
 
 const (
 	StatusPending  Status = "pending"
 	StatusRunning  Status = "running"
+	StatusReview   Status = "review" // 等待審閱字幕時間軸
 	StatusSuccess  Status = "success"
 	StatusFailed   Status = "failed"
 	StatusCanceled Status = "canceled"
 )
 
+// SegmentQuality 斷句品質：AI 斷句與腳本逐字比對的結果
+type SegmentQuality struct {
+	Source   string `json:"source"`   // ai 或 rules (AI 斷句失敗後改用規則斷句)
+	Attempts int    `json:"attempts"` // AI 斷句次數，含偏離原文而重新產生
+	segment.Report
+}
+
 type Record struct {
-	ID           string           `json:"id"`
-	Status       Status           `json:"status"`
-	Progress     int              `json:"progress"`
-	CreatedAt    time.Time        `json:"created_at"`
-	UpdatedAt    time.Time        `json:"updated_at"`
-	ErrorMessage string           `json:"error_message"`
-	ResultURL    string           `json:"result_url"`
-	Request      JobCreateRequest `json:"request"`
-	BasePath     string           `json:"-"`
+	ID           string            `json:"id"`
+	Status       Status            `json:"status"`
+	Progress     int               `json:"progress"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	ErrorMessage string            `json:"error_message"`
+	ResultURL    string            `json:"result_url"`
+	ThumbnailURL string            `json:"thumbnail_url,omitempty"`
+	Exports      []ExportResult    `json:"exports,omitempty"`
+	Renditions   []RenditionResult `json:"renditions,omitempty"`    // 各語言版本的輸出
+	TTSProviders map[string]int    `json:"tts_providers,omitempty"` // 各 provider 實際合成的句數
+	Segmentation *SegmentQuality   `json:"segmentation,omitempty"`  // 斷句來源與品質
+	Request      JobCreateRequest  `json:"request"`
+	Reviewed     bool              `json:"reviewed"` // 審閱模式下已送出修改，繼續合成
+	BasePath     string            `json:"-"`
+	Rendition    string            `json:"-"` // 語言版本的衍生任務 (目標語系)，只在合成時使用，不寫回儲存
 }
 
 func (r *JobCreateRequest) Validate() error {
-	if strings.TrimSpace(r.Script) == "" {
+	if strings.TrimSpace(r.Script) == "" && !r.Transcript.Provided() {
 		return errors.New("腳本不可空白")
 	}
+	if r.Transcript.Provided() {
+		if r.Transcript.Fit == "" {
+			r.Transcript.Fit = "stretch"
+		}
+		if r.Transcript.Fit != "stretch" && r.Transcript.Fit != "pad" {
+			return fmt.Errorf("transcript.fit must be stretch or pad")
+		}
+	}
+	if r.Voiceover.Provided() {
+		if r.Transcript.Provided() {
+			return errors.New("transcript 與 voiceover 不可同時使用")
+		}
+		if strings.TrimSpace(r.Script) == "" {
+			return errors.New("使用自備旁白時仍需提供腳本作為字幕")
+		}
+		if r.Voiceover.Source != "upload" && r.Voiceover.Source != "url" {
+			return fmt.Errorf("voiceover.source must be upload or url")
+		}
+		if r.Voiceover.Aligner == "" {
+			r.Voiceover.Aligner = "energy"
+		}
+		if r.Voiceover.Aligner != "energy" && r.Voiceover.Aligner != "whisper" {
+			return fmt.Errorf("voiceover.aligner must be energy or whisper")
+		}
+	}
 	if len(r.Materials) == 0 {
 		return errors.New("素材至少要有一個")
 	}
-	for _, m := range r.Materials {
-		if m.DurationSec <= 0 {
+	for i := range r.Materials {
+		if r.Materials[i].DurationSec <= 0 {
 			return errors.New("素材時長必須大於 0")
 		}
+		if err := validateEffect(i, &r.Materials[i]); err != nil {
+			return err
+		}
+		if f := r.Materials[i].Fit; f != "" && !slices.Contains(FitModes, f) {
+			return fmt.Errorf("materials[%d].fit must be one of %s", i, strings.Join(FitModes, ", "))
+		}
+		if err := validateClip(i, &r.Materials[i]); err != nil {
+			return err
+		}
+	}
+	if err := validateTransitions(r); err != nil {
+		return err
 	}
 	if r.SubtitleStyle.Size == 0 {
 		r.SubtitleStyle.Size = 36
@@ -103,9 +324,30 @@ func (r *JobCreateRequest) Validate() error {
 	if r.Video.FPS == 0 {
 		r.Video.FPS = 30
 	}
+	if err := r.Preview.validate(); err != nil {
+		return err
+	}
+	if err := r.Cover.validate(); err != nil {
+		return err
+	}
+	if err := validateExports(r.Exports); err != nil {
+		return err
+	}
+	if err := r.Pacing.validate(); err != nil {
+		return err
+	}
+	if err := r.validateLanguages(); err != nil {
+		return err
+	}
+	if err := r.Bilingual.validate(); err != nil {
+		return err
+	}
 	if r.TTS.Speed == 0 {
 		r.TTS.Speed = 1.0
 	}
+	if g := r.TTS.Gender; g != "" && !strings.EqualFold(g, "female") && !strings.EqualFold(g, "male") {
+		return fmt.Errorf("tts.gender must be Female or Male")
+	}
 	if r.BGM.Source != "upload" && r.BGM.Source != "url" && r.BGM.Source != "preset" && r.BGM.Source != "none" {
 		return fmt.Errorf("bgm.source must be upload, url, preset or none")
 	}
@@ -115,6 +357,24 @@ func (r *JobCreateRequest) Validate() error {
 	if r.BGM.Volume == 0 {
 		r.BGM.Volume = 0.25
 	}
+	if r.BGM.FadeIn < 0 || r.BGM.FadeOut < 0 {
+		return fmt.Errorf("bgm.fade_in and bgm.fade_out must not be negative")
+	}
+	if r.BGM.FadeCurve == "" {
+		r.BGM.FadeCurve = "tri"
+	}
+	if !slices.Contains(FadeCurves, r.BGM.FadeCurve) {
+		return fmt.Errorf("bgm.fade_curve must be one of %s", strings.Join(FadeCurves, ", "))
+	}
+	if r.BGM.DuckRatio == 0 {
+		r.BGM.DuckRatio = 6
+	}
+	if r.BGM.DuckRatio < 1 || r.BGM.DuckRatio > 20 {
+		return fmt.Errorf("bgm.duck_ratio must be between 1 and 20")
+	}
+	if r.Audio.TargetLUFS > 0 || r.Audio.TargetLUFS < -70 {
+		return fmt.Errorf("audio.target_lufs must be between -70 and 0")
+	}
 	if r.SubtitleStyle.Font == "" {
 		r.SubtitleStyle.Font = "NotoSansTC"
 	}
@@ -130,6 +390,18 @@ func (r *JobCreateRequest) Validate() error {
 	if r.SubtitleStyle.OutlineColor == "" {
 		r.SubtitleStyle.OutlineColor = "000000"
 	}
+	if err := r.SubtitleStyle.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateOverlays(r.Overlays); err != nil {
+		return err
+	}
+	if r.SubtitleMode == "" {
+		r.SubtitleMode = SubtitleModeBurn
+	}
+	if r.SubtitleMode != SubtitleModeBurn && r.SubtitleMode != SubtitleModeSoft && r.SubtitleMode != SubtitleModeNone {
+		return fmt.Errorf("subtitle_mode must be burn, soft or none")
+	}
 	return nil
 }
 
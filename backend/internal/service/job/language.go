@@ -0,0 +1,102 @@
+package job
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxLanguages 單一任務最多額外輸出的語言版本數
+const maxLanguages = 6
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// LanguageSetting 額外輸出的語言版本：腳本以 AI 翻譯後重新配音，沿用相同素材與設定
+type LanguageSetting struct {
+	Locale   string `json:"locale"`   // 目標語系，例如 en-US、ja-JP
+	Provider string `json:"provider"` // TTS provider，未填沿用 tts.provider
+	Voice    string `json:"voice"`    // 未填依語系從語音目錄挑選
+	Font     string `json:"font"`     // 字幕字型，未填沿用 subtitle_style.font，無法顯示譯文時自動改用其他字型
+}
+
+// GlossaryTerm 翻譯詞彙表：translations 依語系指定譯法，未指定的語系保留原文不翻譯
+type GlossaryTerm struct {
+	Term         string            `json:"term"`
+	Translations map[string]string `json:"translations"` // 語系 (en-US 或 en) → 譯文
+}
+
+// Translation 回傳 term 在指定語系的譯法，先比對完整語系再比對語言；未指定時回傳原文
+func (g GlossaryTerm) Translation(locale string) string {
+	if t, ok := g.Translations[locale]; ok && t != "" {
+		return t
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	for k, t := range g.Translations {
+		if strings.EqualFold(k, lang) && t != "" {
+			return t
+		}
+	}
+	return g.Term
+}
+
+// RenditionResult 語言版本的輸出結果
+type RenditionResult struct {
+	Locale   string `json:"locale"`
+	Provider string `json:"provider,omitempty"`
+	Voice    string `json:"voice,omitempty"`
+	Font     string `json:"font,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Pending  bool   `json:"pending,omitempty"` // 主要成品完成後才輸出，尚未完成時為 true
+}
+
+// validateLanguages 檢查語言版本與詞彙表
+func (r *JobCreateRequest) validateLanguages() error {
+	if len(r.Languages) == 0 {
+		return nil
+	}
+	if r.Transcript.Provided() || r.Voiceover.Provided() {
+		return fmt.Errorf("languages 不可與 transcript 或 voiceover 同時使用")
+	}
+	if len(r.Languages) > maxLanguages {
+		return fmt.Errorf("languages 最多 %d 個", maxLanguages)
+	}
+	seen := map[string]bool{}
+	for i := range r.Languages {
+		l := &r.Languages[i]
+		l.Locale = strings.TrimSpace(l.Locale)
+		if !localePattern.MatchString(l.Locale) {
+			return fmt.Errorf("languages[%d].locale 格式錯誤，例如 en-US", i)
+		}
+		key := strings.ToLower(l.Locale)
+		if seen[key] {
+			return fmt.Errorf("languages[%d].locale %s 重複", i, l.Locale)
+		}
+		seen[key] = true
+	}
+	for i, g := range r.Glossary {
+		if strings.TrimSpace(g.Term) == "" {
+			return fmt.Errorf("glossary[%d].term 不可空白", i)
+		}
+	}
+	return nil
+}
+
+// ForLanguage 產生語言版本使用的請求：換成譯文與目標語系的語音、字型，
+// 並關閉審閱、額外輸出與封面 (只保留主要語言的版本)
+func (r JobCreateRequest) ForLanguage(l LanguageSetting, script, provider, voice, font string) JobCreateRequest {
+	out := r
+	out.Script = script
+	out.TTS.Provider = provider
+	out.TTS.Voice = voice
+	out.TTS.Locale = l.Locale
+	out.SubtitleStyle.Font = font
+	out.Languages = nil
+	out.Glossary = nil
+	// 對照稿與第二行語系是針對原文設定的
+	out.Bilingual = BilingualSetting{}
+	out.Exports = nil
+	out.Review = false
+	out.Cover.Disabled = true
+	return out
+}
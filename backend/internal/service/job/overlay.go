@@ -0,0 +1,87 @@
+package job
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Overlay 疊加在影片上的圖片或文字圖層 (浮水印、開頭標題、片尾卡)
+type Overlay struct {
+	Type      string        `json:"type"`      // image 或 text
+	Source    string        `json:"source"`    // image 專用：upload 或 url
+	Path      string        `json:"path"`      // image 專用：上傳後的路徑或 URL
+	Text      string        `json:"text"`      // text 專用：文字內容，可用 \n 換行
+	Style     SubtitleStyle `json:"style"`     // text 專用：文字樣式，位置由 Position 決定
+	Position  string        `json:"position"`  // top-left、top、top-right、left、center、right、bottom-left、bottom、bottom-right 或 custom
+	X         int           `json:"x"`         // position 為 custom 時的左上角座標 (px)
+	Y         int           `json:"y"`         // position 為 custom 時的左上角座標 (px)
+	Width     int           `json:"width"`     // image 專用：縮放後寬度 (px)，0 為影片寬度的 20%
+	Opacity   float64       `json:"opacity"`   // 不透明度 0~1，預設 1
+	Start     float64       `json:"start"`     // 出現時間 (秒)
+	End       float64       `json:"end"`       // 消失時間 (秒)，0 表示到影片結束
+	Animation string        `json:"animation"` // none (預設)、fade；text 另支援 pop、slide
+}
+
+// OverlayPositions 疊加層支援的位置
+var OverlayPositions = []string{"top-left", "top", "top-right", "left", "center", "right", "bottom-left", "bottom", "bottom-right", "custom"}
+
+// maxOverlays 單一任務最多的疊加層數
+const maxOverlays = 10
+
+// ValidateOverlays 檢查疊加層設定並補上預設值
+func ValidateOverlays(overlays []Overlay) error {
+	if len(overlays) > maxOverlays {
+		return fmt.Errorf("overlays 最多 %d 個", maxOverlays)
+	}
+	for i := range overlays {
+		o := &overlays[i]
+		switch o.Type {
+		case "image":
+			if o.Source != "upload" && o.Source != "url" {
+				return fmt.Errorf("overlays[%d].source must be upload or url", i)
+			}
+			if strings.TrimSpace(o.Path) == "" {
+				return fmt.Errorf("overlays[%d].path is required for image overlay", i)
+			}
+			if o.Width < 0 {
+				return fmt.Errorf("overlays[%d].width must not be negative", i)
+			}
+		case "text":
+			if strings.TrimSpace(o.Text) == "" {
+				return fmt.Errorf("overlays[%d].text is required for text overlay", i)
+			}
+			if err := o.Style.Validate(); err != nil {
+				return fmt.Errorf("overlays[%d]: %w", i, err)
+			}
+		default:
+			return fmt.Errorf("overlays[%d].type must be image or text", i)
+		}
+		if o.Position == "" {
+			o.Position = "top-right"
+		}
+		if !slices.Contains(OverlayPositions, o.Position) {
+			return fmt.Errorf("overlays[%d].position must be one of %s", i, strings.Join(OverlayPositions, ", "))
+		}
+		if o.Opacity < 0 || o.Opacity > 1 {
+			return fmt.Errorf("overlays[%d].opacity must be between 0 and 1", i)
+		}
+		if o.Opacity == 0 {
+			o.Opacity = 1
+		}
+		if o.Start < 0 || (o.End != 0 && o.End <= o.Start) {
+			return fmt.Errorf("overlays[%d] 的 end 必須大於 start", i)
+		}
+		if o.Animation == "" {
+			o.Animation = "none"
+		}
+		allowed := []string{"none", "fade"}
+		if o.Type == "text" {
+			allowed = SubtitleAnimations
+		}
+		if !slices.Contains(allowed, o.Animation) {
+			return fmt.Errorf("overlays[%d].animation must be one of %s", i, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
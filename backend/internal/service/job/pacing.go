@@ -0,0 +1,62 @@
+package job
+
+import "fmt"
+
+const (
+	DefaultCommaPause     = 0.2  // 逗號、頓號等句中斷點後的停頓 (秒)
+	DefaultSentencePause  = 0.4  // 句號、問號、驚嘆號後的停頓 (秒)
+	DefaultParagraphPause = 0.8  // 空行分段後的停頓 (秒)
+	DefaultTrimDB         = -50. // 修剪 TTS 前後靜音的門檻
+)
+
+// PacingSetting 句間停頓與語速：依斷點標點決定停頓長度，腳本中的 [pause=…] 可直接指定。
+// 停頓以指標區分未填 (使用預設) 與 0 (不停頓)
+type PacingSetting struct {
+	CommaPause     *float64 `json:"comma_pause,omitempty"`     // 句中斷點 (，、；：) 後的停頓秒數
+	SentencePause  *float64 `json:"sentence_pause,omitempty"`  // 句尾 (。！？) 後的停頓秒數
+	ParagraphPause *float64 `json:"paragraph_pause,omitempty"` // 段落 (空行) 後的停頓秒數
+	TargetCPM      float64  `json:"target_cpm"`                // 語速目標：每分鐘字數 (中日韓以字、英文以單字計)，0 表示不調整
+	TrimDB         float64  `json:"trim_db"`                   // 修剪 TTS 前後靜音的門檻 (dB)，越接近 0 修剪越多
+}
+
+// WithDefaults 回傳填入預設值的設定；建立任務前保存的舊任務沒有 pacing 設定。
+// 停頓只在未填時補上預設值，明確指定的 0 保留
+func (p PacingSetting) WithDefaults() PacingSetting {
+	if p.CommaPause == nil {
+		v := DefaultCommaPause
+		p.CommaPause = &v
+	}
+	if p.SentencePause == nil {
+		v := DefaultSentencePause
+		p.SentencePause = &v
+	}
+	if p.ParagraphPause == nil {
+		v := DefaultParagraphPause
+		p.ParagraphPause = &v
+	}
+	if p.TrimDB == 0 {
+		p.TrimDB = DefaultTrimDB
+	}
+	return p
+}
+
+// validate 檢查停頓與語速設定並填入預設值
+func (p *PacingSetting) validate() error {
+	*p = p.WithDefaults()
+	pauses := []struct {
+		name  string
+		value float64
+	}{{"comma_pause", *p.CommaPause}, {"sentence_pause", *p.SentencePause}, {"paragraph_pause", *p.ParagraphPause}}
+	for _, pause := range pauses {
+		if pause.value < 0 || pause.value > 5 {
+			return fmt.Errorf("pacing.%s must be between 0 and 5 seconds", pause.name)
+		}
+	}
+	if p.TargetCPM != 0 && (p.TargetCPM < 60 || p.TargetCPM > 600) {
+		return fmt.Errorf("pacing.target_cpm must be between 60 and 600")
+	}
+	if p.TrimDB < -90 || p.TrimDB > -20 {
+		return fmt.Errorf("pacing.trim_db must be between -90 and -20")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PreviewShortSide 草稿預覽的短邊像素，1080x1920 會輸出 360x640
+	PreviewShortSide = 360
+	// PreviewMaxFPS 草稿預覽的最高幀率
+	PreviewMaxFPS = 15
+)
+
+// PreviewSetting 草稿預覽：以低解析度、低幀率與最快編碼跑完整流程，確認節奏後再正式輸出
+type PreviewSetting struct {
+	Enabled    bool    `json:"enabled"`
+	MaxSeconds float64 `json:"max_seconds"` // 只輸出前 N 秒 (依語音長度截斷)，0 為完整長度
+}
+
+// validate 檢查預覽設定
+func (p *PreviewSetting) validate() error {
+	if p.MaxSeconds < 0 {
+		return fmt.Errorf("preview.max_seconds must not be negative")
+	}
+	if p.MaxSeconds > 0 && !p.Enabled {
+		return fmt.Errorf("preview.max_seconds requires preview.enabled")
+	}
+	return nil
+}
+
+// RenderVideo 回傳實際算圖使用的影片設定，草稿預覽時降低解析度與幀率
+func (r *JobCreateRequest) RenderVideo() VideoSetting {
+	v := r.Video
+	if !r.Preview.Enabled {
+		return v
+	}
+	v.Resolution = previewResolution(v.Resolution)
+	if v.FPS > PreviewMaxFPS {
+		v.FPS = PreviewMaxFPS
+	}
+	return v
+}
+
+// PreviewScale 草稿預覽相對正式解析度的縮放比例，用於換算以像素指定的座標
+func (r *JobCreateRequest) PreviewScale() float64 {
+	if !r.Preview.Enabled {
+		return 1
+	}
+	_, h := splitResolution(r.Video.Resolution)
+	_, ph := splitResolution(previewResolution(r.Video.Resolution))
+	if h == 0 {
+		return 1
+	}
+	return float64(ph) / float64(h)
+}
+
+// previewResolution 依比例將短邊縮到 PreviewShortSide，維持偶數尺寸
+func previewResolution(res string) string {
+	w, h := splitResolution(res)
+	if w == 0 || h == 0 || min(w, h) <= PreviewShortSide {
+		return res
+	}
+	s := float64(PreviewShortSide) / float64(min(w, h))
+	pw := int(float64(w)*s) / 2 * 2
+	ph := int(float64(h)*s) / 2 * 2
+	return fmt.Sprintf("%dx%d", pw, ph)
+}
+
+// splitResolution 解析 "寬x高"，格式錯誤回傳 0
+func splitResolution(res string) (int, int) {
+	ws, hs, ok := strings.Cut(res, "x")
+	if !ok {
+		return 0, 0
+	}
+	w, err1 := strconv.Atoi(ws)
+	h, err2 := strconv.Atoi(hs)
+	if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	return w, h
+}
@@ -0,0 +1,78 @@
+package job
+
+import "testing"
+
+func TestRenderVideo(t *testing.T) {
+	cases := []struct {
+		video   VideoSetting
+		preview bool
+		want    VideoSetting
+	}{
+		{VideoSetting{Resolution: "1080x1920", FPS: 30}, true, VideoSetting{Resolution: "360x640", FPS: 15}},
+		{VideoSetting{Resolution: "1920x1080", FPS: 12}, true, VideoSetting{Resolution: "640x360", FPS: 12}},
+		// 短邊已不超過預覽尺寸時維持原解析度
+		{VideoSetting{Resolution: "320x568", FPS: 30}, true, VideoSetting{Resolution: "320x568", FPS: 15}},
+		{VideoSetting{Resolution: "1080x1920", FPS: 30}, false, VideoSetting{Resolution: "1080x1920", FPS: 30}},
+	}
+	for _, c := range cases {
+		r := JobCreateRequest{Video: c.video, Preview: PreviewSetting{Enabled: c.preview}}
+		if got := r.RenderVideo(); got.Resolution != c.want.Resolution || got.FPS != c.want.FPS {
+			t.Errorf("RenderVideo(%+v, preview=%v) = %s@%d，期望 %s@%d", c.video, c.preview, got.Resolution, got.FPS, c.want.Resolution, c.want.FPS)
+		}
+	}
+}
+
+func TestPreviewResolution(t *testing.T) {
+	cases := []struct{ res, want string }{
+		{"1080x1920", "360x640"},
+		{"720x1280", "360x640"},
+		{"1080x1080", "360x360"},
+		// 縮放後的奇數尺寸捨去為偶數
+		{"1001x1999", "360x718"},
+		{"1366x768", "640x360"},
+		{"bad", "bad"},
+		{"0x1920", "0x1920"},
+	}
+	for _, c := range cases {
+		if got := previewResolution(c.res); got != c.want {
+			t.Errorf("previewResolution(%q) = %q，期望 %q", c.res, got, c.want)
+		}
+	}
+}
+
+func TestPreviewScale(t *testing.T) {
+	cases := []struct {
+		res     string
+		preview bool
+		want    float64
+	}{
+		{"1080x1920", true, 640.0 / 1920},
+		{"1080x1920", false, 1},
+		{"320x568", true, 1},
+		{"bad", true, 1},
+	}
+	for _, c := range cases {
+		r := JobCreateRequest{Video: VideoSetting{Resolution: c.res}, Preview: PreviewSetting{Enabled: c.preview}}
+		if got := r.PreviewScale(); got != c.want {
+			t.Errorf("PreviewScale(%q, preview=%v) = %v，期望 %v", c.res, c.preview, got, c.want)
+		}
+	}
+}
+
+func TestPreviewValidate(t *testing.T) {
+	cases := []struct {
+		p  PreviewSetting
+		ok bool
+	}{
+		{PreviewSetting{}, true},
+		{PreviewSetting{Enabled: true}, true},
+		{PreviewSetting{Enabled: true, MaxSeconds: 15}, true},
+		{PreviewSetting{MaxSeconds: 15}, false},
+		{PreviewSetting{Enabled: true, MaxSeconds: -1}, false},
+	}
+	for _, c := range cases {
+		if err := c.p.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%+v) err = %v，期望通過 = %v", c.p, err, c.ok)
+		}
+	}
+}
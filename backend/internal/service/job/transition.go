@@ -0,0 +1,84 @@
+package job
+
+import "fmt"
+
+// TransitionInfo 轉場效果說明
+type TransitionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Transitions 支援的轉場，對應 ffmpeg 4.3 (映像檔內版本) xfade 的 transition 參數
+var Transitions = []TransitionInfo{
+	{"fade", "淡入淡出"},
+	{"fadeblack", "經由黑畫面淡換"},
+	{"fadewhite", "經由白畫面淡換"},
+	{"dissolve", "溶解"},
+	{"distance", "距離漸變"},
+	{"pixelize", "像素化"},
+	{"radial", "放射狀擦除"},
+	{"wipeleft", "向左擦除"},
+	{"wiperight", "向右擦除"},
+	{"wipeup", "向上擦除"},
+	{"wipedown", "向下擦除"},
+	{"slideleft", "向左滑動"},
+	{"slideright", "向右滑動"},
+	{"slideup", "向上滑動"},
+	{"slidedown", "向下滑動"},
+	{"smoothleft", "向左平滑擦除"},
+	{"smoothright", "向右平滑擦除"},
+	{"smoothup", "向上平滑擦除"},
+	{"smoothdown", "向下平滑擦除"},
+	{"circlecrop", "圓形裁切"},
+	{"rectcrop", "矩形裁切"},
+	{"circleopen", "圓形展開"},
+	{"circleclose", "圓形收合"},
+	{"vertopen", "垂直展開"},
+	{"vertclose", "垂直收合"},
+	{"horzopen", "水平展開"},
+	{"horzclose", "水平收合"},
+	{"diagtl", "對角 (左上)"},
+	{"diagtr", "對角 (右上)"},
+	{"diagbl", "對角 (左下)"},
+	{"diagbr", "對角 (右下)"},
+	{"hlslice", "水平切片 (向左)"},
+	{"hrslice", "水平切片 (向右)"},
+	{"vuslice", "垂直切片 (向上)"},
+	{"vdslice", "垂直切片 (向下)"},
+}
+
+const (
+	// DefaultTransitionSec 未指定時的轉場長度 (秒)
+	DefaultTransitionSec = 1.0
+	minTransitionSec     = 0.2
+	maxTransitionSec     = 3.0
+)
+
+// IsTransition 檢查轉場名稱是否支援，空字串與 none 表示直接切換
+func IsTransition(name string) bool {
+	if name == "" || name == "none" {
+		return true
+	}
+	for _, t := range Transitions {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTransitions 檢查全域與各素材的轉場設定
+func validateTransitions(r *JobCreateRequest) error {
+	if !IsTransition(r.Video.Transition) {
+		return fmt.Errorf("video.transition %q 不支援，請參考 GET /api/v1/transitions", r.Video.Transition)
+	}
+	for i, m := range r.Materials {
+		if !IsTransition(m.Transition) {
+			return fmt.Errorf("materials[%d].transition %q 不支援，請參考 GET /api/v1/transitions", i, m.Transition)
+		}
+		if m.TransitionSec != 0 && (m.TransitionSec < minTransitionSec || m.TransitionSec > maxTransitionSec) {
+			return fmt.Errorf("materials[%d].transition_sec must be between %.1f and %.1f", i, minTransitionSec, maxTransitionSec)
+		}
+	}
+	return nil
+}
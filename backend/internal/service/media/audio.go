@@ -0,0 +1,97 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// loudnormLRA 響度正規化的目標響度範圍 (LU)
+const loudnormLRA = 11.0
+
+// LoudnormStats loudnorm 第一階段量測結果 (ffmpeg 以字串輸出)
+type LoudnormStats struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// BGMFilter 產生背景音樂的濾鏡鏈：音量、循環、裁切到影片長度，並套用淡入淡出
+func BGMFilter(bgm job.BGMSetting, duration float64) string {
+	parts := []string{
+		fmt.Sprintf("volume=%.2f", bgm.Volume),
+		"aloop=-1:size=0",
+		fmt.Sprintf("atrim=0:%.3f", duration),
+		"aformat=sample_rates=44100:channel_layouts=stereo",
+	}
+	curve := bgm.FadeCurve
+	if curve == "" {
+		curve = "tri"
+	}
+	if bgm.FadeIn > 0 {
+		parts = append(parts, fmt.Sprintf("afade=t=in:st=0:d=%.3f:curve=%s", min(bgm.FadeIn, duration), curve))
+	}
+	if bgm.FadeOut > 0 {
+		d := min(bgm.FadeOut, duration)
+		parts = append(parts, fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f:curve=%s", duration-d, d, curve))
+	}
+	return strings.Join(parts, ",")
+}
+
+// DuckFilter 以人聲為 sidechain 壓低背景音樂，ratio 為 1 時不壓低 (回傳空字串)
+func DuckFilter(ratio float64) string {
+	if ratio <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("sidechaincompress=threshold=0.02:ratio=%.1f:attack=20:release=400:makeup=1", ratio)
+}
+
+// NormalizeLoudness 以 loudnorm 兩階段正規化影片音軌 (EBU R128)，影像與字幕軌直接複製
+func NormalizeLoudness(input, output string, target, truePeak float64) error {
+	measure := fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:print_format=json", target, truePeak, loudnormLRA)
+	out, err := utils.RunCmdTimeout(3*time.Minute, "ffmpeg", "-hide_banner", "-nostats", "-i", input, "-vn", "-sn", "-af", measure, "-f", "null", "-")
+	if err != nil {
+		return fmt.Errorf("量測響度失敗: %v / %s", err, out)
+	}
+	stats, err := parseLoudnormStats(out)
+	if err != nil {
+		return err
+	}
+
+	apply := fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+		target, truePeak, loudnormLRA, stats.InputI, stats.InputTP, stats.InputLRA, stats.InputThresh, stats.TargetOffset)
+	tmp := output + ".loudnorm.mp4"
+	if out, err := utils.RunCmdTimeout(3*time.Minute, "ffmpeg", "-y", "-i", input, "-map", "0", "-c:v", "copy", "-c:s", "copy", "-af", apply, "-c:a", "aac", "-b:a", "128k", "-ar", "44100", tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("響度正規化失敗: %v / %s", err, out)
+	}
+	return os.Rename(tmp, output)
+}
+
+// parseLoudnormStats 從 ffmpeg 輸出中取出 loudnorm 印出的 JSON 區塊
+func parseLoudnormStats(out string) (*LoudnormStats, error) {
+	start := strings.LastIndex(out, "{")
+	end := strings.LastIndex(out, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("找不到 loudnorm 量測結果")
+	}
+	var stats LoudnormStats
+	if err := json.Unmarshal([]byte(out[start:end+1]), &stats); err != nil {
+		return nil, fmt.Errorf("解析 loudnorm 量測結果失敗: %w", err)
+	}
+	if stats.InputI == "" || stats.InputTP == "" || stats.InputLRA == "" || stats.InputThresh == "" {
+		return nil, fmt.Errorf("loudnorm 量測結果不完整")
+	}
+	// 完全靜音的音軌量測值為 -inf，無法正規化
+	if strings.Contains(stats.InputI, "inf") {
+		return nil, fmt.Errorf("音軌為靜音，無法量測響度")
+	}
+	return &stats, nil
+}
@@ -0,0 +1,53 @@
+package media
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+func TestParseLoudnormStats(t *testing.T) {
+	out := `[Parsed_loudnorm_0 @ 0x55d]
+{
+	"input_i" : "-23.54",
+	"input_tp" : "-4.12",
+	"input_lra" : "6.30",
+	"input_thresh" : "-34.01",
+	"output_i" : "-14.02",
+	"output_tp" : "-1.50",
+	"output_lra" : "5.10",
+	"output_thresh" : "-24.40",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.02"
+}
+`
+	stats, err := parseLoudnormStats(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.InputI != "-23.54" || stats.InputThresh != "-34.01" || stats.TargetOffset != "0.02" {
+		t.Errorf("解析結果錯誤 %+v", stats)
+	}
+
+	if _, err := parseLoudnormStats("no json here"); err == nil {
+		t.Error("沒有 JSON 時應回傳錯誤")
+	}
+	silent := `{"input_i" : "-inf", "input_tp" : "-inf", "input_lra" : "0.00", "input_thresh" : "-inf", "target_offset" : "inf"}`
+	if _, err := parseLoudnormStats(silent); err == nil {
+		t.Error("靜音音軌應回傳錯誤")
+	}
+}
+
+func TestBGMFilter(t *testing.T) {
+	f := BGMFilter(job.BGMSetting{Volume: 0.3, FadeIn: 1, FadeOut: 2, FadeCurve: "qsin"}, 20)
+	if !strings.Contains(f, "afade=t=in:st=0:d=1.000:curve=qsin") {
+		t.Errorf("缺少淡入: %s", f)
+	}
+	if !strings.Contains(f, "afade=t=out:st=18.000:d=2.000:curve=qsin") {
+		t.Errorf("缺少淡出: %s", f)
+	}
+	if f := BGMFilter(job.BGMSetting{Volume: 0.3}, 20); strings.Contains(f, "afade") {
+		t.Errorf("未設定淡入淡出時不應加入 afade: %s", f)
+	}
+}
@@ -0,0 +1,79 @@
+package media
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// needsClip 影片是否需要先做裁切、變速或倒轉
+func (s Segment) needsClip() bool {
+	return s.Type == "video" && (s.StartSec > 0 || s.EndSec > 0 || (s.Speed > 0 && s.Speed != 1) || s.Reverse)
+}
+
+// prepareClip 依入出點、播放速度與倒轉產生處理後的片段，回傳檔案路徑與長度 (秒)
+func prepareClip(seg Segment, dir string, index int, enc Encoding) (string, float64, error) {
+	info := seg.Probe
+	if info == nil {
+		var err error
+		if info, err = Probe(seg.Path); err != nil {
+			return "", 0, err
+		}
+	}
+	end := seg.EndSec
+	if end <= 0 || end > info.Duration {
+		end = info.Duration
+	}
+	if seg.StartSec >= end {
+		return "", 0, fmt.Errorf("入點 %.2f 秒超過素材長度 %.2f 秒", seg.StartSec, info.Duration)
+	}
+	speed := seg.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	vf := []string{fmt.Sprintf("setpts=(PTS-STARTPTS)/%.4f", speed)}
+	af := []string{"asetpts=PTS-STARTPTS"}
+	if speed != 1 {
+		af = append(af, atempoChain(speed))
+	}
+	if seg.Reverse {
+		vf = append(vf, "reverse")
+		af = append(af, "areverse")
+	}
+
+	target := filepath.Join(dir, fmt.Sprintf("clip_%03d.mp4", index))
+	args := []string{"-y", "-ss", fmt.Sprintf("%.3f", seg.StartSec), "-t", fmt.Sprintf("%.3f", end-seg.StartSec), "-i", seg.Path,
+		"-map", "0:v:0", "-vf", strings.Join(vf, ",")}
+	if info.HasAudio {
+		args = append(args, "-map", "0:a:0", "-af", strings.Join(af, ","), "-c:a", "aac", "-b:a", "128k")
+	}
+	args = append(args, "-c:v", "libx264", "-preset", enc.Preset, "-crf", "20", "-pix_fmt", "yuv420p", target)
+	if out, err := utils.RunCmdTimeout(3*time.Minute, "ffmpeg", args...); err != nil {
+		return "", 0, fmt.Errorf("處理影片入出點失敗: %v / %s", err, out)
+	}
+	return target, (end - seg.StartSec) / speed, nil
+}
+
+// CheckMaterialRanges 對照準備好的素材實際長度，檢查影片素材的入出點是否落在素材長度內；
+// 讀取不到素材資訊的略過，由裁切時再回報
+func CheckMaterialRanges(prepared []PreparedMaterial, mats []job.Material) error {
+	for i, m := range mats {
+		if m.Type != "video" || (m.StartSec == 0 && m.EndSec == 0) || i >= len(prepared) || prepared[i].Probe == nil {
+			continue
+		}
+		info := prepared[i].Probe
+		if m.StartSec >= info.Duration {
+			return fmt.Errorf("materials[%d].start_sec %.2f 超過素材長度 %.2f 秒", i, m.StartSec, info.Duration)
+		}
+		// 容許 0.05 秒的誤差 (容器長度與實際影格)
+		if m.EndSec > info.Duration+0.05 {
+			return fmt.Errorf("materials[%d].end_sec %.2f 超過素材長度 %.2f 秒", i, m.EndSec, info.Duration)
+		}
+	}
+	return nil
+}
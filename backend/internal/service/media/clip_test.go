@@ -0,0 +1,28 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+func TestCheckMaterialRanges(t *testing.T) {
+	prepared := []PreparedMaterial{{Probe: &ProbeInfo{Duration: 10}}, {}, {Probe: &ProbeInfo{Duration: 4}}}
+	cases := []struct {
+		mats []job.Material
+		ok   bool
+	}{
+		{[]job.Material{{Type: "video", StartSec: 2, EndSec: 10.02}}, true},
+		{[]job.Material{{Type: "video", StartSec: 10}}, false},
+		{[]job.Material{{Type: "video", EndSec: 12}}, false},
+		// 讀取不到素材資訊時略過，圖片不檢查
+		{[]job.Material{{Type: "video"}, {Type: "video", StartSec: 99}}, true},
+		{[]job.Material{{Type: "video"}, {Type: "video"}, {Type: "image", StartSec: 5}}, true},
+		{[]job.Material{{Type: "video"}, {Type: "video"}, {Type: "video", StartSec: 1, EndSec: 5}}, false},
+	}
+	for i, c := range cases {
+		if err := CheckMaterialRanges(prepared, c.mats); (err == nil) != c.ok {
+			t.Errorf("case %d: err = %v，期望通過 = %v", i, err, c.ok)
+		}
+	}
+}
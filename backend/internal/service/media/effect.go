@@ -0,0 +1,126 @@
+package media
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+// randomEffectPool random 模式可挑選的運鏡
+var randomEffectPool = []string{"ken_burns", "zoom_in", "zoom_out", "pan_left", "pan_right", "pan_up", "pan_down", "diagonal_pan"}
+
+// motion 參數化的 zoompan 運鏡：縮放倍率與畫面中心 (0~1) 由起點漸變到終點
+type motion struct {
+	z0, z1   float64
+	cx0, cx1 float64
+	cy0, cy1 float64
+	easing   string
+}
+
+// needsFocus 特效是否需要焦點 (auto 時才需要偵測)
+func needsFocus(effect string, p job.EffectParams) bool {
+	if p.Focus == "auto" {
+		return true
+	}
+	return p.Focus == "" && effect == "ken_burns"
+}
+
+// effectMotion 依特效與參數產生運鏡，非 zoompan 類特效回傳 false
+// fx、fy 為畫面上的焦點位置 (0~1)
+func effectMotion(effect string, p job.EffectParams, fx, fy float64) (motion, bool) {
+	m := motion{z0: 1.5, z1: 1.5, cx0: fx, cx1: fx, cy0: fy, cy1: fy, easing: p.Easing}
+	switch effect {
+	case "ken_burns":
+		// 從整張畫面緩慢推近焦點
+		m.z0, m.z1 = 1.0, 1.3
+		m.cx0, m.cy0 = 0.5, 0.5
+	case "zoom_in":
+		m.z0, m.z1 = 1.0, 1.5
+	case "zoom_out":
+		m.z0, m.z1 = 1.5, 1.0
+	case "pan_left", "pan_right", "pan_up", "pan_down", "diagonal_pan":
+	default:
+		return motion{}, false
+	}
+	if p.ZoomStart > 0 {
+		m.z0 = p.ZoomStart
+	}
+	if p.ZoomEnd > 0 {
+		m.z1 = p.ZoomEnd
+	}
+
+	// 平移從一側邊緣移到另一側，邊緣位置取決於縮放後可移動的範圍
+	lo := 1 / (2 * min(m.z0, m.z1))
+	hi := 1 - lo
+	switch effect {
+	case "pan_left":
+		m.cx0, m.cx1 = hi, lo
+	case "pan_right":
+		m.cx0, m.cx1 = lo, hi
+	case "pan_up":
+		m.cy0, m.cy1 = hi, lo
+	case "pan_down":
+		m.cy0, m.cy1 = lo, hi
+	case "diagonal_pan":
+		m.cx0, m.cx1 = lo, hi
+		m.cy0, m.cy1 = lo, hi
+	}
+	return m, true
+}
+
+// filter 產生 zoompan 濾鏡，使用 time 插值，避免 recursive 'zoom' 導致 segfault
+func (m motion) filter(d float64, w, h, fps int) string {
+	e := easingExpr(m.easing, fmt.Sprintf("min(time/%.4f,1)", d))
+	z := fmt.Sprintf("%.4f+%.4f*%s", m.z0, m.z1-m.z0, e)
+	x := fmt.Sprintf("clip((%.4f+%.4f*%s)*iw-iw/zoom/2,0,iw-iw/zoom)", m.cx0, m.cx1-m.cx0, e)
+	y := fmt.Sprintf("clip((%.4f+%.4f*%s)*ih-ih/zoom/2,0,ih-ih/zoom)", m.cy0, m.cy1-m.cy0, e)
+	return fmt.Sprintf(",zoompan=z='%s':d=1:x='%s':y='%s':s=%dx%d:fps=%d", z, x, y, w, h, fps)
+}
+
+// easingExpr 將 0~1 的進度 p 套上緩動曲線
+func easingExpr(easing, p string) string {
+	switch easing {
+	case "ease_in":
+		return fmt.Sprintf("pow(%s,2)", p)
+	case "ease_out":
+		return fmt.Sprintf("(1-pow(1-%s,2))", p)
+	case "ease_in_out":
+		return fmt.Sprintf("(pow(%s,2)*(3-2*%s))", p, p)
+	default:
+		return p
+	}
+}
+
+// assignRandomEffects 為 random 的圖片片段挑選運鏡：整組用完前不重複，且相鄰片段不相同
+func assignRandomEffects(segments []Segment, rng *rand.Rand) {
+	var bag []string
+	prev := ""
+	for i := range segments {
+		if segments[i].Type != "image" || segments[i].Effect != "random" {
+			continue
+		}
+		if len(bag) == 0 {
+			bag = append(bag, randomEffectPool...)
+			rng.Shuffle(len(bag), func(a, b int) { bag[a], bag[b] = bag[b], bag[a] })
+			if bag[0] == prev {
+				bag[0], bag[len(bag)-1] = bag[len(bag)-1], bag[0]
+			}
+		}
+		segments[i].Effect = bag[0]
+		bag = bag[1:]
+		prev = segments[i].Effect
+	}
+}
+
+// frameFocus 將原圖上的焦點換算為縮放置中 (force_original_aspect_ratio=decrease) 後畫面上的位置
+func frameFocus(fx, fy float64, imgW, imgH, w, h int) (float64, float64) {
+	if imgW <= 0 || imgH <= 0 {
+		return fx, fy
+	}
+	s := min(float64(w)/float64(imgW), float64(h)/float64(imgH))
+	sw, sh := float64(imgW)*s, float64(imgH)*s
+	x := ((float64(w)-sw)/2 + fx*sw) / float64(w)
+	y := ((float64(h)-sh)/2 + fy*sh) / float64(h)
+	return x, y
+}
@@ -0,0 +1,104 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+func TestEffectMotion(t *testing.T) {
+	m, ok := effectMotion("pan_left", job.EffectParams{}, 0.5, 0.5)
+	if !ok {
+		t.Fatal("pan_left 應為 zoompan 運鏡")
+	}
+	// z=1.5 時可移動範圍為 1/3 ~ 2/3
+	if math.Abs(m.cx0-2.0/3) > 1e-9 || math.Abs(m.cx1-1.0/3) > 1e-9 || m.cy0 != 0.5 {
+		t.Errorf("pan_left 參數錯誤 %+v", m)
+	}
+
+	m, _ = effectMotion("ken_burns", job.EffectParams{ZoomEnd: 1.8, Easing: "ease_out"}, 0.8, 0.2)
+	if m.z0 != 1.0 || m.z1 != 1.8 || m.cx1 != 0.8 || m.cy1 != 0.2 {
+		t.Errorf("ken_burns 參數錯誤 %+v", m)
+	}
+	want := ",zoompan=z='1.0000+0.8000*(1-pow(1-min(time/4.0000,1),2))':d=1:" +
+		"x='clip((0.5000+0.3000*(1-pow(1-min(time/4.0000,1),2)))*iw-iw/zoom/2,0,iw-iw/zoom)':" +
+		"y='clip((0.5000+-0.3000*(1-pow(1-min(time/4.0000,1),2)))*ih-ih/zoom/2,0,ih-ih/zoom)':s=720x1280:fps=30"
+	if got := m.filter(4, 720, 1280, 30); got != want {
+		t.Errorf("zoompan 濾鏡錯誤\n got: %s\nwant: %s", got, want)
+	}
+
+	if _, ok := effectMotion("shake", job.EffectParams{}, 0.5, 0.5); ok {
+		t.Error("shake 不是 zoompan 運鏡")
+	}
+}
+
+func TestAssignRandomEffects(t *testing.T) {
+	segments := make([]Segment, 20)
+	for i := range segments {
+		segments[i] = Segment{Type: "image", Effect: "random"}
+	}
+	segments[5] = Segment{Type: "video", Effect: "random"}
+	assignRandomEffects(segments, rand.New(rand.NewPCG(1, 2)))
+
+	prev := ""
+	seen := map[string]bool{}
+	count := 0
+	for _, s := range segments {
+		if s.Type != "image" {
+			if s.Effect != "random" {
+				t.Errorf("影片素材不應被指定運鏡")
+			}
+			continue
+		}
+		if !slices.Contains(randomEffectPool, s.Effect) {
+			t.Fatalf("非預期的運鏡 %s", s.Effect)
+		}
+		if s.Effect == prev {
+			t.Errorf("相鄰片段運鏡重複: %s", s.Effect)
+		}
+		if count < len(randomEffectPool) {
+			if seen[s.Effect] {
+				t.Errorf("第一輪內運鏡重複: %s", s.Effect)
+			}
+			seen[s.Effect] = true
+		}
+		prev = s.Effect
+		count++
+	}
+}
+
+func TestFocalPoint(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{30, 40, 50, 255})
+		}
+	}
+	// 右上方的亮紅色物體
+	for y := 8; y < 16; y++ {
+		for x := 44; x < 54; x++ {
+			img.Set(x, y, color.RGBA{230, 30, 30, 255})
+		}
+	}
+	fx, fy := focalPoint(img)
+	if math.Abs(fx-49.0/64) > 0.08 || math.Abs(fy-12.0/48) > 0.08 {
+		t.Errorf("焦點應在物體附近，得到 (%.2f, %.2f)", fx, fy)
+	}
+}
+
+func TestFrameFocus(t *testing.T) {
+	// 橫式 16:9 圖片放進直式畫面，上下留黑邊
+	fx, fy := frameFocus(0.25, 0.5, 1920, 1080, 1080, 1920)
+	if math.Abs(fx-0.25) > 1e-9 || math.Abs(fy-0.5) > 1e-9 {
+		t.Errorf("置中時垂直中心不變，得到 (%.3f, %.3f)", fx, fy)
+	}
+	_, fy = frameFocus(0.5, 0, 1920, 1080, 1080, 1920)
+	if want := (1920 - 607.5) / 2 / 1920; math.Abs(fy-want) > 1e-9 {
+		t.Errorf("頂端應在黑邊下緣 %.4f，得到 %.4f", want, fy)
+	}
+}
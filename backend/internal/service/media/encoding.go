@@ -0,0 +1,14 @@
+package media
+
+// Encoding libx264 編碼參數
+type Encoding struct {
+	Preset string
+	CRF    string
+}
+
+var (
+	// FinalEncoding 正式輸出
+	FinalEncoding = Encoding{Preset: "veryfast", CRF: "23"}
+	// DraftEncoding 草稿預覽：最快速度，畫質次要
+	DraftEncoding = Encoding{Preset: "ultrafast", CRF: "30"}
+)
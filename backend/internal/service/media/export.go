@@ -0,0 +1,103 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// AnimatedTeaser 從影片擷取一段輸出為循環播放的 GIF 或 WebP
+// GIF 先產生調色盤再套用，避免色帶
+func AnimatedTeaser(video, out string, e job.ExportSetting) error {
+	base := fmt.Sprintf("fps=%d,scale=%d:-2:flags=lanczos", e.FPS, e.Width)
+	args := []string{"-y", "-ss", fmt.Sprintf("%.3f", e.StartSec), "-t", fmt.Sprintf("%.3f", e.DurationSec), "-i", video, "-an"}
+	switch e.Format {
+	case "gif":
+		args = append(args, "-filter_complex", fmt.Sprintf("[0:v]%s,split[a][b];[a]palettegen=stats_mode=diff[p];[b][p]paletteuse=dither=bayer:bayer_scale=3", base),
+			"-loop", "0", out)
+	case "webp":
+		args = append(args, "-vf", base, "-c:v", "libwebp", "-quality", "70", "-compression_level", "4", "-loop", "0", out)
+	default:
+		return fmt.Errorf("不支援的動圖格式: %s", e.Format)
+	}
+	if msg, err := utils.RunCmdTimeout(2*time.Minute, "ffmpeg", args...); err != nil {
+		return fmt.Errorf("輸出 %s 失敗: %v / %s", e.Format, err, msg)
+	}
+	return nil
+}
+
+// AudioExport 將旁白與 BGM 混音輸出為 MP3/M4A，並以字幕句子寫入章節
+// bgm 為空時只輸出旁白；targetLUFS 為 0 時不做響度正規化
+func AudioExport(voice, bgm string, setting job.BGMSetting, lines []SubtitleLine, title, out string, duration, targetLUFS, truePeak float64) error {
+	meta := filepath.Join(filepath.Dir(out), filepath.Base(out)+".ffmeta")
+	if err := os.WriteFile(meta, []byte(ffmetadata(title, lines)), 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(meta)
+
+	args := []string{"-y", "-i", voice}
+	voiceChain := fmt.Sprintf("[0:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo", duration)
+	var filter string
+	if bgm != "" {
+		args = append(args, "-i", bgm)
+		bgmChain := BGMFilter(setting, duration)
+		if duck := DuckFilter(setting.DuckRatio); duck != "" {
+			filter = fmt.Sprintf("[1:a]%s[bgm_raw];%s,asplit=2[tts][tts_sc];[bgm_raw][tts_sc]%s[bgm];[tts][bgm]amix=inputs=2:duration=first",
+				bgmChain, voiceChain, duck)
+		} else {
+			filter = fmt.Sprintf("[1:a]%s[bgm];%s[tts];[tts][bgm]amix=inputs=2:duration=first", bgmChain, voiceChain)
+		}
+	} else {
+		filter = voiceChain
+	}
+	if targetLUFS != 0 {
+		filter += fmt.Sprintf(",loudnorm=I=%.1f:TP=%.1f:LRA=%.1f,aresample=44100", targetLUFS, truePeak, loudnormLRA)
+	}
+	filter += "[aout]"
+	metaIndex := 1
+	if bgm != "" {
+		metaIndex = 2
+	}
+	args = append(args, "-i", meta, "-filter_complex", filter, "-map", "[aout]", "-map_metadata", fmt.Sprint(metaIndex), "-map_chapters", fmt.Sprint(metaIndex))
+	switch filepath.Ext(out) {
+	case ".mp3":
+		args = append(args, "-c:a", "libmp3lame", "-b:a", "192k", "-id3v2_version", "3")
+	case ".m4a":
+		args = append(args, "-c:a", "aac", "-b:a", "160k", "-movflags", "+faststart")
+	default:
+		return fmt.Errorf("不支援的音訊格式: %s", filepath.Ext(out))
+	}
+	args = append(args, out)
+	if msg, err := utils.RunCmdTimeout(3*time.Minute, "ffmpeg", args...); err != nil {
+		return fmt.Errorf("輸出音訊失敗: %v / %s", err, msg)
+	}
+	return nil
+}
+
+// ffmetadata 產生 ffmpeg 的 FFMETADATA1 檔，每句字幕為一個章節
+func ffmetadata(title string, lines []SubtitleLine) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	if title != "" {
+		fmt.Fprintf(&b, "title=%s\n", escapeFFMeta(title))
+	}
+	for _, l := range lines {
+		if l.End <= l.Start {
+			continue
+		}
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n", l.Start, l.End, escapeFFMeta(l.Text))
+	}
+	return b.String()
+}
+
+// escapeFFMeta 跳脫 FFMETADATA 的特殊字元 (= ; # \ 與換行)
+func escapeFFMeta(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	r := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`)
+	return r.Replace(s)
+}
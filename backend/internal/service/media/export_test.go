@@ -0,0 +1,26 @@
+package media
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFFMetadata(t *testing.T) {
+	got := ffmetadata("Go = 好用; #1", []SubtitleLine{
+		{Start: 0, End: 1500, Text: "第一句"},
+		{Start: 1500, End: 1500, Text: "空白"},
+		{Start: 1500, End: 3200, Text: "a\\b\nc"},
+	})
+	for _, want := range []string{
+		";FFMETADATA1\ntitle=Go \\= 好用\\; \\#1\n",
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=1500\ntitle=第一句\n",
+		"START=1500\nEND=3200\ntitle=a\\\\b c\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("缺少 %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "空白") {
+		t.Errorf("長度為 0 的句子不應成為章節\n%s", got)
+	}
+}
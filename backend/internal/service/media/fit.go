@@ -0,0 +1,272 @@
+package media
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+const (
+	// roiSampleFPS 智慧裁切分析時每秒取樣的畫面數
+	roiSampleFPS = 2
+	// roiWidth、roiHeight 分析用的縮圖尺寸 (灰階)
+	roiWidth  = 96
+	roiHeight = 54
+	// roiMaxKeyframes 裁切表達式最多的關鍵點，避免 ffmpeg 表達式過長
+	roiMaxKeyframes = 40
+	// roiMaxStep 相鄰取樣之間裁切中心最多移動的比例，讓鏡頭平順
+	roiMaxStep = 0.05
+)
+
+// fitFilter 依填滿模式產生縮放濾鏡：contain 加邊框、cover 裁切填滿、blur 模糊背景
+// smart 需要分析素材，由 smartCropFilter 產生，這裡回傳 cover 作為備援
+func fitFilter(fit string, w, h, fps int, bgColor string) string {
+	switch fit {
+	case "blur":
+		// 模糊背景邏輯 (垂直影片)
+		// 優化版：減少縮放次數以降低 CPU 使用
+		// 1. 背景層：直接縮放到小尺寸 -> 模糊 -> 放大回目標尺寸
+		// 2. 前景層：縮放並適應 (decrease)
+		// 3. Overlay
+		return fmt.Sprintf("split[bg][fg];[bg]scale=270:-1,boxblur=8:4,scale=%d:%d:flags=bilinear[bg_blurred];[fg]scale=%d:%d:force_original_aspect_ratio=decrease[fg_scaled];[bg_blurred][fg_scaled]overlay=(W-w)/2:(H-h)/2,setsar=1,fps=%d",
+			w, h, w, h, fps)
+	case "cover", "smart":
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1,fps=%d", w, h, w, h, fps)
+	default:
+		// 預設邏輯：黑邊
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s,setsar=1,fps=%d",
+			w, h, w, h, bgColor, fps)
+	}
+}
+
+// smartCropFilter 分析素材的重點區域，產生跟隨重點平移的裁切濾鏡
+// 圖片使用靜態焦點；影片逐段追蹤動態與細節的重心
+func smartCropFilter(seg Segment, duration float64, w, h, fps int) (string, error) {
+	srcW, srcH, err := videoSize(seg)
+	if err != nil {
+		return "", err
+	}
+	srcAspect := float64(srcW) / float64(srcH)
+	dstAspect := float64(w) / float64(h)
+	tail := fmt.Sprintf("scale=%d:%d,setsar=1,fps=%d", w, h, fps)
+	if math.Abs(srcAspect-dstAspect) < 0.01 {
+		return tail, nil
+	}
+
+	var times, xs, ys []float64
+	if seg.Type == "image" {
+		fx, fy, _, _, err := FocalPoint(seg.Path)
+		if err != nil {
+			return "", err
+		}
+		times, xs, ys = []float64{0}, []float64{fx}, []float64{fy}
+	} else {
+		frames, err := sampleGrayFrames(seg.Path, duration, roiSampleFPS, roiWidth, roiHeight)
+		if err != nil {
+			return "", err
+		}
+		xs, ys = roiTrack(frames, roiWidth, roiHeight)
+		if len(xs) == 0 {
+			return "", fmt.Errorf("沒有可分析的畫面")
+		}
+		xs, ys = smoothTrack(xs), smoothTrack(ys)
+		for i := range xs {
+			times = append(times, float64(i)/roiSampleFPS)
+		}
+	}
+
+	if srcAspect > dstAspect {
+		// 素材較寬：裁切寬度，水平跟隨
+		cw := even(float64(srcH) * dstAspect)
+		expr := keyframeExpr(times, xs)
+		return fmt.Sprintf("crop=w=%d:h=ih:x='clip(%s*iw-%d/2,0,iw-%d)':y=0,%s", cw, expr, cw, cw, tail), nil
+	}
+	// 素材較高：裁切高度，垂直跟隨
+	ch := even(float64(srcW) / dstAspect)
+	expr := keyframeExpr(times, ys)
+	return fmt.Sprintf("crop=w=iw:h=%d:x=0:y='clip(%s*ih-%d/2,0,ih-%d)',%s", ch, expr, ch, ch, tail), nil
+}
+
+// videoSize 取得素材的顯示寬高 (已套用旋轉)，優先使用素材準備時的 ffprobe 結果
+func videoSize(seg Segment) (int, int, error) {
+	info := seg.Probe
+	if info == nil {
+		var err error
+		if info, err = Probe(seg.Path); err != nil {
+			return 0, 0, fmt.Errorf("讀取素材尺寸失敗: %w", err)
+		}
+	}
+	if info.Width <= 0 || info.Height <= 0 {
+		return 0, 0, fmt.Errorf("無法取得素材尺寸")
+	}
+	return info.Width, info.Height, nil
+}
+
+// sampleGrayFrames 以低解析度灰階取樣影片畫面 (每秒 fps 張，縮成 w x h)
+func sampleGrayFrames(path string, duration, fps float64, w, h int) ([][]byte, error) {
+	tmp, err := os.MkdirTemp("", "roi_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	raw := filepath.Join(tmp, "frames.gray")
+	vf := fmt.Sprintf("fps=%g,scale=%d:%d,format=gray", fps, w, h)
+	if out, err := utils.RunCmdTimeout(time.Minute, "ffmpeg", "-y", "-t", fmt.Sprintf("%.2f", duration), "-i", path, "-vf", vf, "-f", "rawvideo", raw); err != nil {
+		return nil, fmt.Errorf("取樣畫面失敗: %v / %s", err, out)
+	}
+	data, err := os.ReadFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	size := w * h
+	var frames [][]byte
+	for off := 0; off+size <= len(data); off += size {
+		frames = append(frames, data[off:off+size])
+	}
+	return frames, nil
+}
+
+// roiTrack 計算每個取樣畫面的重點位置 (0~1)：
+// 與前一張畫面的差異 (移動中的主體) 權重較高，加上亮度梯度 (細節與邊緣)
+func roiTrack(frames [][]byte, fw, fh int) ([]float64, []float64) {
+	var xs, ys []float64
+	for k, f := range frames {
+		energy := make([]float64, fw*fh)
+		for y := 1; y < fh-1; y++ {
+			for x := 1; x < fw-1; x++ {
+				i := y*fw + x
+				gx := float64(f[i+1]) - float64(f[i-1])
+				gy := float64(f[i+fw]) - float64(f[i-fw])
+				energy[i] = math.Sqrt(gx*gx + gy*gy)
+				if k > 0 {
+					energy[i] += 2 * math.Abs(float64(f[i])-float64(frames[k-1][i]))
+				}
+			}
+		}
+		x, y, ok := weightedCentroid(energy, fw, fh, 0.15)
+		if !ok {
+			// 沒有明顯重點時沿用上一張的位置
+			x, y = 0.5, 0.5
+			if len(xs) > 0 {
+				x, y = xs[len(xs)-1], ys[len(ys)-1]
+			}
+		}
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	return xs, ys
+}
+
+// weightedCentroid 取數值最高的 top 比例做加權平均，回傳正規化座標
+func weightedCentroid(vals []float64, w, h int, top float64) (float64, float64, bool) {
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	idx := int(float64(len(sorted)) * (1 - top))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	threshold := sorted[idx]
+
+	var sx, sy, sw float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := vals[y*w+x]
+			if v < threshold || v <= 0 {
+				continue
+			}
+			sx += v * (float64(x) + 0.5)
+			sy += v * (float64(y) + 0.5)
+			sw += v
+		}
+	}
+	if sw == 0 {
+		return 0.5, 0.5, false
+	}
+	return sx / sw / float64(w), sy / sw / float64(h), true
+}
+
+// smoothTrack 前後雙向指數平滑 (不產生延遲)，再限制每次取樣的移動量
+func smoothTrack(vals []float64) []float64 {
+	const alpha = 0.3
+	out := append([]float64(nil), vals...)
+	for i := 1; i < len(out); i++ {
+		out[i] = alpha*out[i] + (1-alpha)*out[i-1]
+	}
+	for i := len(out) - 2; i >= 0; i-- {
+		out[i] = alpha*out[i] + (1-alpha)*out[i+1]
+	}
+	for i := 1; i < len(out); i++ {
+		d := out[i] - out[i-1]
+		if d > roiMaxStep {
+			out[i] = out[i-1] + roiMaxStep
+		} else if d < -roiMaxStep {
+			out[i] = out[i-1] - roiMaxStep
+		}
+	}
+	return out
+}
+
+// keyframeExpr 產生以 t 在關鍵點之間線性插值的 ffmpeg 表達式
+func keyframeExpr(times, vals []float64) string {
+	if len(vals) > roiMaxKeyframes {
+		// 平均抽取關鍵點，保留最後一點
+		var ts, vs []float64
+		step := float64(len(vals)-1) / float64(roiMaxKeyframes-1)
+		for k := 0; k < roiMaxKeyframes; k++ {
+			i := int(math.Round(float64(k) * step))
+			ts = append(ts, times[i])
+			vs = append(vs, vals[i])
+		}
+		times, vals = ts, vs
+	}
+	expr := fmt.Sprintf("%.4f", vals[len(vals)-1])
+	for i := len(vals) - 2; i >= 0; i-- {
+		slope := (vals[i+1] - vals[i]) / (times[i+1] - times[i])
+		expr = fmt.Sprintf("if(lt(t,%.3f),%.4f+%.4f*(t-%.3f),%s)", times[i+1], vals[i], slope, times[i], expr)
+	}
+	return expr
+}
+
+// fitFocus 將原圖上的焦點換算為套用填滿模式後畫面上的位置
+func fitFocus(fit string, fx, fy float64, imgW, imgH, w, h int) (float64, float64) {
+	if imgW <= 0 || imgH <= 0 {
+		return fx, fy
+	}
+	switch fit {
+	case "cover", "smart":
+		srcAspect := float64(imgW) / float64(imgH)
+		dstAspect := float64(w) / float64(h)
+		if srcAspect > dstAspect {
+			// 裁切寬度，cover 置中裁切，smart 以焦點為中心裁切
+			cwf := dstAspect / srcAspect
+			x0 := (1 - cwf) / 2
+			if fit == "smart" {
+				x0 = math.Max(0, math.Min(fx-cwf/2, 1-cwf))
+			}
+			return (fx - x0) / cwf, fy
+		}
+		chf := srcAspect / dstAspect
+		y0 := (1 - chf) / 2
+		if fit == "smart" {
+			y0 = math.Max(0, math.Min(fy-chf/2, 1-chf))
+		}
+		return fx, (fy - y0) / chf
+	default:
+		return frameFocus(fx, fy, imgW, imgH, w, h)
+	}
+}
+
+// even 取最接近的偶數 (libx264 yuv420p 需要偶數尺寸)
+func even(v float64) int {
+	n := int(math.Round(v))
+	if n%2 != 0 {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package media
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoiTrackFollowsMovingObject(t *testing.T) {
+	// 亮點由左往右移動
+	var frames [][]byte
+	for k := 0; k < 6; k++ {
+		f := make([]byte, roiWidth*roiHeight)
+		for i := range f {
+			f[i] = 40
+		}
+		cx := 10 + k*15
+		for y := 20; y < 34; y++ {
+			for x := cx - 4; x < cx+4; x++ {
+				f[y*roiWidth+x] = 220
+			}
+		}
+		frames = append(frames, f)
+	}
+	xs, ys := roiTrack(frames, roiWidth, roiHeight)
+	if len(xs) != 6 {
+		t.Fatalf("應有 6 個位置，得到 %d", len(xs))
+	}
+	for k := 1; k < len(xs); k++ {
+		if xs[k] <= xs[k-1] {
+			t.Errorf("位置應持續往右: %v", xs)
+			break
+		}
+	}
+	if math.Abs(ys[3]-27.0/roiHeight) > 0.1 {
+		t.Errorf("垂直位置錯誤: %v", ys)
+	}
+
+	smoothed := smoothTrack([]float64{0.1, 0.9, 0.1, 0.9})
+	for k := 1; k < len(smoothed); k++ {
+		if math.Abs(smoothed[k]-smoothed[k-1]) > roiMaxStep+1e-9 {
+			t.Errorf("平滑後移動量超過上限: %v", smoothed)
+		}
+	}
+}
+
+func TestKeyframeExpr(t *testing.T) {
+	got := keyframeExpr([]float64{0, 0.5, 1}, []float64{0.2, 0.4, 0.4})
+	want := "if(lt(t,0.500),0.2000+0.4000*(t-0.000),if(lt(t,1.000),0.4000+0.0000*(t-0.500),0.4000))"
+	if got != want {
+		t.Errorf("表達式錯誤\n got: %s\nwant: %s", got, want)
+	}
+	if got := keyframeExpr([]float64{0}, []float64{0.7}); got != "0.7000" {
+		t.Errorf("單一關鍵點應為常數: %s", got)
+	}
+}
+
+func TestFitFocus(t *testing.T) {
+	// 16:9 圖片以焦點為中心裁成 9:16，焦點在靠右 0.8
+	fx, fy := fitFocus("smart", 0.8, 0.4, 1920, 1080, 1080, 1920)
+	if math.Abs(fx-0.5) > 1e-6 || fy != 0.4 {
+		t.Errorf("smart 裁切後焦點應在畫面中央，得到 (%.3f, %.3f)", fx, fy)
+	}
+	fx, _ = fitFocus("cover", 0.5, 0.5, 1920, 1080, 1080, 1920)
+	if math.Abs(fx-0.5) > 1e-6 {
+		t.Errorf("cover 置中裁切，中心不變，得到 %.3f", fx)
+	}
+}
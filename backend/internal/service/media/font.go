@@ -0,0 +1,124 @@
+package media
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxCharsetRunes 查詢字型涵蓋範圍時最多帶入的字元數，避免 fc-list 參數過長
+const maxCharsetRunes = 512
+
+// cjkFontRegions 依語系偏好的 CJK 字型地區 (例如 Noto Sans CJK JP)，同一個漢字在各地區字形不同
+var cjkFontRegions = map[string][]string{
+	"ja":    {"JP"},
+	"ko":    {"KR"},
+	"zh-cn": {"SC"},
+	"zh-sg": {"SC"},
+	"zh-hk": {"HK", "TC"},
+	"zh":    {"TC"},
+}
+
+// FontForText 確認字型能顯示文字中的所有字元，不能時以 fc-list 依字元集挑選替代字型 (優先 Noto Sans)。
+// 沒有 fc-list 或找不到合適字型時回傳原字型，交給 libass 自行替代
+func FontForText(font, text, locale string) string {
+	charset := fontCharset(text)
+	if charset == "" {
+		return font
+	}
+	if font != "" {
+		if covered, err := fontFamilies(escapeFontName(font) + ":charset=" + charset); err == nil && len(covered) > 0 {
+			return font
+		}
+	}
+	families, err := fontFamilies(":charset=" + charset)
+	if err != nil || len(families) == 0 {
+		return font
+	}
+	return pickFamily(families, locale)
+}
+
+// fontCharset 將文字中的字元轉為 fontconfig charset 格式 (十六進位，以空白分隔)
+func fontCharset(text string) string {
+	seen := map[rune]bool{}
+	var runes []rune
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || seen[r] || r >= 0xE000 && r <= 0xF8FF {
+			continue
+		}
+		seen[r] = true
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	if len(runes) > maxCharsetRunes {
+		runes = runes[:maxCharsetRunes]
+	}
+	parts := make([]string, len(runes))
+	for i, r := range runes {
+		parts[i] = fmt.Sprintf("%x", r)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fontFamilies 以 fc-list 查詢符合 pattern 的字型家族名稱 (每個字型取第一個名稱)
+func fontFamilies(pattern string) ([]string, error) {
+	out, err := exec.Command("fc-list", pattern, "family").Output()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var families []string
+	for _, line := range strings.Split(string(out), "\n") {
+		name, _, _ := strings.Cut(line, ",")
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		families = append(families, name)
+	}
+	return families, nil
+}
+
+// escapeFontName 跳脫 fontconfig pattern 中有特殊意義的字元
+func escapeFontName(name string) string {
+	return strings.NewReplacer(`\`, `\\`, "-", `\-`, ":", `\:`, ",", `\,`).Replace(name)
+}
+
+// pickFamily 從可顯示文字的字型中挑選：Noto Sans 優先 (CJK 依語系挑選地區版本)，
+// 避開等寬與襯線字型，其餘依名稱排序取第一個
+func pickFamily(families []string, locale string) string {
+	loc := strings.ToLower(locale)
+	regions := cjkFontRegions[loc]
+	if regions == nil {
+		lang, _, _ := strings.Cut(loc, "-")
+		regions = cjkFontRegions[lang]
+	}
+	score := func(name string) int {
+		s := 0
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "noto sans") {
+			s += 4
+		}
+		if strings.Contains(lower, "mono") || strings.Contains(lower, "serif") {
+			s -= 8
+		}
+		for i, r := range regions {
+			if strings.HasSuffix(name, " "+r) {
+				s += 2 * (len(regions) - i)
+			}
+		}
+		return s
+	}
+	sorted := append([]string(nil), families...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := score(sorted[i]), score(sorted[j])
+		if si != sj {
+			return si > sj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted[0]
+}
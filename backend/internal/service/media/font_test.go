@@ -0,0 +1,27 @@
+package media
+
+import "testing"
+
+func TestFontCharset(t *testing.T) {
+	if got := fontCharset("Hi 你好 Hi\n"); got != "48 69 4f60 597d" {
+		t.Errorf("fontCharset = %q", got)
+	}
+}
+
+func TestPickFamily(t *testing.T) {
+	families := []string{"DejaVu Sans", "DejaVu Sans Mono", "Noto Sans CJK SC", "Noto Sans CJK JP", "Noto Sans CJK TC", "Noto Serif CJK JP"}
+	cases := map[string]string{
+		"ja-JP": "Noto Sans CJK JP",
+		"zh-CN": "Noto Sans CJK SC",
+		"zh-TW": "Noto Sans CJK TC",
+		"en-US": "Noto Sans CJK JP",
+	}
+	for locale, want := range cases {
+		if got := pickFamily(families, locale); got != want {
+			t.Errorf("pickFamily(%s) = %s，期望 %s", locale, got, want)
+		}
+	}
+	if got := pickFamily([]string{"FreeSans", "DejaVu Sans"}, "en-US"); got != "DejaVu Sans" {
+		t.Errorf("沒有 Noto 時應依名稱排序，得到 %s", got)
+	}
+}
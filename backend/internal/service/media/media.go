@@ -2,31 +2,39 @@ package media
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
-	"unicode/utf8"
 
 	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
 	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
 	"github.com/rs/zerolog/log"
 )
 
 type SubtitleLine struct {
-	Start int
-	End   int
-	Text  string
+	Start     int    `json:"start_ms"`
+	End       int    `json:"end_ms"`
+	Text      string `json:"text"`
+	Secondary string `json:"secondary,omitempty"` // 雙語字幕的第二行
 }
 
-// PrepareMaterials 下載素材並複製到工單資料夾
-func PrepareMaterials(base string, mats []job.Material) ([]string, error) {
+// PreparedMaterial 已下載到工單資料夾的素材與 ffprobe 資訊
+type PreparedMaterial struct {
+	Path  string
+	Probe *ProbeInfo // 讀取失敗時為 nil，算圖時改用保守做法
+}
+
+// PrepareMaterials 下載素材並複製到工單資料夾，影片另以 ffprobe 讀取資訊以決定算圖方式
+func PrepareMaterials(base string, mats []job.Material) ([]PreparedMaterial, error) {
 	dir := filepath.Join(base, "materials")
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, err
 	}
-	var prepared []string
+	var prepared []PreparedMaterial
 	for i, m := range mats {
 		target := filepath.Join(dir, fmt.Sprintf("mat_%d", i))
 		ext := filepath.Ext(m.Path)
@@ -56,14 +64,23 @@ func PrepareMaterials(base string, mats []job.Material) ([]string, error) {
 				return nil, fmt.Errorf("複製素材失敗: %v", err)
 			}
 		}
-		prepared = append(prepared, target)
+		p := PreparedMaterial{Path: target}
+		if m.Type == "video" {
+			info, err := Probe(target)
+			if err != nil {
+				log.Warn().Err(err).Str("file", target).Msg("讀取素材資訊失敗")
+			} else {
+				p.Probe = info
+			}
+		}
+		prepared = append(prepared, p)
 	}
 	return prepared, nil
 }
 
 // BuildVideoTimeline 依據素材產生影片時間軸，補足或裁切以符合語音長度
 // 修改：影片時間軸長度至少 1 秒 (hard limit)
-func BuildVideoTimeline(prepared []string, mats []job.Material, needDuration int) []Segment {
+func BuildVideoTimeline(prepared []PreparedMaterial, mats []job.Material, needDuration int) []Segment {
 	var segments []Segment
 	cursor := 0
 	idx := 0
@@ -96,13 +113,24 @@ func BuildVideoTimeline(prepared []string, mats []job.Material, needDuration int
 				vol = 1.0
 			}
 			segments = append(segments, Segment{
-				Path:   prepared[idx],
+				Path:   prepared[idx].Path,
+				Probe:  prepared[idx].Probe,
 				Start:  cursor,
 				End:    cursor + d,
 				Type:   mats[idx].Type,
 				Mute:   mats[idx].Mute,
 				Volume: vol,
 				Effect: mats[idx].Effect,
+
+				EffectParams:  mats[idx].EffectParams,
+				Fit:           mats[idx].Fit,
+				StartSec:      mats[idx].StartSec,
+				EndSec:        mats[idx].EndSec,
+				Speed:         mats[idx].Speed,
+				Reverse:       mats[idx].Reverse,
+				Shortfall:     mats[idx].Shortfall,
+				Transition:    mats[idx].Transition,
+				TransitionSec: mats[idx].TransitionSec,
 			})
 			cursor += d
 		}
@@ -114,6 +142,7 @@ func BuildVideoTimeline(prepared []string, mats []job.Material, needDuration int
 
 		idx++
 	}
+	assignRandomEffects(segments, rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)))
 	return segments
 }
 
@@ -125,10 +154,41 @@ type Segment struct {
 	Mute   bool
 	Volume float64
 	Effect string
+	Probe  *ProbeInfo // 影片的 ffprobe 資訊，nil 表示未知
+
+	EffectParams  job.EffectParams
+	Fit           string  // 填滿模式，空字串沿用 blur_background 設定
+	StartSec      float64 // 影片入點 (秒)
+	EndSec        float64 // 影片出點 (秒)，0 表示到結尾
+	Speed         float64 // 播放速度
+	Reverse       bool    // 倒轉播放
+	Shortfall     string  // 片段比時間槽短時：freeze 或 loop
+	Transition    string  // 切到下一段的轉場，空字串沿用全域設定
+	TransitionSec float64 // 轉場長度 (秒)，0 使用預設
+}
+
+// transitionBuffer 片段延長時在轉場長度之外多留的安全緩衝 (秒)，
+// 避免轉場因檔案長度微小誤差而截斷
+const transitionBuffer = 0.2
+
+// outgoing 回傳切到下一段使用的轉場與重疊長度，none 時重疊為 0
+func (s Segment) outgoing(global string) (string, float64, float64) {
+	name := s.Transition
+	if name == "" {
+		name = global
+	}
+	if name == "" || name == "none" {
+		return "none", 0, 0
+	}
+	d := s.TransitionSec
+	if d <= 0 {
+		d = job.DefaultTransitionSec
+	}
+	return name, d, d + transitionBuffer
 }
 
 // MakeSegments 製作影片片段並 concat
-func MakeSegments(base, resolution string, fps int, bgColor string, segments []Segment, transition string, blurBackground bool, onProgress func(int)) (string, error) {
+func MakeSegments(base, resolution string, fps int, bgColor string, segments []Segment, transition string, blurBackground bool, enc Encoding, onProgress func(int)) (string, error) {
 	outDir := filepath.Join(base, "segments")
 	if err := os.MkdirAll(outDir, 0o755); err != nil {
 		return "", err
@@ -152,20 +212,10 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 		bgColor = "#" + bgColor
 	}
 
-	// 建立 filter
-	var vf string
+	// 未指定填滿模式的素材：沿用 blur_background 設定
+	defaultFit := "contain"
 	if blurBackground {
-		// 模糊背景邏輯 (垂直影片)
-		// 優化版：減少縮放次數以降低 CPU 使用
-		// 1. 背景層：直接縮放到小尺寸 -> 模糊 -> 放大回目標尺寸
-		// 2. 前景層：縮放並適應 (decrease)
-		// 3. Overlay
-		vf = fmt.Sprintf("split[bg][fg];[bg]scale=270:-1,boxblur=8:4,scale=%d:%d:flags=bilinear[bg_blurred];[fg]scale=%d:%d:force_original_aspect_ratio=decrease[fg_scaled];[bg_blurred][fg_scaled]overlay=(W-w)/2:(H-h)/2,setsar=1,fps=%d",
-			w, h, w, h, fps)
-	} else {
-		// 預設邏輯：黑邊
-		vf = fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s,setsar=1,fps=%d",
-			w, h, w, h, bgColor, fps)
+		defaultFit = "blur"
 	}
 
 	var segmentFiles []string
@@ -179,23 +229,23 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 		durationSec := float64(seg.End-seg.Start) / 1000.0
 
 		// 設定重疊長度 (Overlap)
-		// 為了避免轉場因檔案長度微小誤差而截斷，我們設定 1.2 秒的重疊區
-		// 但轉場動畫 (xfade) 只使用 1.0 秒，保留 0.2 秒的安全緩衝
-		const overlap = 1.2
-
-		// 處理轉場，若不是最後一個片段，延長 overlap 秒給轉場使用
-		if transition != "none" && transition != "" {
-			if i < len(segments)-1 {
-				durationSec += overlap
-				// 中間片段需要足夠長以供重疊 (至少 overlap + 安全緩衝)
-				if durationSec < overlap+1.0 {
-					durationSec = overlap + 1.0
-				}
-			} else {
-				// 最後一個片段雖然不用 fade out，但因為要 fade in，所以至少要 overlap 長度
-				if durationSec < overlap {
-					durationSec = overlap
-				}
+		// 轉場長度之外多留 0.2 秒的安全緩衝；進入此段的轉場也需要足夠長度
+		_, _, overlap := seg.outgoing(transition)
+		incoming := 0.0
+		if i > 0 {
+			_, _, incoming = segments[i-1].outgoing(transition)
+		}
+
+		if overlap > 0 && i < len(segments)-1 {
+			// 延長 overlap 秒給轉場使用，中間片段需要足夠長以供重疊 (至少 overlap + 安全緩衝)
+			durationSec += overlap
+			if durationSec < overlap+incoming+1.0 {
+				durationSec = overlap + incoming + 1.0
+			}
+		} else if incoming > 0 {
+			// 不需要 fade out 的片段，因為要 fade in，所以至少要 incoming 長度
+			if durationSec < incoming {
+				durationSec = incoming
 			}
 		} else {
 			// 預設模式，保險起見最小長度檢核 (可選)
@@ -207,19 +257,59 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 		// 設定 2 分鐘 timeout，避免卡死
 		timeout := 2 * time.Minute
 
+		// 影片入出點、變速與倒轉：先產生處理後的片段，再依時間槽補足長度
+		clipLen := 0.0
+		if seg.needsClip() {
+			clip, l, err := prepareClip(seg, outDir, i, enc)
+			if err != nil {
+				return "", fmt.Errorf("製作片段失敗(seg %d): %w", i, err)
+			}
+			seg.Path, clipLen = clip, l
+		} else if seg.Probe != nil {
+			clipLen = seg.Probe.Duration
+		} else if seg.Type == "video" {
+			clipLen, _ = utils.AudioDurationSeconds(seg.Path)
+		}
+		short := seg.Type == "video" && clipLen > 0 && clipLen < durationSec
+		videoInput := []string{"-t", fmt.Sprintf("%.2f", durationSec), "-i", seg.Path}
+		if short && seg.Shortfall == "loop" {
+			// 重複播放直到填滿時間槽
+			videoInput = append([]string{"-stream_loop", "-1"}, videoInput...)
+		}
+
+		// 建立 filter
+		fit := seg.Fit
+		if fit == "" {
+			fit = defaultFit
+		}
+		vf := fitFilter(fit, w, h, fps, bgColor)
+		if fit == "smart" {
+			if smart, err := smartCropFilter(seg, durationSec, w, h, fps); err != nil {
+				log.Warn().Err(err).Str("file", seg.Path).Msg("智慧裁切分析失敗，改用 cover")
+			} else {
+				vf = smart
+			}
+		}
+
 		// 準備 tpad filter 字串
-		// 如果有轉場 (transition != "none")，我們需要確保影片最後一幀能延伸，
+		// 如果有轉場，我們需要確保影片最後一幀能延伸，
 		// 以便與下一段影片進行重疊 (overlap)。
-		// 這裡統一延伸 overlap 秒 (stop_duration=1.2)，模式為 clone (複製最後一幀)。
+		// 這裡延伸 overlap 秒，模式為 clone (複製最後一幀)。
+		// 片段比時間槽短且為 freeze 時，延伸到填滿時間槽。
+		padSec := overlap
+		if short && seg.Shortfall != "loop" {
+			padSec = durationSec - clipLen + overlap
+		}
 		var finalVf string
-		if transition != "none" && transition != "" {
-			// tpad=stop_mode=clone:stop_duration=1.2
-			finalVf = fmt.Sprintf("%s,tpad=stop_mode=clone:stop_duration=%.1f", vf, overlap)
+		if padSec > 0 {
+			finalVf = fmt.Sprintf("%s,tpad=stop_mode=clone:stop_duration=%.2f", vf, padSec)
 		} else {
 			finalVf = vf
 		}
 
-		if seg.Type == "image" || seg.Mute {
+		// 依 ffprobe 結果決定是否混入原音，沒有音軌的影片直接走靜音流程
+		noAudio := seg.Probe != nil && !seg.Probe.HasAudio
+		if seg.Type == "image" || seg.Mute || noAudio {
 			// 圖片或靜音影片：視訊流 + 靜音音訊
 			if seg.Type == "image" {
 				// 圖片：loop
@@ -236,31 +326,25 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 				if seg.Effect != "" && seg.Effect != "none" {
 					// 使用 time 和 durationSec 進行插值，避免依賴 frames
 					// zoompan d=1 配合 loop 1，每一幀輸入產生一幀輸出
-					d := durationSec
-					switch seg.Effect {
-					case "zoom_in":
-						// Zoom 1.0 -> 1.5. Center.
-						// 改用 time-based，避免 recursive 'zoom' 導致 segfault
-						effectFilter = fmt.Sprintf(",zoompan=z='min(1.0+0.5*time/%.4f,1.5)':d=1:x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':s=%dx%d:fps=%d", d, w, h, fps)
-					case "zoom_out":
-						// Zoom 1.5 -> 1.0. Center.
-						// z = 1.5 - 0.5 * (time / duration)
-						effectFilter = fmt.Sprintf(",zoompan=z='1.5-0.5*time/%.4f':d=1:x='iw/2-(iw/zoom/2)':y='ih/2-(ih/zoom/2)':s=%dx%d:fps=%d", d, w, h, fps)
-					case "pan_left":
-						// Moveright to left
-						effectFilter = fmt.Sprintf(",zoompan=z=1.5:d=1:x='(1-time/%.4f)*(iw-iw/zoom)':y='(ih-ih/zoom)/2':s=%dx%d:fps=%d", d, w, h, fps)
-					case "pan_right":
-						effectFilter = fmt.Sprintf(",zoompan=z=1.5:d=1:x='(time/%.4f)*(iw-iw/zoom)':y='(ih-ih/zoom)/2':s=%dx%d:fps=%d", d, w, h, fps)
-					case "pan_up":
-						effectFilter = fmt.Sprintf(",zoompan=z=1.5:d=1:x='(iw-iw/zoom)/2':y='(1-time/%.4f)*(ih-ih/zoom)':s=%dx%d:fps=%d", d, w, h, fps)
-					case "pan_down":
-						effectFilter = fmt.Sprintf(",zoompan=z=1.5:d=1:x='(iw-iw/zoom)/2':y='(time/%.4f)*(ih-ih/zoom)':s=%dx%d:fps=%d", d, w, h, fps)
-					case "diagonal_pan":
-						effectFilter = fmt.Sprintf(",zoompan=z=1.5:d=1:x='(time/%.4f)*(iw-iw/zoom)':y='(time/%.4f)*(ih-ih/zoom)':s=%dx%d:fps=%d", d, d, w, h, fps)
-					case "rotate":
-						effectFilter = fmt.Sprintf(",rotate=a='0.05*sin(t*2)':ow=iw+100:oh=ih+100%s", scale)
-					case "shake":
-						effectFilter = fmt.Sprintf(",crop=w=iw-40:h=ih-40:x='20+random(1)*20-10':y='20+random(1)*20-10'%s", scale)
+					fx, fy := 0.5, 0.5
+					if seg.EffectParams.Focus == "manual" {
+						fx, fy = seg.EffectParams.FocusX, seg.EffectParams.FocusY
+					} else if needsFocus(seg.Effect, seg.EffectParams) {
+						if px, py, iw, ih, err := FocalPoint(seg.Path); err != nil {
+							log.Warn().Err(err).Str("file", seg.Path).Msg("偵測畫面重點失敗，改用中心")
+						} else {
+							fx, fy = fitFocus(fit, px, py, iw, ih, w, h)
+						}
+					}
+					if m, ok := effectMotion(seg.Effect, seg.EffectParams, fx, fy); ok {
+						effectFilter = m.filter(durationSec, w, h, fps)
+					} else {
+						switch seg.Effect {
+						case "rotate":
+							effectFilter = fmt.Sprintf(",rotate=a='0.05*sin(t*2)':ow=iw+100:oh=ih+100%s", scale)
+						case "shake":
+							effectFilter = fmt.Sprintf(",crop=w=iw-40:h=ih-40:x='20+random(1)*20-10':y='20+random(1)*20-10'%s", scale)
+						}
 					}
 				}
 
@@ -273,7 +357,7 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 					"-f", "lavfi", "-t", fmt.Sprintf("%.2f", durationSec), "-i", "anullsrc=r=44100:cl=stereo",
 					"-filter_complex", finalFilter,
 					"-map", "[v]", "-map", "1:a",
-					"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target}
+					"-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target}
 
 				if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", cmdArgs...); err != nil {
 					return "", fmt.Errorf("製作圖片片段失敗(seg %d): %v", i, err)
@@ -284,12 +368,13 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 				// 注意：durationSec 已經包含了 overlap (如果是中間片段)。
 				// 我們希望影片播完後，停留在最後一幀直到 durationSec 結束。
 				// 所以使用 finalVf (含 tpad)。
-				if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", "-y",
-					"-t", fmt.Sprintf("%.2f", durationSec), "-i", seg.Path,
+				args := append([]string{"-y"}, videoInput...)
+				args = append(args,
 					"-f", "lavfi", "-t", fmt.Sprintf("%.2f", durationSec), "-i", "anullsrc=r=44100:cl=stereo",
 					"-filter_complex", fmt.Sprintf("[0:v]%s[v]", finalVf),
 					"-map", "[v]", "-map", "1:a",
-					"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target); err != nil {
+					"-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target)
+				if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", args...); err != nil {
 					return "", fmt.Errorf("製作影片片段(靜音 seg %d)失敗: %v", i, err)
 				}
 			}
@@ -309,19 +394,24 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 			// 套用音量調整 (volume 濾鏡)
 			filterComplex := fmt.Sprintf("[0:v]%s[v];[0:a]volume=%.2f,aformat=sample_rates=44100:channel_layouts=stereo,apad=whole_dur=%.2f[a]", finalVf, seg.Volume, durationSec)
 
-			if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", "-y",
-				"-t", fmt.Sprintf("%.2f", durationSec), "-i", seg.Path,
+			args := append([]string{"-y"}, videoInput...)
+			args = append(args,
 				"-filter_complex", filterComplex,
 				"-map", "[v]", "-map", "[a]",
-				"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target); err != nil {
+				"-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target)
+			if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", args...); err != nil {
+				if seg.Probe != nil {
+					return "", fmt.Errorf("製作影片片段失敗(seg %d): %v", i, err)
+				}
 
-				// 若失敗，可能是沒有音軌，嘗試靜音模式重試
-				if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", "-y",
-					"-t", fmt.Sprintf("%.2f", durationSec), "-i", seg.Path,
+				// 無法事先讀取素材資訊時，失敗可能是沒有音軌，嘗試靜音模式重試
+				args := append([]string{"-y"}, videoInput...)
+				args = append(args,
 					"-f", "lavfi", "-t", fmt.Sprintf("%.2f", durationSec), "-i", "anullsrc=r=44100:cl=stereo",
 					"-filter_complex", fmt.Sprintf("[0:v]%s[v]", finalVf),
 					"-map", "[v]", "-map", "1:a",
-					"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target); err != nil {
+					"-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", "-shortest", target)
+				if _, err := utils.RunCmdTimeout(timeout, "ffmpeg", args...); err != nil {
 					return "", fmt.Errorf("製作影片片段失敗(重試靜音 seg %d): %v", i, err)
 				}
 			}
@@ -351,7 +441,14 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 	final := filepath.Join(base, "video.mp4")
 
 	// 2. 合併片段
-	if transition == "none" || transition == "" || len(segmentFiles) < 2 {
+	hasTransition := false
+	for i := 0; i+1 < len(segmentFiles); i++ {
+		if _, _, overlap := segments[i].outgoing(transition); overlap > 0 {
+			hasTransition = true
+			break
+		}
+	}
+	if !hasTransition {
 		// 預設模式使用 concat demuxer (快速)
 		var list []string
 		for _, f := range segmentFiles {
@@ -365,55 +462,7 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 		}
 	} else {
 		// 轉場模式使用 xfade (重新編碼)
-		// 轉場長度固定 1 秒
-		offset := 0.0
-		filterComplex := ""
-		const overlap = 1.2
-
-		// 構建 filter_complex
-		// [0][1]xfade=transition=fade:duration=1:offset=OFFSET[v1];
-		// [0:a][1:a]acrossfade=d=1.2[a1];
-		// [v1][2]xfade...
-
-		// 視訊流
-		prevV := "0:v"
-		prevA := "0:a"
-
-		for i := 0; i < len(segmentFiles)-1; i++ {
-			nextV := fmt.Sprintf("%d:v", i+1)
-			nextA := fmt.Sprintf("%d:a", i+1)
-
-			// 計算 offset: 上一段 offset + 上一段長度 - 重疊長度
-			// 第一個 offset = duration[0] - overlap
-			// 第二個 offset = duration[0] + duration[1] - 2*overlap ?
-			// 修正：offset 是相對於前一個影片的開始
-			// offset += currentDur - overlap
-
-			currentDur := durations[i]
-			if i == 0 {
-				offset = currentDur - overlap
-			} else {
-				offset += currentDur - overlap
-			}
-
-			outV := fmt.Sprintf("v%d", i+1)
-			outA := fmt.Sprintf("a%d", i+1)
-
-			if i == len(segmentFiles)-2 {
-				outV = "outv"
-				outA = "outa"
-			}
-
-			// 這樣有 0.2s 的安全緩衝
-			filterComplex += fmt.Sprintf("[%s][%s]xfade=transition=%s:duration=1:offset=%.2f[%s];", prevV, nextV, transition, offset, outV)
-			// acrossfade duration 必須等於 overlap (1.2)，以保持音訊同步
-			filterComplex += fmt.Sprintf("[%s][%s]acrossfade=d=%.2f[%s];", prevA, nextA, overlap, outA)
-
-			prevV = outV
-			prevA = outA
-		}
-		// 移除最後的分號
-		filterComplex = strings.TrimSuffix(filterComplex, ";")
+		filterComplex := buildTransitionFilter(segments, durations, transition)
 
 		// 執行 ffmpeg
 		// 注意：inputs 包含多個 -i，需要正確拼接
@@ -421,7 +470,7 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 		for _, f := range segmentFiles {
 			args = append(args, "-i", f)
 		}
-		args = append(args, "-filter_complex", filterComplex, "-map", "[outv]", "-map", "[outa]", "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", final)
+		args = append(args, "-filter_complex", filterComplex, "-map", "[outv]", "-map", "[outa]", "-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-pix_fmt", "yuv420p", final)
 
 		// Debug Log
 		fmt.Printf("Transition FFmpeg Args: %v\n", args)
@@ -435,8 +484,9 @@ func MakeSegments(base, resolution string, fps int, bgColor string, segments []S
 }
 
 // BuildASS 依樣式產生字幕檔，並處理高度自動放大
+// PlayRes 寫入實際解析度，樣式尺寸以 288 行高為基準換算，確保不同解析度的成品比例一致
 func BuildASS(base string, style job.SubtitleStyle, segments []SubtitleLine, resolution string) (string, job.SubtitleStyle, error) {
-	_, resY := 1080, 1920
+	resX, resY := 1080, 1920
 	if style.Font == "" {
 		style.Font = "Noto Sans CJK TC"
 	}
@@ -449,6 +499,9 @@ func BuildASS(base string, style job.SubtitleStyle, segments []SubtitleLine, res
 	}
 	if resolution != "" {
 		if p := strings.Split(resolution, "x"); len(p) == 2 {
+			if w, err := strconv.Atoi(p[0]); err == nil {
+				resX = w
+			}
 			if h, err := strconv.Atoi(p[1]); err == nil {
 				resY = h
 			}
@@ -467,48 +520,49 @@ func BuildASS(base string, style job.SubtitleStyle, segments []SubtitleLine, res
 			style.YOffset = 40
 		}
 	}
-	color := strings.TrimPrefix(style.Color, "#")
-	if len(color) == 6 {
-		r := color[0:2]
-		g := color[2:4]
-		b := color[4:6]
-		color = "00" + b + g + r // AA + BBGGRR
-	}
+	st := newASSStyle("Default", style, resX, resY)
 
-	outlineColor := strings.TrimPrefix(style.OutlineColor, "#")
-	if len(outlineColor) == 6 {
-		r := outlineColor[0:2]
-		g := outlineColor[2:4]
-		b := outlineColor[4:6]
-		outlineColor = "00" + b + g + r // AA + BBGGRR
-	} else {
-		outlineColor = "00000000"
+	// 雙語字幕：第二行使用獨立樣式，兩行都需在安全區內換行
+	bilingual := false
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.Secondary) != "" {
+			bilingual = true
+			break
+		}
 	}
+	secStyle := style.SecondaryLine()
+	secSt := newASSStyle("Secondary", secStyle, resX, resY)
 
 	var b strings.Builder
-	b.WriteString("[Script Info]\n")
-	b.WriteString("ScriptType: v4.00+\n")
-	// b.WriteString(fmt.Sprintf("PlayResX: %d\n", resX))
-	// b.WriteString(fmt.Sprintf("PlayResY: %d\n", resY))
-	b.WriteString("[V4+ Styles]\n")
-	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
-	// BorderStyle=1，Outline=style.OutlineWidth，Shadow=0，Alignment=2（正中央），MarginL/R=20，MarginV=YOffset
-	b.WriteString(fmt.Sprintf("Style: Default,%s,%d,&H%s,&H00FFFFFF,&H%s,&H64000000,0,0,0,0,100,100,0,0,1,%.1f,0,2,20,20,%d,1\n", style.Font, style.Size, color, outlineColor, style.OutlineWidth, style.YOffset))
+	writeASSHeader(&b, resX, resY)
+	b.WriteString(st.line)
+	if bilingual {
+		b.WriteString(secSt.line)
+	}
 	b.WriteString("[Events]\n")
 	b.WriteString("Format: Layer, Start, End, Style, Text\n")
+
+	anim := st.animation(style)
 	for _, seg := range segments {
 		start := formatASSTime(seg.Start)
 		end := formatASSTime(seg.End)
 
-		// 自動換行邏輯：如果文本超過 max_line_width，插入 \N 換行符
-		text := seg.Text
-		if style.MaxLineWidth > 0 {
-			text = wrapText(text, style.MaxLineWidth)
+		// 自動換行邏輯：如果文本超過 max_line_width，插入 \N 換行符，並標示關鍵字
+		width := segment.Columns(style.MaxLineWidth, seg.Text)
+		if bilingual {
+			width = st.wrapWidth(style.MaxLineWidth, seg.Text)
+		}
+		text := emphasizeKeywords(seg.Text, style.Keywords, st.emphasis, st.primary, width)
+
+		// 第二行以 \r 切換樣式，並重新套用進場動畫
+		if sec := strings.TrimSpace(seg.Secondary); sec != "" {
+			sec = wrapText(sec, secSt.wrapWidth(secStyle.MaxLineWidth, sec))
+			text += "\n{\\rSecondary}" + anim + sec
 		}
 
 		// 替換換行符為 ASS 格式
 		text = strings.ReplaceAll(text, "\n", "\\N")
-		b.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,Default,%s\n", start, end, text))
+		b.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,Default,%s%s\n", start, end, anim, text))
 	}
 
 	content := b.String()
@@ -521,85 +575,17 @@ func BuildASS(base string, style job.SubtitleStyle, segments []SubtitleLine, res
 	return path, style, nil
 }
 
-// wrapText 將文本按照最大寬度自動換行
-func wrapText(text string, maxWidth int) string {
-	runes := []rune(text)
-	if utf8.RuneCountInString(text) <= maxWidth {
-		return text
-	}
-
-	var lines []string
-	var currentLine []rune
-
-	for i := 0; i < len(runes); {
-		// 嘗試取出 maxWidth 個字符
-		remaining := len(runes) - i
-		chunkSize := maxWidth
-		if remaining < chunkSize {
-			chunkSize = remaining
-		}
-
-		chunk := runes[i : i+chunkSize]
-
-		// 如果這是最後一塊，直接加入
-		if i+chunkSize >= len(runes) {
-			currentLine = append(currentLine, chunk...)
-			lines = append(lines, string(currentLine))
-			break
-		}
-
-		// 檢查是否可以在更好的位置切分（空格、標點等）
-		// 向後查找最多 8 個字符
-		bestSplit := chunkSize
-		foundPunctuation := false
-		for j := chunkSize; j > chunkSize-8 && j > 0; j-- {
-			char := runes[i+j-1]
-			// 中文標點或空格
-			if char == ' ' || char == '，' || char == '。' || char == '！' || char == '？' || char == '；' || char == '、' {
-				bestSplit = j
-				foundPunctuation = true
-				break
-			}
-		}
-
-		// 如果沒找到標點/空格，嘗試找一個"安全"的切分點 (非英數/特殊符號)
-		if !foundPunctuation {
-			for j := chunkSize; j > chunkSize-8 && j > 0; j-- {
-				idx := i + j
-				if idx >= len(runes) {
-					continue
-				}
-
-				curr := runes[idx]
-				prev := runes[idx-1]
-
-				isUnsafe := false
-				// 1. 英文/數字中間
-				isPrevAlpha := (prev >= 'a' && prev <= 'z') || (prev >= 'A' && prev <= 'Z') || (prev >= '0' && prev <= '9')
-				isCurrAlpha := (curr >= 'a' && curr <= 'z') || (curr >= 'A' && curr <= 'Z') || (curr >= '0' && curr <= '9')
-				if isPrevAlpha && isCurrAlpha {
-					isUnsafe = true
-				}
-
-				// 2. 特殊符號前
-				if curr == '%' || curr == '％' || curr == '℃' || curr == '°' {
-					isUnsafe = true
-				}
-
-				if !isUnsafe {
-					bestSplit = j
-					break
-				}
-			}
-		}
+// wrapText 依語系斷行規則將文本換行，每行不超過 maxCols 個顯示欄 (全形字為 2 欄)
+func wrapText(text string, maxCols int) string {
+	return strings.Join(wrapLines(text, maxCols), "\n")
+}
 
-		currentLine = append(currentLine, runes[i:i+bestSplit]...)
-		lines = append(lines, string(currentLine))
-		currentLine = []rune{}
-		i += bestSplit
+// wrapLines 同 wrapText，回傳各行；maxCols <= 0 或未超過寬度時不換行
+func wrapLines(text string, maxCols int) []string {
+	if maxCols <= 0 || segment.Width(text) <= maxCols {
+		return []string{text}
 	}
-
-	return strings.Join(lines, "\n")
+	return segment.ForText("", text).Wrap(text, maxCols)
 }
 
 func formatASSTime(ms int) string {
@@ -612,8 +598,8 @@ func formatASSTime(ms int) string {
 	return fmt.Sprintf("%01d:%02d:%02d.%02d", h, m, s, cs)
 }
 
-// GeneratePreviewImage 產生字幕預覽圖
-func GeneratePreviewImage(base string, style job.SubtitleStyle, text string, bgColor string, resolution string) (string, error) {
+// GeneratePreviewImage 產生字幕預覽圖，overlays 不為空時一併合成疊加層
+func GeneratePreviewImage(base string, style job.SubtitleStyle, text string, bgColor string, resolution string, overlays []job.Overlay) (string, error) {
 	// 建立臨時目錄
 	tmpDir := filepath.Join(base, "preview")
 	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
@@ -631,6 +617,9 @@ func GeneratePreviewImage(base string, style job.SubtitleStyle, text string, bgC
 		{Start: 0, End: 5000, Text: text},
 	}
 
+	// 預覽只輸出第一幀，進場動畫會讓文字還沒出現，一律取消
+	style.Animation = "none"
+
 	// 使用 BuildASS 產生 ASS 檔案
 	assPath, _, err := BuildASS(tmpDir, style, segments, resolution)
 	if err != nil {
@@ -660,12 +649,40 @@ func GeneratePreviewImage(base string, style job.SubtitleStyle, text string, bgC
 	// -frames:v 1 輸出第一幀
 
 	vf := fmt.Sprintf("ass='%s'", strings.ReplaceAll(assPath, "\\", "/"))
-
-	_, err = utils.RunCmd("ffmpeg", "-y",
-		"-f", "lavfi", "-i", fmt.Sprintf("color=c=%s:s=%s:d=0.1", bgColor, resolution),
-		"-vf", vf,
-		"-frames:v", "1",
-		"-f", "image2", outPath)
+	background := fmt.Sprintf("color=c=%s:s=%s:d=0.1", bgColor, resolution)
+
+	if len(overlays) == 0 {
+		_, err = utils.RunCmd("ffmpeg", "-y",
+			"-f", "lavfi", "-i", background,
+			"-vf", vf,
+			"-frames:v", "1",
+			"-f", "image2", outPath)
+	} else {
+		// 疊加層全部從第 0 秒顯示，不套用動畫
+		layers := make([]job.Overlay, len(overlays))
+		copy(layers, overlays)
+		for i := range layers {
+			layers[i].Start, layers[i].End = 0, 0
+			layers[i].Animation = "none"
+		}
+		layerDir, mkErr := os.MkdirTemp(tmpDir, "overlay_")
+		if mkErr != nil {
+			return "", mkErr
+		}
+		defer os.RemoveAll(layerDir)
+		plan, planErr := PrepareOverlays(layerDir, layers, resolution, 1, 5)
+		if planErr != nil {
+			return "", planErr
+		}
+		args := []string{"-y", "-f", "lavfi", "-i", background}
+		args = append(args, plan.Inputs()...)
+		args = append(args,
+			"-filter_complex", plan.Filter("0:v", "ov", 1)+";[ov]"+vf+"[out]",
+			"-map", "[out]",
+			"-frames:v", "1",
+			"-f", "image2", outPath)
+		_, err = utils.RunCmd("ffmpeg", args...)
+	}
 
 	if err != nil {
 		return "", fmt.Errorf("ffmpeg 預覽失敗: %v", err)
@@ -0,0 +1,258 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// overlayFadeSec 疊加層淡入淡出秒數
+const overlayFadeSec = 0.5
+
+// OverlayPlan 已準備好的疊加層：圖片作為額外的 ffmpeg 輸入，文字合併為一個 ASS 檔
+type OverlayPlan struct {
+	images  []imageLayer
+	assPath string
+	resX    int
+	fps     int
+}
+
+type imageLayer struct {
+	overlay job.Overlay
+	path    string
+}
+
+// PrepareOverlays 下載圖片疊加層並產生文字疊加層的 overlay.ass
+// duration 為影片長度 (秒)，用於 end 未設定的疊加層
+func PrepareOverlays(base string, overlays []job.Overlay, resolution string, fps int, duration float64) (*OverlayPlan, error) {
+	resX, resY := parseResolution(resolution)
+	if fps <= 0 {
+		fps = 30
+	}
+	plan := &OverlayPlan{resX: resX, fps: fps}
+	if len(overlays) == 0 {
+		return plan, nil
+	}
+	dir := filepath.Join(base, "overlays")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var texts []job.Overlay
+	for i, o := range overlays {
+		if o.End <= 0 || o.End > duration {
+			o.End = duration
+		}
+		if o.Type == "text" {
+			texts = append(texts, o)
+			continue
+		}
+		ext := filepath.Ext(o.Path)
+		if ext == "" || len(ext) > 5 {
+			ext = ".png"
+		}
+		target := filepath.Join(dir, fmt.Sprintf("overlay_%d%s", i, ext))
+		src := strings.TrimSpace(o.Path)
+		if o.Source == "url" {
+			if out, err := utils.RunCmd("curl", "-f", "-L", "-o", target, src); err != nil {
+				return nil, fmt.Errorf("下載疊加圖片失敗: %s %v", out, err)
+			}
+		} else if err := utils.CopyFile(src, target); err != nil {
+			return nil, fmt.Errorf("複製疊加圖片失敗: %v", err)
+		}
+		plan.images = append(plan.images, imageLayer{overlay: o, path: target})
+	}
+
+	if len(texts) > 0 {
+		path, err := buildOverlayASS(dir, texts, resX, resY)
+		if err != nil {
+			return nil, err
+		}
+		plan.assPath = path
+	}
+	return plan, nil
+}
+
+// Empty 沒有任何疊加層
+func (p *OverlayPlan) Empty() bool {
+	return p == nil || (len(p.images) == 0 && p.assPath == "")
+}
+
+// Inputs 圖片疊加層的 ffmpeg 輸入參數，需接在既有輸入之後
+func (p *OverlayPlan) Inputs() []string {
+	if p == nil {
+		return nil
+	}
+	var args []string
+	for _, img := range p.images {
+		args = append(args, "-loop", "1", "-framerate", strconv.Itoa(p.fps), "-i", img.path)
+	}
+	return args
+}
+
+// InputCount 額外的 ffmpeg 輸入數量
+func (p *OverlayPlan) InputCount() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.images)
+}
+
+// Filter 產生把疊加層合成到 in 標籤上並輸出為 out 標籤的濾鏡鏈
+// firstInput 為第一張圖片在 ffmpeg 輸入中的編號
+func (p *OverlayPlan) Filter(in, out string, firstInput int) string {
+	if p.Empty() {
+		return fmt.Sprintf("[%s]null[%s]", in, out)
+	}
+	var parts []string
+	cur := in
+	for i, img := range p.images {
+		o := img.overlay
+		width := o.Width
+		if width <= 0 {
+			width = p.resX / 5
+		}
+		layer := fmt.Sprintf("[%d:v]format=rgba,scale=%d:-1", firstInput+i, width)
+		if o.Opacity < 1 {
+			layer += fmt.Sprintf(",colorchannelmixer=aa=%.2f", o.Opacity)
+		}
+		if o.Animation == "fade" {
+			layer += fmt.Sprintf(",fade=t=in:st=%.3f:d=%.1f:alpha=1,fade=t=out:st=%.3f:d=%.1f:alpha=1",
+				o.Start, overlayFadeSec, max(o.End-overlayFadeSec, o.Start), overlayFadeSec)
+		}
+		next := fmt.Sprintf("ovl%d", i)
+		x, y := overlayXY(o, p.resX/27)
+		parts = append(parts, fmt.Sprintf("%s[ov%d]", layer, i),
+			fmt.Sprintf("[%s][ov%d]overlay=x=%s:y=%s:enable='between(t,%.3f,%.3f)'[%s]", cur, i, x, y, o.Start, o.End, next))
+		cur = next
+	}
+	if p.assPath != "" {
+		assFF := strings.ReplaceAll(filepath.ToSlash(p.assPath), "'", "\\'")
+		parts = append(parts, fmt.Sprintf("[%s]ass='%s'[%s]", cur, assFF, out))
+	} else {
+		parts = append(parts, fmt.Sprintf("[%s]null[%s]", cur, out))
+	}
+	return strings.Join(parts, ";")
+}
+
+// overlayXY 依位置產生 overlay 濾鏡的 x、y 表達式
+func overlayXY(o job.Overlay, margin int) (string, string) {
+	if o.Position == "custom" {
+		return strconv.Itoa(o.X), strconv.Itoa(o.Y)
+	}
+	vertical, horizontal := splitOverlayPosition(o.Position)
+	x := "(main_w-overlay_w)/2"
+	switch horizontal {
+	case "left":
+		x = strconv.Itoa(margin)
+	case "right":
+		x = fmt.Sprintf("main_w-overlay_w-%d", margin)
+	}
+	y := "(main_h-overlay_h)/2"
+	switch vertical {
+	case "top":
+		y = strconv.Itoa(margin)
+	case "bottom":
+		y = fmt.Sprintf("main_h-overlay_h-%d", margin)
+	}
+	return x, y
+}
+
+// splitOverlayPosition 將 top-left 等位置拆成垂直 (top/middle/bottom) 與水平 (left/center/right)
+func splitOverlayPosition(position string) (string, string) {
+	vertical, horizontal := "middle", "center"
+	for _, p := range strings.Split(position, "-") {
+		switch p {
+		case "top", "bottom":
+			vertical = p
+		case "left", "right":
+			horizontal = p
+		}
+	}
+	return vertical, horizontal
+}
+
+// buildOverlayASS 將文字疊加層寫成 overlay.ass，每個疊加層一個樣式
+func buildOverlayASS(dir string, texts []job.Overlay, resX, resY int) (string, error) {
+	var styles, events strings.Builder
+	writeASSHeader(&styles, resX, resY)
+	for i, o := range texts {
+		style := o.Style
+		if style.Font == "" {
+			style.Font = "Noto Sans CJK TC"
+		}
+		if style.Color == "" {
+			style.Color = "FFFFFF"
+		}
+		if style.Size <= 0 {
+			style.Size = 24
+		}
+		if style.YOffset <= 0 {
+			style.YOffset = 20
+		}
+		style.Position, style.Align = splitOverlayPosition(o.Position)
+		style.Animation = o.Animation
+		if style.AnimationMs <= 0 {
+			style.AnimationMs = 300
+		}
+
+		name := fmt.Sprintf("Overlay%d", i)
+		st := newASSStyle(name, style, resX, resY)
+		styles.WriteString(st.line)
+
+		x, y := st.anchor()
+		var tags string
+		if o.Position == "custom" {
+			x, y = o.X, o.Y
+			tags = `{\an7}`
+			if o.Animation != "slide" {
+				tags += fmt.Sprintf(`{\pos(%d,%d)}`, x, y)
+			}
+		}
+		if o.Opacity < 1 {
+			tags += fmt.Sprintf(`{\alpha&H%02X&}`, int((1-o.Opacity)*255))
+		}
+		if o.Animation == "fade" {
+			// 疊加層淡入也淡出
+			d := style.AnimationMs
+			tags += fmt.Sprintf(`{\fad(%d,%d)}`, d, d)
+		} else {
+			tags += animationTags(style, x, y, resY)
+		}
+		text := emphasizeKeywords(o.Text, style.Keywords, st.emphasis, st.primary, 0)
+		text = strings.ReplaceAll(text, "\n", "\\N")
+		events.WriteString(fmt.Sprintf("Dialogue: 1,%s,%s,%s,%s%s\n",
+			formatASSTime(int(o.Start*1000)), formatASSTime(int(o.End*1000)), name, tags, text))
+	}
+	styles.WriteString("[Events]\n")
+	styles.WriteString("Format: Layer, Start, End, Style, Text\n")
+	styles.WriteString(events.String())
+
+	path, err := filepath.Abs(filepath.Join(dir, "overlay.ass"))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(styles.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// parseResolution 解析 WxH 格式的解析度，格式錯誤時使用 1080x1920
+func parseResolution(resolution string) (int, int) {
+	w, h := 1080, 1920
+	if parts := strings.Split(resolution, "x"); len(parts) == 2 {
+		if v, err := strconv.Atoi(parts[0]); err == nil && v > 0 {
+			w = v
+		}
+		if v, err := strconv.Atoi(parts[1]); err == nil && v > 0 {
+			h = v
+		}
+	}
+	return w, h
+}
@@ -0,0 +1,59 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+func TestOverlayPlanFilter(t *testing.T) {
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.png")
+	if err := os.WriteFile(logo, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	overlays := []job.Overlay{
+		{Type: "image", Source: "upload", Path: logo, Position: "top-right", Opacity: 0.5, Animation: "fade", Start: 1},
+		{Type: "text", Text: "限時優惠", Position: "top", Opacity: 1, End: 3, Animation: "pop"},
+	}
+	plan, err := PrepareOverlays(dir, overlays, "1080x1920", 30, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.InputCount() != 1 || len(plan.Inputs()) != 6 {
+		t.Fatalf("應有一個圖片輸入: %v", plan.Inputs())
+	}
+	f := plan.Filter("vbase", "vov", 3)
+	for _, want := range []string{
+		"[3:v]format=rgba,scale=216:-1,colorchannelmixer=aa=0.50,fade=t=in:st=1.000",
+		"fade=t=out:st=11.500",
+		"[vbase][ov0]overlay=x=main_w-overlay_w-40:y=40:enable='between(t,1.000,12.000)'[ovl0]",
+		"[ovl0]ass='",
+		"[vov]",
+	} {
+		if !strings.Contains(f, want) {
+			t.Errorf("濾鏡缺少 %q\n%s", want, f)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "overlays", "overlay.ass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `Dialogue: 1,0:00:00.00,0:00:03.00,Overlay0,{\fscx60`) {
+		t.Errorf("文字疊加層錯誤\n%s", b)
+	}
+}
+
+func TestOverlayPlanEmpty(t *testing.T) {
+	plan, err := PrepareOverlays(t.TempDir(), nil, "720x1280", 30, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() || plan.Filter("a", "b", 2) != "[a]null[b]" {
+		t.Errorf("沒有疊加層時應為 null 濾鏡")
+	}
+}
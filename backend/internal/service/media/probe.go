@@ -0,0 +1,211 @@
+package media
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// ProbeInfo ffprobe 取得的素材資訊
+type ProbeInfo struct {
+	Format     string        `json:"format"`   // 容器格式，例如 mov,mp4,m4a,3gp,3g2,mj2
+	Duration   float64       `json:"duration"` // 秒
+	Size       int64         `json:"size"`     // 檔案大小 (bytes)
+	BitRate    int64         `json:"bit_rate"` // 整體位元率 (bps)
+	Width      int           `json:"width"`    // 顯示寬度 (已套用旋轉)
+	Height     int           `json:"height"`   // 顯示高度 (已套用旋轉)
+	Rotation   int           `json:"rotation"` // 旋轉角度 (0、90、180、270)
+	FPS        float64       `json:"fps"`      // 影像幀率
+	VideoCodec string        `json:"video_codec"`
+	AudioCodec string        `json:"audio_codec"`
+	HasVideo   bool          `json:"has_video"`
+	HasAudio   bool          `json:"has_audio"`
+	Streams    []ProbeStream `json:"streams"`
+}
+
+// ProbeStream 單一音視訊軌資訊
+type ProbeStream struct {
+	Index      int     `json:"index"`
+	Type       string  `json:"type"` // video、audio、subtitle...
+	Codec      string  `json:"codec"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FPS        float64 `json:"fps,omitempty"`
+	Rotation   int     `json:"rotation,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	SampleRate int     `json:"sample_rate,omitempty"`
+	Duration   float64 `json:"duration,omitempty"`
+	Language   string  `json:"language,omitempty"`
+}
+
+type ffprobeSideData struct {
+	Rotation float64 `json:"rotation"`
+}
+
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		Index        int               `json:"index"`
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Duration     string            `json:"duration"`
+		AvgFrameRate string            `json:"avg_frame_rate"`
+		RFrameRate   string            `json:"r_frame_rate"`
+		Channels     int               `json:"channels"`
+		SampleRate   string            `json:"sample_rate"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []ffprobeSideData `json:"side_data_list"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// Probe 以 ffprobe 讀取素材的長度、尺寸、編碼、旋轉與音視訊軌 (支援本機路徑與 URL)
+func Probe(path string) (*ProbeInfo, error) {
+	out, err := utils.RunCmdTimeout(30*time.Second, "ffprobe", "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe 失敗: %v / %s", err, out)
+	}
+	return parseProbe([]byte(out))
+}
+
+// parseProbe 解析 ffprobe 的 JSON 輸出
+func parseProbe(data []byte) (*ProbeInfo, error) {
+	var raw ffprobeOutput
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析 ffprobe 輸出失敗: %w", err)
+	}
+	info := &ProbeInfo{Format: raw.Format.FormatName}
+	info.Duration, _ = strconv.ParseFloat(raw.Format.Duration, 64)
+	info.Size, _ = strconv.ParseInt(raw.Format.Size, 10, 64)
+	info.BitRate, _ = strconv.ParseInt(raw.Format.BitRate, 10, 64)
+	for _, s := range raw.Streams {
+		st := ProbeStream{Index: s.Index, Type: s.CodecType, Codec: s.CodecName, Language: s.Tags["language"]}
+		st.Duration, _ = strconv.ParseFloat(s.Duration, 64)
+		switch s.CodecType {
+		case "video":
+			st.Width, st.Height = s.Width, s.Height
+			st.FPS = parseFrameRate(s.AvgFrameRate)
+			if st.FPS == 0 {
+				st.FPS = parseFrameRate(s.RFrameRate)
+			}
+			st.Rotation = streamRotation(s.Tags["rotate"], s.SideDataList)
+			// 音樂檔的封面圖不算影像軌
+			if s.Disposition.AttachedPic == 1 || info.HasVideo {
+				break
+			}
+			info.HasVideo = true
+			info.VideoCodec, info.FPS, info.Rotation = st.Codec, st.FPS, st.Rotation
+			info.Width, info.Height = s.Width, s.Height
+			if st.Rotation == 90 || st.Rotation == 270 {
+				info.Width, info.Height = s.Height, s.Width
+			}
+			if info.Duration == 0 {
+				info.Duration = st.Duration
+			}
+		case "audio":
+			st.Channels = s.Channels
+			st.SampleRate, _ = strconv.Atoi(s.SampleRate)
+			if !info.HasAudio {
+				info.HasAudio = true
+				info.AudioCodec = st.Codec
+				if info.Duration == 0 {
+					info.Duration = st.Duration
+				}
+			}
+		}
+		info.Streams = append(info.Streams, st)
+	}
+	if !info.HasVideo && !info.HasAudio {
+		return nil, fmt.Errorf("素材沒有可用的影音軌")
+	}
+	return info, nil
+}
+
+// parseFrameRate 解析 ffprobe 的分數幀率，例如 30000/1001
+func parseFrameRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !ok {
+		return n
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return math.Round(n/d*1000) / 1000
+}
+
+// streamRotation 取得影像軌的旋轉角度：舊版 ffmpeg 放在 tags.rotate，新版放在 display matrix side data
+// 統一換算為順時針 0、90、180、270
+func streamRotation(tag string, sideData []ffprobeSideData) int {
+	var deg float64
+	if v, err := strconv.ParseFloat(tag, 64); err == nil {
+		deg = v
+	} else {
+		for _, sd := range sideData {
+			if sd.Rotation != 0 {
+				// display matrix 為逆時針角度
+				deg = -sd.Rotation
+				break
+			}
+		}
+	}
+	r := int(math.Round(deg/90)) * 90 % 360
+	if r < 0 {
+		r += 360
+	}
+	return r
+}
+
+// ContactSheet 產生素材縮圖 (JPEG)：影片平均取 cols*rows 張畫面拼成預覽表，圖片直接縮小
+func ContactSheet(path string, info *ProbeInfo, cols, rows int) ([]byte, error) {
+	if info == nil || !info.HasVideo {
+		return nil, fmt.Errorf("素材沒有影像可產生縮圖")
+	}
+	tmp, err := os.MkdirTemp("", "sheet_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	out := filepath.Join(tmp, "sheet.jpg")
+	n := cols * rows
+	var vf string
+	if info.Duration < 1 || n <= 1 {
+		// 圖片或單張縮圖
+		vf = "scale=320:320:force_original_aspect_ratio=decrease"
+	} else {
+		// 每 duration/n 秒取一張，拼成 cols x rows
+		vf = fmt.Sprintf("fps=%.4f,scale=240:240:force_original_aspect_ratio=decrease,pad=240:240:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+			float64(n)/info.Duration, cols, rows)
+	}
+	if msg, err := utils.RunCmdTimeout(time.Minute, "ffmpeg", "-y", "-i", path, "-vf", vf, "-frames:v", "1", "-q:v", "5", out); err != nil {
+		return nil, fmt.Errorf("產生縮圖失敗: %v / %s", err, msg)
+	}
+	return os.ReadFile(out)
+}
+
+// ThumbnailDataURI 將 JPEG 縮圖轉成 data URI，方便前端直接顯示
+func ThumbnailDataURI(data []byte) string {
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,54 @@
+package media
+
+import "testing"
+
+func TestParseProbe(t *testing.T) {
+	out := `{
+	"streams": [
+		{"index": 0, "codec_type": "video", "width": 1920, "height": 1080, "duration": "12.480000"},
+		{"index": 1, "codec_type": "audio", "duration": "12.500000"}
+	],
+	"format": {"duration": "12.500000"}
+}`
+	info, err := parseProbe([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Duration != 12.5 || info.Width != 1920 || info.Height != 1080 || !info.HasVideo || !info.HasAudio {
+		t.Errorf("解析結果錯誤 %+v", info)
+	}
+
+	// 沒有音軌、容器沒有長度時使用影像軌長度
+	info, err = parseProbe([]byte(`{"streams":[{"codec_type":"video","width":720,"height":1280,"duration":"3.2"}],"format":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.HasAudio || info.Duration != 3.2 {
+		t.Errorf("解析結果錯誤 %+v", info)
+	}
+
+	// 直拍手機影片：display matrix 為 -90 度，寬高需對調；音樂封面不算影像軌
+	info, err = parseProbe([]byte(`{"streams":[
+		{"index":0,"codec_type":"video","codec_name":"hevc","width":1920,"height":1080,"avg_frame_rate":"30000/1001","side_data_list":[{"rotation":-90}]},
+		{"index":1,"codec_type":"audio","codec_name":"aac","channels":2,"sample_rate":"48000"},
+		{"index":2,"codec_type":"video","codec_name":"mjpeg","width":600,"height":600,"disposition":{"attached_pic":1}}
+	],"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"5.0","size":"1048576"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Rotation != 90 || info.Width != 1080 || info.Height != 1920 || info.FPS != 29.97 || info.VideoCodec != "hevc" || info.AudioCodec != "aac" {
+		t.Errorf("解析結果錯誤 %+v", info)
+	}
+	if len(info.Streams) != 3 || info.Streams[1].SampleRate != 48000 || info.Size != 1048576 {
+		t.Errorf("音視訊軌解析錯誤 %+v", info.Streams)
+	}
+
+	// 舊版 ffmpeg 的 rotate 標籤
+	if r := streamRotation("270", nil); r != 270 {
+		t.Errorf("rotate 標籤 270 解析為 %d", r)
+	}
+
+	if _, err := parseProbe([]byte(`{"streams":[],"format":{}}`)); err == nil {
+		t.Error("沒有影音軌應回傳錯誤")
+	}
+}
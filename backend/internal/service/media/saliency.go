@@ -0,0 +1,111 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// saliencySize 偵測焦點時縮小到的長邊尺寸
+const saliencySize = 64
+
+// FocalPoint 以顯著性偵測找出圖片的視覺重點，回傳焦點 (0~1) 與縮圖尺寸 (保留原圖比例)
+// 先用 ffmpeg 縮成小圖 (支援任何 ffmpeg 能讀的格式)，再以純 Go 計算
+func FocalPoint(path string) (float64, float64, int, int, error) {
+	tmp, err := os.MkdirTemp("", "focal_")
+	if err != nil {
+		return 0.5, 0.5, 0, 0, err
+	}
+	defer os.RemoveAll(tmp)
+
+	thumb := filepath.Join(tmp, "thumb.png")
+	scale := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", saliencySize, saliencySize)
+	if out, err := utils.RunCmd("ffmpeg", "-y", "-i", path, "-vf", scale, "-frames:v", "1", thumb); err != nil {
+		return 0.5, 0.5, 0, 0, fmt.Errorf("縮圖失敗: %v / %s", err, out)
+	}
+	f, err := os.Open(thumb)
+	if err != nil {
+		return 0.5, 0.5, 0, 0, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return 0.5, 0.5, 0, 0, err
+	}
+	x, y := focalPoint(img)
+	b := img.Bounds()
+	return x, y, b.Dx(), b.Dy(), nil
+}
+
+// focalPoint 計算顯著區域的重心：
+// 顏色與整體平均色的差異 (突出的物體) 加上亮度梯度 (細節與邊緣)，再乘上輕微的中心偏好，
+// 取最顯著的前 10% 像素做加權平均
+func focalPoint(img image.Image) (float64, float64) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return 0.5, 0.5
+	}
+
+	n := w * h
+	rs, gs, bs, lum := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	var mr, mg, mb float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bb, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			i := y*w + x
+			rs[i], gs[i], bs[i] = float64(r>>8), float64(g>>8), float64(bb>>8)
+			lum[i] = 0.299*rs[i] + 0.587*gs[i] + 0.114*bs[i]
+			mr += rs[i]
+			mg += gs[i]
+			mb += bs[i]
+		}
+	}
+	mr, mg, mb = mr/float64(n), mg/float64(n), mb/float64(n)
+
+	colorDist := make([]float64, n)
+	grad := make([]float64, n)
+	var maxColor, maxGrad float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			colorDist[i] = math.Sqrt((rs[i]-mr)*(rs[i]-mr) + (gs[i]-mg)*(gs[i]-mg) + (bs[i]-mb)*(bs[i]-mb))
+			maxColor = max(maxColor, colorDist[i])
+			if x > 0 && x < w-1 && y > 0 && y < h-1 {
+				gx := lum[i+1] - lum[i-1]
+				gy := lum[i+w] - lum[i-w]
+				grad[i] = math.Sqrt(gx*gx + gy*gy)
+				maxGrad = max(maxGrad, grad[i])
+			}
+		}
+	}
+	if maxColor == 0 && maxGrad == 0 {
+		// 純色圖片沒有重點
+		return 0.5, 0.5
+	}
+
+	sal := make([]float64, n)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			var v float64
+			if maxColor > 0 {
+				v += 0.6 * colorDist[i] / maxColor
+			}
+			if maxGrad > 0 {
+				v += 0.4 * grad[i] / maxGrad
+			}
+			dx := (float64(x)+0.5)/float64(w) - 0.5
+			dy := (float64(y)+0.5)/float64(h) - 0.5
+			sal[i] = v * (1 - 0.3*math.Sqrt(dx*dx+dy*dy))
+		}
+	}
+
+	x, y, _ := weightedCentroid(sal, w, h, 0.1)
+	return x, y
+}
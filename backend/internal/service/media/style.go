@@ -0,0 +1,286 @@
+package media
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
+)
+
+// assRefHeight 舊版字幕未寫 PlayResY，libass 以 288 為畫布高度；
+// 樣式中的尺寸 (字級、描邊、邊距) 皆以此為基準，依實際解析度等比例換算
+const assRefHeight = 288.0
+
+// assStyle 一個 ASS 樣式行與事件需要的衍生資訊
+type assStyle struct {
+	line      string // Style: 行
+	primary   string // 主要顏色 (AABBGGRR)
+	emphasis  string // 關鍵字顏色 (AABBGGRR)
+	alignment int
+	fontSize  int // 實際字級 (px)
+	marginH   int
+	marginV   int
+	resX      int
+	resY      int
+}
+
+// writeASSHeader 寫入 Script Info 與樣式區塊標頭，PlayRes 使用實際解析度
+func writeASSHeader(b *strings.Builder, resX, resY int) {
+	b.WriteString("[Script Info]\n")
+	b.WriteString("ScriptType: v4.00+\n")
+	b.WriteString(fmt.Sprintf("PlayResX: %d\n", resX))
+	b.WriteString(fmt.Sprintf("PlayResY: %d\n", resY))
+	b.WriteString("WrapStyle: 2\n")
+	b.WriteString("ScaledBorderAndShadow: yes\n")
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+}
+
+// newASSStyle 依字幕樣式產生 ASS 樣式行，尺寸以 288 行高為基準換算到實際解析度
+func newASSStyle(name string, style job.SubtitleStyle, resX, resY int) assStyle {
+	scale := float64(resY) / assRefHeight
+
+	st := assStyle{
+		primary:   assColor(style.Color, 1, strings.TrimPrefix(style.Color, "#")),
+		emphasis:  assColor(style.EmphasisColor, 1, "0000D7FF"),
+		alignment: assAlignment(style.Position, style.Align),
+		marginH:   int(20 * scale),
+		marginV:   int(float64(style.YOffset) * scale),
+		resX:      resX,
+		resY:      resY,
+	}
+	outlineColor := assColor(style.OutlineColor, 1, "00000000")
+	backColor := assColor(style.ShadowColor, 0.6, "64000000")
+
+	borderStyle := 1
+	outline := style.OutlineWidth * scale
+	if style.BackgroundBox {
+		// BorderStyle 3 以 OutlineColour 繪製底框，Outline 為底框留白
+		borderStyle = 3
+		opacity := style.BackgroundOpacity
+		if opacity <= 0 {
+			opacity = 0.6
+		}
+		outlineColor = assColor(style.BackgroundColor, opacity, assColor("000000", opacity, ""))
+		outline = max(style.OutlineWidth, float64(style.Size)*0.2) * scale
+	}
+
+	bold, italic := 0, 0
+	if style.Bold {
+		bold = -1
+	}
+	if style.Italic {
+		italic = -1
+	}
+	fontSize := int(float64(style.Size)*scale + 0.5)
+	st.fontSize = fontSize
+
+	st.line = fmt.Sprintf("Style: %s,%s,%d,&H%s,&H%s,&H%s,&H%s,%d,%d,0,0,100,100,%.1f,0,%d,%.1f,%.1f,%d,%d,%d,%d,1\n",
+		name, style.Font, fontSize, st.primary, st.emphasis, outlineColor, backColor, bold, italic,
+		style.LetterSpacing*scale, borderStyle, outline, style.Shadow*scale, st.alignment, st.marginH, st.marginH, st.marginV)
+	return st
+}
+
+// wrapWidth 換行欄數上限：取 max_line_width (0 表示不限，依 text 的語言換算欄數) 與安全區可容納欄數的較小值。
+// 安全區為畫面寬度扣除左右邊距，全形字約一個字級寬 (2 欄)
+func (st assStyle) wrapWidth(maxWidth int, text string) int {
+	limit := segment.Columns(maxWidth, text)
+	if st.fontSize <= 0 {
+		return limit
+	}
+	fit := max(2*(st.resX-2*st.marginH)/st.fontSize, 2)
+	if limit > 0 && limit < fit {
+		return limit
+	}
+	return fit
+}
+
+// anchor 文字在畫布上的錨點
+func (st assStyle) anchor() (int, int) {
+	return assAnchor(st.alignment, st.resX, st.resY, st.marginH, st.marginH, st.marginV)
+}
+
+// animation 產生此樣式的進場動畫標籤
+func (st assStyle) animation(style job.SubtitleStyle) string {
+	x, y := st.anchor()
+	return animationTags(style, x, y, st.resY)
+}
+
+// assColor 將 RRGGBB 轉為 ASS 的 AABBGGRR，opacity 為 0~1 (1 為不透明)
+// 已是 8 碼時視為 ASS 原生格式直接使用，格式不符時回傳 def
+func assColor(hex string, opacity float64, def string) string {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	switch len(hex) {
+	case 8:
+		return strings.ToUpper(hex)
+	case 6:
+		alpha := int((1 - opacity) * 255)
+		return strings.ToUpper(fmt.Sprintf("%02X%s%s%s", alpha, hex[4:6], hex[2:4], hex[0:2]))
+	default:
+		return def
+	}
+}
+
+// assAlignment 依位置與對齊方式換算 ASS 的九宮格 Alignment
+func assAlignment(position, align string) int {
+	n := 2
+	switch align {
+	case "left":
+		n = 1
+	case "right":
+		n = 3
+	}
+	switch position {
+	case "middle":
+		n += 3
+	case "top":
+		n += 6
+	}
+	return n
+}
+
+// assAnchor 計算文字在畫布上的錨點，供 \move 等需要絕對座標的標籤使用
+func assAnchor(alignment, resX, resY, marginL, marginR, marginV int) (int, int) {
+	var x, y int
+	switch alignment % 3 {
+	case 1:
+		x = marginL
+	case 0:
+		x = resX - marginR
+	default:
+		x = resX / 2
+	}
+	switch {
+	case alignment >= 7:
+		y = marginV
+	case alignment >= 4:
+		y = resY / 2
+	default:
+		y = resY - marginV
+	}
+	return x, y
+}
+
+// animationTags 產生進場動畫的覆寫標籤
+func animationTags(style job.SubtitleStyle, x, y, resY int) string {
+	d := style.AnimationMs
+	if d <= 0 {
+		d = 250
+	}
+	switch style.Animation {
+	case "pop":
+		// 先放大超過原尺寸再回彈
+		peak := d * 6 / 10
+		return fmt.Sprintf(`{\fscx60\fscy60\t(0,%d,\fscx110\fscy110)\t(%d,%d,\fscx100\fscy100)}`, peak, peak, d)
+	case "fade":
+		return fmt.Sprintf(`{\fad(%d,0)}`, d)
+	case "slide":
+		return fmt.Sprintf(`{\move(%d,%d,%d,%d,0,%d)\fad(%d,0)}`, x, y+resY/20, x, y, d, d)
+	default:
+		return ""
+	}
+}
+
+// emphasizeKeywords 以強調色標示關鍵字並換行 (maxCols <= 0 時不換行)，關鍵字結束後恢復主要顏色。
+// 關鍵字在換行前比對，被換行切開的關鍵字仍會標示；覆寫標籤在換行後才插入，不計入行寬
+func emphasizeKeywords(text string, keywords []string, emphasis, primary string, maxCols int) string {
+	lines := wrapLines(text, maxCols)
+	spans := keywordSpans(text, keywords)
+	if len(spans) == 0 || len(emphasis) != 8 || len(primary) != 8 {
+		return strings.Join(lines, "\n")
+	}
+
+	on := fmt.Sprintf(`{\c&H%s&}`, emphasis[2:])
+	off := fmt.Sprintf(`{\c&H%s&}`, primary[2:])
+	tags := map[int]string{}
+	for _, sp := range spans {
+		tags[sp[0]] += on
+		tags[sp[1]] = off
+	}
+	// 換行後的各行皆為原文的片段 (去除前後空白)，依序找出在原文的位置
+	starts := make([]int, len(lines))
+	pos := 0
+	for n, line := range lines {
+		k := strings.Index(text[pos:], line)
+		if k < 0 {
+			return strings.Join(lines, "\n")
+		}
+		starts[n] = pos + k
+		pos = starts[n] + len(line)
+	}
+
+	var b strings.Builder
+	n := 0
+	for i := 0; i <= len(text); i++ {
+		if n+1 < len(lines) && i == starts[n+1] {
+			b.WriteByte('\n')
+			n++
+		}
+		b.WriteString(tags[i])
+		if i < len(text) && i >= starts[n] && i < starts[n]+len(lines[n]) {
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// keywordSpans 關鍵字在文字中的位元組區間，不分大小寫且長的關鍵字優先 (避免被較短的關鍵字切開)；
+// 以空白分詞的文字需落在詞邊界，例如 go 不會標示 good 或 Google 中的字母
+func keywordSpans(text string, keywords []string) [][2]int {
+	var kws []string
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			kws = append(kws, k)
+		}
+	}
+	if len(kws) == 0 {
+		return nil
+	}
+	sort.SliceStable(kws, func(i, j int) bool { return len(kws[i]) > len(kws[j]) })
+
+	var spans [][2]int
+	for i := 0; i < len(text); {
+		matched := 0
+		for _, k := range kws {
+			end := i + len(k)
+			if end <= len(text) && strings.EqualFold(text[i:end], k) && wordBounded(text, i, end) {
+				matched = len(k)
+				break
+			}
+		}
+		if matched > 0 {
+			spans = append(spans, [2]int{i, i + matched})
+			i += matched
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return spans
+}
+
+// wordBounded text[start:end] 的首尾若是以空白分詞的字母或數字，前後不可緊接同類字元；
+// 中日韓與泰文沒有分詞空白，不檢查邊界
+func wordBounded(text string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:end])
+	last, _ := utf8.DecodeLastRuneInString(text[start:end])
+	if prev, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && spacedWord(first) && spacedWord(prev) {
+		return false
+	}
+	if next, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && spacedWord(last) && spacedWord(next) {
+		return false
+	}
+	return true
+}
+
+// spacedWord 是否為以空白分詞的文字系統的字母或數字
+func spacedWord(r rune) bool {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	return !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
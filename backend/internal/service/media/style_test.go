@@ -0,0 +1,113 @@
+package media
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+)
+
+func TestAssColor(t *testing.T) {
+	if got := assColor("#FF8000", 1, ""); got != "000080FF" {
+		t.Errorf("不透明顏色錯誤: %s", got)
+	}
+	if got := assColor("000000", 0.6, ""); got != "66000000" {
+		t.Errorf("半透明顏色錯誤: %s", got)
+	}
+	if got := assColor("bad", 1, "DEF"); got != "DEF" {
+		t.Errorf("格式錯誤時應回傳預設值: %s", got)
+	}
+}
+
+func TestEmphasizeKeywords(t *testing.T) {
+	cases := []struct {
+		text     string
+		keywords []string
+		maxCols  int
+		want     string
+	}{
+		{"今天介紹 Go 語言與 Golang", []string{"go", "Golang"}, 0,
+			`今天介紹 {\c&H00D7FF&}Go{\c&HFFFFFF&} 語言與 {\c&H00D7FF&}Golang{\c&HFFFFFF&}`},
+		// 英文需落在詞邊界，不標示 good、Google 中的字母
+		{"Good news: Google loves go", []string{"go"}, 0, `Good news: Google loves {\c&H00D7FF&}go{\c&HFFFFFF&}`},
+		{"It's a good day", []string{"go"}, 0, "It's a good day"},
+		// 中文沒有分詞空白，緊接漢字的英文關鍵字與漢字關鍵字都標示
+		{"用Go寫短影音", []string{"go", "短影音"}, 0, `用{\c&H00D7FF&}Go{\c&HFFFFFF&}寫{\c&H00D7FF&}短影音{\c&HFFFFFF&}`},
+		// 先比對再換行：被換行切開的關鍵字仍標示，標籤不計入行寬
+		{"我們來聊聊短影音製作", []string{"短影音"}, 12, "我們來聊聊\n{\\c&H00D7FF&}短影音{\\c&HFFFFFF&}製作"},
+		{"learn machine learning today", []string{"machine learning"}, 16, "learn {\\c&H00D7FF&}machine\nlearning{\\c&HFFFFFF&} today"},
+	}
+	for _, c := range cases {
+		got := emphasizeKeywords(c.text, c.keywords, "0000D7FF", "00FFFFFF", c.maxCols)
+		if got != c.want {
+			t.Errorf("%q 關鍵字標示錯誤\n got: %s\nwant: %s", c.text, got, c.want)
+		}
+	}
+}
+
+func TestBuildASSStyle(t *testing.T) {
+	style := job.SubtitleStyle{Size: 36, YOffset: 80, Bold: true, BackgroundBox: true, Position: "top", Align: "center", Animation: "slide", AnimationMs: 300}
+	path, _, err := BuildASS(t.TempDir(), style, []SubtitleLine{{Start: 0, End: 1000, Text: "hello"}}, "720x1280")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(b)
+	for _, want := range []string{
+		"PlayResX: 720\n", "PlayResY: 1280\n",
+		// 36 * 1280 / 288 = 160，BorderStyle 3，Alignment 8 (上方置中)
+		"Style: Default,Noto Sans CJK TC,160,", ",-1,0,0,0,100,100,0.0,0,3,",
+		`{\move(360,419,360,355,0,300)\fad(300,0)}hello`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("字幕缺少 %q\n%s", want, content)
+		}
+	}
+}
+
+func TestBuildASSBilingual(t *testing.T) {
+	style := job.SubtitleStyle{Size: 16, Font: "Noto Sans TC", MaxLineWidth: 16, Animation: "fade", AnimationMs: 200,
+		Secondary: &job.SecondaryStyle{Font: "Noto Sans", Color: "FFFF00"}}
+	segs := []SubtitleLine{{Start: 0, End: 1000, Text: "今天我們來聊聊短影音的製作流程", Secondary: "Today we talk about how short videos are made from start to finish"}}
+	path, _, err := BuildASS(t.TempDir(), style, segs, "1080x1920")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(b)
+	// 字級 16 * 1920 / 288 = 107，安全區 (1080 - 2*133) / 107 約 7.6 個字寬 = 15 欄；第二行字級 12 → 80，20 欄；
+	// 各行長度盡量平均，英文不切開單字
+	for _, want := range []string{
+		"Style: Secondary,Noto Sans,80,&H0000FFFF,",
+		`{\fad(200,0)}今天我們來聊\N聊短影音的\N製作流程\N{\rSecondary}{\fad(200,0)}Today we talk about\Nhow short videos\Nare made from\Nstart to finish`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("字幕缺少 %q\n%s", want, content)
+		}
+	}
+}
+
+func TestBuildASSLatinLineWidth(t *testing.T) {
+	// 非中日韓文字的 max_line_width 以字元計，每行不超過 20 個字元
+	style := job.SubtitleStyle{Size: 16, MaxLineWidth: 20}
+	segs := []SubtitleLine{{Start: 0, End: 1000, Text: "Short videos are easy to make with the right tools"}}
+	path, _, err := BuildASS(t.TempDir(), style, segs, "1080x1920")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Short videos are\Neasy to make with\Nthe right tools`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("字幕缺少 %q\n%s", want, b)
+	}
+}
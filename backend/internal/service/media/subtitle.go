@@ -0,0 +1,66 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildSRT 產生 SubRip 字幕內容
+func BuildSRT(segments []SubtitleLine) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		b.WriteString(fmt.Sprintf("%d\n", i+1))
+		b.WriteString(fmt.Sprintf("%s --> %s\n", formatSRTTime(seg.Start), formatSRTTime(seg.End)))
+		b.WriteString(cueText(seg))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// BuildVTT 產生 WebVTT 字幕內容
+func BuildVTT(segments []SubtitleLine) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, seg := range segments {
+		b.WriteString(fmt.Sprintf("%s --> %s\n", formatVTTTime(seg.Start), formatVTTTime(seg.End)))
+		b.WriteString(cueText(seg))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// cueText 字幕內容，雙語字幕的第二行放在下一行
+func cueText(seg SubtitleLine) string {
+	text := strings.TrimSpace(seg.Text)
+	if sec := strings.TrimSpace(seg.Secondary); sec != "" {
+		text += "\n" + sec
+	}
+	return text
+}
+
+// WriteSRT 將字幕寫成 subtitle.srt，供軟字幕封裝使用
+func WriteSRT(base string, segments []SubtitleLine) (string, error) {
+	path := filepath.Join(base, "subtitle.srt")
+	if err := os.WriteFile(path, []byte(BuildSRT(segments)), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// formatSRTTime 00:00:01,234
+func formatSRTTime(ms int) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms%3600000/60000, ms%60000/1000, ms%1000)
+}
+
+// formatVTTTime 00:00:01.234
+func formatVTTTime(ms int) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms%3600000/60000, ms%60000/1000, ms%1000)
+}
@@ -0,0 +1,24 @@
+package media
+
+import "testing"
+
+func TestBuildSRT(t *testing.T) {
+	segs := []SubtitleLine{
+		{Start: 0, End: 1500, Text: "第一句"},
+		{Start: 1500, End: 3723456, Text: "second line"},
+	}
+	got := BuildSRT(segs)
+	want := "1\n00:00:00,000 --> 00:00:01,500\n第一句\n\n2\n00:00:01,500 --> 01:02:03,456\nsecond line\n\n"
+	if got != want {
+		t.Fatalf("SRT 內容錯誤:\n%q\n期望:\n%q", got, want)
+	}
+}
+
+func TestBuildVTT(t *testing.T) {
+	segs := []SubtitleLine{{Start: 250, End: 61005, Text: "hello"}}
+	got := BuildVTT(segs)
+	want := "WEBVTT\n\n00:00:00.250 --> 00:01:01.005\nhello\n\n"
+	if got != want {
+		t.Fatalf("VTT 內容錯誤:\n%q\n期望:\n%q", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package media
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+const (
+	// thumbCandidates 挑選縮圖時最多評分的畫面數
+	thumbCandidates = 48
+	// thumbSampleLong 評分用縮圖的長邊
+	thumbSampleLong = 96
+	// thumbSkipSec 跳過開頭的秒數，避開淡入與黑畫面
+	thumbSkipSec = 0.5
+)
+
+// BestFrame 從影片挑出最適合當縮圖的畫面 (清晰、穩定、曝光正常)，輸出 JPEG 並回傳所在秒數
+func BestFrame(video string, duration float64, resolution, out string) (float64, error) {
+	if duration <= 0 {
+		return 0, fmt.Errorf("影片長度無效")
+	}
+	resX, resY := parseResolution(resolution)
+	sw, sh := thumbSampleLong, thumbSampleLong*resY/resX
+	if resY > resX {
+		sw, sh = thumbSampleLong*resX/resY, thumbSampleLong
+	}
+	fps := math.Min(float64(thumbCandidates)/duration, 4)
+	frames, err := sampleGrayFrames(video, duration, fps, sw, sh)
+	if err != nil {
+		return 0, err
+	}
+	skip := int(math.Ceil(thumbSkipSec * fps))
+	if skip >= len(frames) {
+		skip = 0
+	}
+	idx := bestFrame(frames[skip:], sw, sh) + skip
+	at := (float64(idx) + 0.5) / fps
+	if at > duration {
+		at = duration / 2
+	}
+	if msg, err := utils.RunCmdTimeout(time.Minute, "ffmpeg", "-y", "-ss", fmt.Sprintf("%.3f", at), "-i", video, "-frames:v", "1", "-q:v", "2", out); err != nil {
+		return 0, fmt.Errorf("擷取縮圖失敗: %v / %s", err, msg)
+	}
+	return at, nil
+}
+
+// bestFrame 為每個取樣畫面評分，回傳最高分的索引：
+// 清晰度 (Laplacian 變異數) 越高越好；與前後畫面差異大代表轉場或動態模糊，扣分；過暗或過亮扣分
+func bestFrame(frames [][]byte, w, h int) int {
+	if len(frames) == 0 {
+		return 0
+	}
+	sharp := make([]float64, len(frames))
+	motion := make([]float64, len(frames))
+	exposure := make([]float64, len(frames))
+	var maxSharp, maxMotion float64
+	for k, f := range frames {
+		sharp[k] = laplacianVariance(f, w, h)
+		maxSharp = max(maxSharp, sharp[k])
+		var diff float64
+		var n int
+		for _, j := range []int{k - 1, k + 1} {
+			if j < 0 || j >= len(frames) {
+				continue
+			}
+			diff += meanAbsDiff(f, frames[j])
+			n++
+		}
+		if n > 0 {
+			motion[k] = diff / float64(n)
+		}
+		maxMotion = max(maxMotion, motion[k])
+		exposure[k] = exposurePenalty(f)
+	}
+
+	best, bestScore := 0, math.Inf(-1)
+	for k := range frames {
+		score := -exposure[k]
+		if maxSharp > 0 {
+			score += sharp[k] / maxSharp
+		}
+		if maxMotion > 0 {
+			score -= 0.5 * motion[k] / maxMotion
+		}
+		if score > bestScore {
+			best, bestScore = k, score
+		}
+	}
+	return best
+}
+
+// laplacianVariance 以 4 鄰域 Laplacian 的變異數衡量清晰度
+func laplacianVariance(f []byte, w, h int) float64 {
+	var sum, sq float64
+	var n int
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			v := 4*float64(f[i]) - float64(f[i-1]) - float64(f[i+1]) - float64(f[i-w]) - float64(f[i+w])
+			sum += v
+			sq += v * v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	mean := sum / float64(n)
+	return sq/float64(n) - mean*mean
+}
+
+// meanAbsDiff 兩張畫面的平均像素差異 (0~255)
+func meanAbsDiff(a, b []byte) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var d float64
+	for i := range a {
+		d += math.Abs(float64(a[i]) - float64(b[i]))
+	}
+	return d / float64(len(a))
+}
+
+// exposurePenalty 平均亮度低於 40 或高於 215 時的扣分 (0~1)
+func exposurePenalty(f []byte) float64 {
+	if len(f) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range f {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(f))
+	switch {
+	case mean < 40:
+		return (40 - mean) / 40
+	case mean > 215:
+		return (mean - 215) / 40
+	}
+	return 0
+}
+
+// MakeCover 在畫面上以字幕樣式 (BuildASS) 燒錄標題，輸出封面 JPEG
+func MakeCover(frame, title string, style job.SubtitleStyle, resolution, out string) error {
+	dir, err := os.MkdirTemp("", "cover_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// 封面只有一張畫面，進場動畫會讓文字還沒出現
+	style.Animation = "none"
+	assPath, _, err := BuildASS(dir, style, []SubtitleLine{{Start: 0, End: 5000, Text: title}}, resolution)
+	if err != nil {
+		return err
+	}
+	resX, resY := parseResolution(resolution)
+	vf := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,ass='%s'",
+		resX, resY, resX, resY, strings.ReplaceAll(filepath.ToSlash(assPath), "'", "\\'"))
+	if msg, err := utils.RunCmdTimeout(time.Minute, "ffmpeg", "-y", "-i", frame, "-vf", vf, "-frames:v", "1", "-q:v", "2", out); err != nil {
+		return fmt.Errorf("產生封面失敗: %v / %s", err, msg)
+	}
+	return nil
+}
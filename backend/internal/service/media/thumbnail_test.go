@@ -0,0 +1,38 @@
+package media
+
+import "testing"
+
+func TestBestFrame(t *testing.T) {
+	const w, h = 16, 16
+	flat := func(v byte) []byte {
+		f := make([]byte, w*h)
+		for i := range f {
+			f[i] = v
+		}
+		return f
+	}
+	checker := func(lo, hi byte) []byte {
+		f := make([]byte, w*h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				f[y*w+x] = lo
+				if (x/2+y/2)%2 == 0 {
+					f[y*w+x] = hi
+				}
+			}
+		}
+		return f
+	}
+
+	// 模糊、清晰 (穩定)、清晰但太暗、模糊
+	frames := [][]byte{flat(120), checker(60, 200), checker(60, 200), checker(0, 30), flat(120)}
+	if got := bestFrame(frames, w, h); got != 1 && got != 2 {
+		t.Errorf("應挑選清晰且曝光正常的畫面，得到 %d", got)
+	}
+	if got := bestFrame(nil, w, h); got != 0 {
+		t.Errorf("沒有畫面應回傳 0，得到 %d", got)
+	}
+	if v := laplacianVariance(flat(100), w, h); v != 0 {
+		t.Errorf("純色畫面清晰度應為 0，得到 %f", v)
+	}
+}
@@ -0,0 +1,229 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+const timelineFile = "timeline.json"
+
+// TimelineLine 字幕行與對應的單句語音
+type TimelineLine struct {
+	SubtitleLine
+	Audio    string  `json:"audio"`              // 單句語音檔 (已修剪、統一為 pcm 24k mono)
+	AudioSec float64 `json:"audio_sec"`          // 單句語音長度 (秒)
+	Dirty    bool    `json:"dirty,omitempty"`    // 文字已修改，需重新合成語音
+	Provider string  `json:"provider,omitempty"` // 實際合成語音的 TTS provider
+	Speech   string  `json:"speech,omitempty"`   // 送往 TTS 的文字 (含行內標記)，空白表示與字幕相同
+}
+
+// SpeechText 回傳合成語音用的文字
+func (l TimelineLine) SpeechText() string {
+	if l.Speech != "" {
+		return l.Speech
+	}
+	return l.Text
+}
+
+// Timeline 字幕時間軸，保存於工單目錄供審閱與後續流程使用
+type Timeline struct {
+	Lines []TimelineLine `json:"lines"`
+	Tempo float64        `json:"tempo,omitempty"` // 為達語速目標套用在每句語音的 atempo 倍率，重新合成時沿用
+}
+
+// ProviderUsage 統計各 TTS provider 合成的句數
+func (t *Timeline) ProviderUsage() map[string]int {
+	usage := map[string]int{}
+	for _, l := range t.Lines {
+		if l.Provider != "" {
+			usage[l.Provider]++
+		}
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+	return usage
+}
+
+// SubtitleLines 取出純字幕行
+func (t *Timeline) SubtitleLines() []SubtitleLine {
+	lines := make([]SubtitleLine, 0, len(t.Lines))
+	for _, l := range t.Lines {
+		lines = append(lines, l.SubtitleLine)
+	}
+	return lines
+}
+
+// SaveTimeline 將時間軸寫入工單目錄
+func SaveTimeline(base string, tl *Timeline) error {
+	b, err := json.MarshalIndent(tl, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(base, timelineFile+".tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(base, timelineFile))
+}
+
+// LoadTimeline 讀取工單目錄中的時間軸
+func LoadTimeline(base string) (*Timeline, error) {
+	b, err := os.ReadFile(filepath.Join(base, timelineFile))
+	if err != nil {
+		return nil, err
+	}
+	var tl Timeline
+	if err := json.Unmarshal(b, &tl); err != nil {
+		return nil, err
+	}
+	return &tl, nil
+}
+
+// maxStretch 單句語音最多加速倍率，超過時仍會被時間窗截斷
+const maxStretch = 2.0
+
+// BuildVoiceTrack 依時間軸把每句語音放進各自的時間窗 (不足補靜音、過長截斷)，合併為 voice.wav
+// 時間窗以字幕 Start/End 為準；stretch 為 true 時，過長的語音先以 atempo 加速塞進時間窗
+func BuildVoiceTrack(base string, lines []TimelineLine, stretch bool) (string, error) {
+	dir := filepath.Join(base, "voice")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	var parts []string
+	cursor := 0
+	for i, l := range lines {
+		start := l.Start
+		if start < cursor {
+			// 與上一句重疊，從上一句結束處開始
+			start = cursor
+		}
+		if gap := start - cursor; gap > 0 {
+			gapPath := filepath.Join(dir, fmt.Sprintf("gap_%03d.wav", i))
+			if _, err := utils.RunCmd("ffmpeg", "-y", "-f", "lavfi", "-i", "anullsrc=r=24000:cl=mono", "-t", fmt.Sprintf("%.3f", float64(gap)/1000.0), "-c:a", "pcm_s16le", gapPath); err != nil {
+				return "", fmt.Errorf("建立間隔靜音失敗(line %d): %w", i, err)
+			}
+			parts = append(parts, gapPath)
+		}
+
+		window := float64(l.End-start) / 1000.0
+		if window <= 0 {
+			continue
+		}
+		fitPath := filepath.Join(dir, fmt.Sprintf("fit_%03d.wav", i))
+		filter := fmt.Sprintf("apad=whole_dur=%.3f,atrim=0:%.3f", window, window)
+		if stretch && l.AudioSec > window*1.01 {
+			factor := l.AudioSec / window
+			if factor > maxStretch {
+				log.Warn().Int("line", i).Float64("factor", factor).Msg("語音過長，加速後仍會被截斷")
+				factor = maxStretch
+			}
+			filter = atempoChain(factor) + "," + filter
+		}
+		if out, err := utils.RunCmd("ffmpeg", "-y", "-i", l.Audio, "-af", filter, "-c:a", "pcm_s16le", "-ar", "24000", "-ac", "1", fitPath); err != nil {
+			return "", fmt.Errorf("調整語音長度失敗(line %d): %v / %s", i, err, out)
+		}
+		parts = append(parts, fitPath)
+		cursor = l.End
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("時間軸沒有可用的語音片段")
+	}
+
+	var list []string
+	for _, p := range parts {
+		list = append(list, fmt.Sprintf("file '%s'", p))
+	}
+	concatTxt := filepath.Join(base, "voice_list.txt")
+	if err := os.WriteFile(concatTxt, []byte(strings.Join(list, "\n")), 0o644); err != nil {
+		return "", err
+	}
+
+	voiceOut := filepath.Join(base, "voice.wav")
+	if out, err := utils.RunCmdTimeout(2*time.Minute, "ffmpeg", "-y", "-f", "concat", "-safe", "0", "-i", concatTxt, "-c:a", "copy", voiceOut); err != nil {
+		return "", fmt.Errorf("合併語音失敗: %v / %s", err, out)
+	}
+	return voiceOut, nil
+}
+
+// ChangeTempo 以 atempo 調整單句語音的速度 (不改變音高)，輸出覆蓋原檔並回傳新長度
+func ChangeTempo(path string, factor float64) (float64, error) {
+	tmp := strings.TrimSuffix(path, filepath.Ext(path)) + "_tempo.wav"
+	if out, err := utils.RunCmdTimeout(time.Minute, "ffmpeg", "-y", "-i", path, "-af", atempoChain(factor), "-c:a", "pcm_s16le", "-ar", "24000", "-ac", "1", tmp); err != nil {
+		return 0, fmt.Errorf("調整語速失敗: %v / %s", err, out)
+	}
+	// 先量測新長度再取代原檔，失敗時原檔不變，呼叫端可沿用原語速與原長度
+	dur, err := utils.AudioDurationSeconds(tmp)
+	if err == nil && dur <= 0 {
+		err = fmt.Errorf("長度為 0")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, fmt.Errorf("讀取調整語速後的長度失敗: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return dur, nil
+}
+
+// atempoChain 產生 atempo 濾鏡鏈，單一 atempo 只接受 0.5~2.0，超出範圍時串接多個
+func atempoChain(factor float64) string {
+	var parts []string
+	for factor > 2.0 {
+		parts = append(parts, "atempo=2.0")
+		factor /= 2.0
+	}
+	for factor < 0.5 {
+		parts = append(parts, "atempo=0.5")
+		factor /= 0.5
+	}
+	parts = append(parts, fmt.Sprintf("atempo=%.4f", factor))
+	return strings.Join(parts, ",")
+}
+
+// LoadTranscript 讀取並解析任務提供的 SRT/WebVTT 字幕
+func LoadTranscript(t job.TranscriptSetting) ([]utils.SubtitleSegment, error) {
+	content := t.Content
+	if strings.TrimSpace(content) == "" {
+		b, err := os.ReadFile(strings.TrimSpace(t.Path))
+		if err != nil {
+			return nil, fmt.Errorf("讀取字幕檔失敗: %w", err)
+		}
+		content = string(b)
+	}
+	return utils.ParseCaptions(content)
+}
+
+// PrepareVoiceover 取得自備旁白並轉為 pcm 24k mono 的 voice.wav
+func PrepareVoiceover(base string, v job.VoiceoverSetting) (string, error) {
+	src := strings.TrimSpace(v.Path)
+	if v.Source == "url" {
+		ext := filepath.Ext(src)
+		if ext == "" || len(ext) > 5 {
+			ext = ".mp3"
+		}
+		dl := filepath.Join(base, "voiceover"+ext)
+		if out, err := utils.RunCmd("curl", "-f", "-L", "-o", dl, src); err != nil {
+			return "", fmt.Errorf("下載旁白失敗: %s %v", out, err)
+		}
+		src = dl
+	}
+
+	voiceOut := filepath.Join(base, "voice.wav")
+	if out, err := utils.RunCmdTimeout(2*time.Minute, "ffmpeg", "-y", "-i", src, "-vn", "-c:a", "pcm_s16le", "-ar", "24000", "-ac", "1", voiceOut); err != nil {
+		return "", fmt.Errorf("轉換旁白格式失敗: %v / %s", err, out)
+	}
+	return voiceOut, nil
+}
@@ -0,0 +1,16 @@
+package media
+
+import "testing"
+
+func TestAtempoChain(t *testing.T) {
+	cases := map[float64]string{
+		1.25: "atempo=1.2500",
+		3.0:  "atempo=2.0,atempo=1.5000",
+		0.3:  "atempo=0.5,atempo=0.6000",
+	}
+	for factor, want := range cases {
+		if got := atempoChain(factor); got != want {
+			t.Errorf("atempoChain(%v) = %s, 期望 %s", factor, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// buildTransitionFilter 依每段的轉場設定串接片段：有轉場用 xfade + acrossfade，none 用 concat 直接切換
+// durations 為各片段實際長度 (已含為轉場延長的部分)
+func buildTransitionFilter(segments []Segment, durations []float64, global string) string {
+	var parts []string
+	prevV, prevA := "0:v", "0:a"
+	// total 為目前已串接的影片長度，xfade 的 offset 以串接結果的開頭為基準
+	total := durations[0]
+	for i := 0; i < len(durations)-1; i++ {
+		outV, outA := fmt.Sprintf("v%d", i+1), fmt.Sprintf("a%d", i+1)
+		if i == len(durations)-2 {
+			outV, outA = "outv", "outa"
+		}
+
+		name, d, overlap := segments[i].outgoing(global)
+		if overlap == 0 {
+			parts = append(parts, fmt.Sprintf("[%s][%s][%d:v][%d:a]concat=n=2:v=1:a=1[%s][%s]", prevV, prevA, i+1, i+1, outV, outA))
+			total += durations[i+1]
+		} else {
+			offset := total - overlap
+			// xfade 只使用轉場長度，保留 0.2 秒的安全緩衝
+			parts = append(parts, fmt.Sprintf("[%s][%d:v]xfade=transition=%s:duration=%.2f:offset=%.2f[%s]", prevV, i+1, name, d, offset, outV))
+			// acrossfade duration 必須等於 overlap，以保持音訊同步
+			parts = append(parts, fmt.Sprintf("[%s][%d:a]acrossfade=d=%.2f[%s]", prevA, i+1, overlap, outA))
+			total += durations[i+1] - overlap
+		}
+		prevV, prevA = outV, outA
+	}
+	return strings.Join(parts, ";")
+}
+
+// TransitionPreview 產生轉場進行到一半的預覽縮圖，結果快取於 dir
+func TransitionPreview(dir, name string) (string, error) {
+	if name == "" || name == "none" || !job.IsTransition(name) {
+		return "", fmt.Errorf("不支援的轉場: %s", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	out := filepath.Join(dir, name+".png")
+	if _, err := os.Stat(out); err == nil {
+		return out, nil
+	}
+
+	// 兩段不同顏色的測試畫面，取轉場中間的一幀
+	tmp := filepath.Join(dir, fmt.Sprintf("%s_%d.png", name, time.Now().UnixNano()))
+	filter := fmt.Sprintf("[0:v][1:v]xfade=transition=%s:duration=1:offset=0.5,format=rgb24[v]", name)
+	if out, err := utils.RunCmd("ffmpeg", "-y",
+		"-f", "lavfi", "-i", "testsrc2=s=216x384:r=25:d=2",
+		"-f", "lavfi", "-i", "color=c=0x3366CC:s=216x384:r=25:d=2",
+		"-filter_complex", filter, "-map", "[v]",
+		"-ss", "1", "-frames:v", "1", tmp); err != nil {
+		return "", fmt.Errorf("產生轉場預覽失敗: %v / %s", err, out)
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
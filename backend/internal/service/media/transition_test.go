@@ -0,0 +1,22 @@
+package media
+
+import "testing"
+
+func TestBuildTransitionFilter(t *testing.T) {
+	segments := []Segment{
+		{Transition: ""},                             // 沿用全域 fade，預設 1 秒
+		{Transition: "none"},                         // 直接切換
+		{Transition: "wipeleft", TransitionSec: 0.5}, // 自訂長度
+		{Transition: "slideup"},                      // 最後一段的轉場不使用
+	}
+	durations := []float64{4.2, 3.0, 2.7, 3.0}
+	got := buildTransitionFilter(segments, durations, "fade")
+	want := "[0:v][1:v]xfade=transition=fade:duration=1.00:offset=3.00[v1];" +
+		"[0:a][1:a]acrossfade=d=1.20[a1];" +
+		"[v1][a1][2:v][2:a]concat=n=2:v=1:a=1[v2][a2];" +
+		"[v2][3:v]xfade=transition=wipeleft:duration=0.50:offset=8.00[outv];" +
+		"[a2][3:a]acrossfade=d=0.70[outa]"
+	if got != want {
+		t.Errorf("轉場濾鏡錯誤\n got: %s\nwant: %s", got, want)
+	}
+}
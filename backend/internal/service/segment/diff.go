@@ -0,0 +1,68 @@
+package segment
+
+// matchRunes 以 Myers O(ND) 差異演算法比對 a 與 b，回傳 b 每個字元對應到 a 的索引 (未對應為 -1)；
+// 編輯距離超過 maxD 時放棄比對並回傳 false
+func matchRunes(a, b []rune, maxD int) ([]int, bool) {
+	n, m := len(a), len(b)
+	match := make([]int, m)
+	for i := range match {
+		match[i] = -1
+	}
+	maxD = min(maxD, n+m)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] 保存第 d 步開始前的 v[-d..d]，回溯時用來找出每一步的來源
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 往下：b 多出一個字
+			} else {
+				x = v[offset+k-1] + 1 // 往右：a 少了一個字
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				backtrack(trace, a, b, d, match)
+				return match, true
+			}
+		}
+	}
+	return match, false
+}
+
+// backtrack 由終點回溯編輯路徑，記錄相同字元的對應
+func backtrack(trace [][]int, a, b []rune, dEnd int, match []int) {
+	x, y := len(a), len(b)
+	for d := dEnd; d > 0; d-- {
+		prev := trace[d] // 第 d-1 步結束後的 v[-d..d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+		// 一次編輯之後的斜線部分為相同字元
+		midX := prevX
+		if prevK == k-1 {
+			midX++
+		}
+		for x > midX {
+			x, y = x-1, y-1
+			match[y] = x
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		match[y] = x
+	}
+}
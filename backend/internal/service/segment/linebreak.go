@@ -0,0 +1,315 @@
+package segment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// class UAX #14 斷行類別 (只實作字幕常見的子集)
+type class uint8
+
+const (
+	clsAL  class = iota // 字母與其他符號 (預設)
+	clsBK               // 強制換行
+	clsCR               // \r
+	clsLF               // \n
+	clsSP               // 空白
+	clsZW               // 零寬空白
+	clsZWJ              // 零寬連接字元
+	clsWJ               // 禁止斷行的零寬字元
+	clsGL               // 不斷行空白
+	clsCM               // 組合符號
+	clsOP               // 開括號
+	clsCL               // 閉括號與中日文句讀
+	clsCP               // 右圓括號、右方括號
+	clsQU               // 引號
+	clsEX               // 驚嘆號、問號
+	clsIS               // 數字間的逗點、句點、冒號
+	clsSY               // 斜線
+	clsNS               // 不可置於行首 (々、ゝ、・ 等)
+	clsIN               // 刪節號
+	clsPR               // 數字前綴 (貨幣符號)
+	clsPO               // 數字後綴 (%、℃)
+	clsNU               // 數字
+	clsID               // 表意文字，前後皆可斷行
+	clsHY               // 連字號
+	clsBA               // 其後可斷行
+	clsBB               // 其前可斷行
+	clsB2               // 破折號
+	clsSA               // 東南亞文字，需依詞切分
+	clsCJ               // 小假名與長音符號
+)
+
+// classOf 查詢字元的預設斷行類別
+func classOf(r rune) class {
+	switch r {
+	case '\n':
+		return clsLF
+	case '\r':
+		return clsCR
+	case '\v', '\f', 0x85, 0x2028, 0x2029:
+		return clsBK
+	case ' ':
+		return clsSP
+	case '\t', '|', 0x00AD, 0x058A, 0x2010, 0x2012, 0x2013, 0x2027, 0x3000:
+		return clsBA
+	case 0x200B:
+		return clsZW
+	case 0x200D:
+		return clsZWJ
+	case 0x2060, 0xFEFF:
+		return clsWJ
+	case 0x00A0, 0x2007, 0x202F, 0x034F:
+		return clsGL
+	case '-':
+		return clsHY
+	case 0x2014, 0x2E3A, 0x2E3B:
+		return clsB2
+	case 0x00B4, 0x02C8, 0x02CC, 0x02DF:
+		return clsBB
+	case '(', '[', '{', 0x00A1, 0x00BF:
+		return clsOP
+	case ')', ']':
+		return clsCP
+	case '}':
+		return clsCL
+	case '"', '\'', 0x00AB, 0x00BB, 0x2018, 0x2019, 0x201C, 0x201D, 0x2039, 0x203A:
+		return clsQU
+	case '!', '?', 0xFF01, 0xFF1F:
+		return clsEX
+	case ',', '.', ':', ';', 0x037E:
+		return clsIS
+	case '/':
+		return clsSY
+	case 0x2025, 0x2026, 0x22EF:
+		return clsIN
+	case '%', 0xFF05, 0x2030, 0x2031, 0x00B0, 0x2103, 0x2109, 0x2032, 0x2033, 0x2034, 0x00A2, 0xFFE0:
+		return clsPO
+	case '+', '#', '\\', 0x2116:
+		return clsPR
+	case 0x3005, 0x303B, 0x309B, 0x309C, 0x309D, 0x309E, 0x30A0, 0x30FB, 0x30FD, 0x30FE,
+		0xFF1A, 0xFF1B, 0xFF65, 0x203C, 0x2047, 0x2048, 0x2049, 0x301C:
+		return clsNS
+	case 0x3041, 0x3043, 0x3045, 0x3047, 0x3049, 0x3063, 0x3083, 0x3085, 0x3087, 0x308E, 0x3095, 0x3096,
+		0x30A1, 0x30A3, 0x30A5, 0x30A7, 0x30A9, 0x30C3, 0x30E3, 0x30E5, 0x30E7, 0x30EE, 0x30F5, 0x30F6, 0x30FC,
+		0xFF67, 0xFF68, 0xFF69, 0xFF6A, 0xFF6B, 0xFF6C, 0xFF6D, 0xFF6E, 0xFF6F, 0xFF70:
+		return clsCJ
+	}
+	switch {
+	case strings.ContainsRune("「『（【《〈〔［｛〖〘〚｢", r):
+		return clsOP
+	case strings.ContainsRune("」』）】》〉〕］｝〗〙〛｣、。，．､｡", r):
+		return clsCL
+	case r >= 0x31F0 && r <= 0x31FF:
+		return clsCJ
+	case unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me):
+		return clsCM
+	case unicode.IsControl(r):
+		return clsCM
+	case unicode.Is(unicode.Sc, r):
+		return clsPR
+	case unicode.IsDigit(r):
+		return clsNU
+	case isSARune(r):
+		return clsSA
+	case r >= 0x1160 && r <= 0x11FF:
+		// 諺文中聲與終聲接在初聲後
+		return clsCM
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return clsID
+	case RuneWidth(r) == 2:
+		// 其餘全形符號與表情符號
+		return clsID
+	}
+	return clsAL
+}
+
+// isSARune 泰文、寮文、緬甸文、高棉文等不以空白分詞的文字
+func isSARune(r rune) bool {
+	return (r >= 0x0E00 && r <= 0x0EFF) || (r >= 0x1000 && r <= 0x109F) || (r >= 0x1780 && r <= 0x17FF)
+}
+
+// Break 斷行機會
+type Break struct {
+	Pos       int  // 位元組位置，可在此位置之前斷行
+	Mandatory bool // 換行字元造成的強制斷行
+}
+
+// LineBreaks 依 UAX #14 (套用語系調整) 列出 text 中的斷行機會，不含開頭與結尾
+func (s Segmenter) LineBreaks(text string) []Break {
+	runes, offsets := decode(text)
+	var out []Break
+	for _, b := range s.breaks(runes) {
+		if b.Pos > 0 && b.Pos < len(runes) {
+			out = append(out, Break{Pos: offsets[b.Pos], Mandatory: b.Mandatory})
+		}
+	}
+	return out
+}
+
+// decode 拆成字元並記錄每個字元的位元組位置 (最後一項為字串長度)
+func decode(text string) ([]rune, []int) {
+	runes := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	return runes, append(offsets, len(text))
+}
+
+// resolve 依語系將類別收斂 (LB1)：小假名在日文禁止置於行首，韓文詞內不斷行
+func (s Segmenter) resolve(r rune) class {
+	c := classOf(r)
+	switch c {
+	case clsCJ:
+		if s.strict {
+			return clsNS
+		}
+		return clsID
+	case clsSA:
+		// 東南亞文字另以音節規則補上斷點
+		return clsAL
+	case clsID:
+		if s.keepAll && unicode.In(r, unicode.Han, unicode.Hangul) {
+			return clsAL
+		}
+	}
+	return c
+}
+
+// breaks 回傳各字元之前的斷行機會 (以字元索引表示)
+func (s Segmenter) breaks(runes []rune) []Break {
+	n := len(runes)
+	if n == 0 {
+		return nil
+	}
+	raw := make([]class, n)
+	eff := make([]class, n)     // 組合符號改用所附著字元的類別 (LB9、LB10)
+	attached := make([]bool, n) // 組合符號附著在前一字元，不可斷開
+	for i, r := range runes {
+		raw[i] = s.resolve(r)
+		eff[i] = raw[i]
+		if raw[i] != clsCM && raw[i] != clsZWJ {
+			continue
+		}
+		if i > 0 && !isBreakSpace(eff[i-1]) {
+			eff[i] = eff[i-1]
+			attached[i] = true
+		} else {
+			eff[i] = clsAL
+		}
+	}
+	sa := syllableBreaks(runes)
+
+	var out []Break
+	for i := 1; i < n; i++ {
+		if ok, mandatory := pairBreak(raw, eff, attached, i); ok || sa[i] {
+			out = append(out, Break{Pos: i, Mandatory: mandatory})
+		}
+	}
+	return out
+}
+
+func isBreakSpace(c class) bool {
+	switch c {
+	case clsSP, clsBK, clsCR, clsLF, clsZW:
+		return true
+	}
+	return false
+}
+
+// pairBreak 判斷第 i 個字元之前能否斷行，依序套用 UAX #14 的 LB4 ~ LB31
+func pairBreak(raw, eff []class, attached []bool, i int) (bool, bool) {
+	a, b := eff[i-1], eff[i]
+	// LB4、LB5：換行字元之後必須斷行，\r\n 視為一個
+	switch {
+	case a == clsCR && b == clsLF:
+		return false, false
+	case a == clsBK || a == clsCR || a == clsLF:
+		return true, true
+	}
+	// LB6、LB7：換行字元與空白之前不斷行
+	switch b {
+	case clsBK, clsCR, clsLF, clsSP, clsZW:
+		return false, false
+	}
+	// 往回略過空白，取得空白前的類別 (LB8、LB14 ~ LB17)
+	j := i - 1
+	for j > 0 && eff[j] == clsSP {
+		j--
+	}
+	before := eff[j]
+	switch {
+	case before == clsZW: // LB8
+		return true, false
+	case raw[i-1] == clsZWJ, attached[i]: // LB8a、LB9
+		return false, false
+	case a == clsWJ || b == clsWJ: // LB11
+		return false, false
+	case a == clsGL: // LB12
+		return false, false
+	case b == clsGL && a != clsSP && a != clsBA && a != clsHY: // LB12a
+		return false, false
+	}
+	switch b {
+	case clsCL, clsCP, clsEX, clsIS, clsSY: // LB13
+		return false, false
+	}
+	switch {
+	case before == clsOP: // LB14
+		return false, false
+	case before == clsQU && b == clsOP: // LB15
+		return false, false
+	case (before == clsCL || before == clsCP) && b == clsNS: // LB16
+		return false, false
+	case before == clsB2 && b == clsB2: // LB17
+		return false, false
+	case a == clsSP: // LB18
+		return true, false
+	case a == clsQU || b == clsQU: // LB19
+		return false, false
+	case b == clsBA || b == clsHY || b == clsNS || a == clsBB: // LB21
+		return false, false
+	case b == clsIN: // LB22
+		return false, false
+	case (a == clsAL && b == clsNU) || (a == clsNU && b == clsAL): // LB23
+		return false, false
+	case (a == clsPR && b == clsID) || (a == clsID && b == clsPO): // LB23a
+		return false, false
+	case (a == clsPR || a == clsPO) && b == clsAL, a == clsAL && (b == clsPR || b == clsPO): // LB24
+		return false, false
+	case numericPair(a, b): // LB25
+		return false, false
+	case a == clsAL && b == clsAL: // LB28
+		return false, false
+	case a == clsIS && b == clsAL: // LB29
+		return false, false
+	case (a == clsAL || a == clsNU) && b == clsOP, a == clsCP && (b == clsAL || b == clsNU): // LB30
+		return false, false
+	}
+	return true, false // LB31
+}
+
+// numericPair LB25 的逐對近似：數字與其前後綴、小數點、千分位不斷開
+func numericPair(a, b class) bool {
+	switch {
+	case (a == clsCL || a == clsCP || a == clsNU) && (b == clsPO || b == clsPR):
+		return true
+	case (a == clsPO || a == clsPR) && (b == clsOP || b == clsNU):
+		return true
+	case b == clsNU:
+		return a == clsHY || a == clsIS || a == clsSY || a == clsNU || a == clsOP
+	}
+	return false
+}
+
+// graphemeBreaks 字元叢集 (字元加上組合符號) 的邊界，單一片段過寬時強制斷行用
+func graphemeBreaks(runes []rune) []bool {
+	out := make([]bool, len(runes)+1)
+	for i := 1; i < len(runes); i++ {
+		c := classOf(runes[i])
+		out[i] = c != clsCM && c != clsZWJ && classOf(runes[i-1]) != clsZWJ
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+// Package segment 依語系切分字幕文字：斷行規則依 UAX #14、詞邊界依 UAX #29 (皆為子集)，
+// 寬度以顯示欄數計算 (全形與中日韓文字為 2 欄)，作為 AI 斷句失敗時的確定性備援
+package segment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Segmenter 單一語系的斷句與換行策略
+type Segmenter struct {
+	Lang string // zh、ja、ko、th 或其他以空白分詞的語言 (空字串)
+
+	keepAll   bool   // 漢字與諺文詞內不斷行，只在空白與標點處斷行 (韓文)
+	strict    bool   // 小假名與長音符號不可置於行首 (日文禁則)
+	spaced    bool   // 以空白分詞，合併片段時補上空白
+	spaceCut  bool   // 空白即為子句分隔 (泰文)
+	particles string // 優先在這些虛詞之後斷行
+}
+
+// For 取得語系的策略，locale 可為 zh-TW、ja-JP、ko-KR、th-TH 等，未列出的語系以空白分詞
+func For(locale string) Segmenter {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case "zh", "cmn", "yue":
+		return Segmenter{Lang: "zh", particles: "的了著着過过吧呢嗎吗啊"}
+	case "ja":
+		return Segmenter{Lang: "ja", strict: true, particles: "はがをにへ"}
+	case "ko":
+		return Segmenter{Lang: "ko", keepAll: true, spaced: true}
+	case "th":
+		return Segmenter{Lang: "th", spaceCut: true}
+	}
+	return Segmenter{Lang: lang, spaced: true}
+}
+
+// Detect 依文字中佔多數的文字系統判斷語系：含假名為 ja，諺文為 ko，泰文為 th，漢字為 zh，
+// 其他回傳空字串 (以空白分詞)
+func Detect(text string) string {
+	var han, kana, hangul, thai, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.IsLetter(r):
+			latin++
+		}
+	}
+	// 拉丁字母以四個字母粗估一個字
+	latin /= 4
+	switch {
+	case kana > 0 && kana+han >= latin:
+		return "ja"
+	case hangul > 0 && hangul >= han && hangul >= latin:
+		return "ko"
+	case thai > 0 && thai >= latin:
+		return "th"
+	case han > 0 && han >= latin:
+		return "zh"
+	}
+	return ""
+}
+
+// ForText 取得語系的策略，locale 空白時依文字內容判斷 (見 Detect)
+func ForText(locale, text string) Segmenter {
+	if locale == "" {
+		locale = Detect(text)
+	}
+	return For(locale)
+}
+
+// 斷點成本，以 max 欄數平方的百分比計：優先減少行數，其次各行長度平均，並避開較差的斷點
+const (
+	penaltyNone      = 0
+	penaltyIdeograph = 15   // 表意文字之間
+	penaltyForced    = 1000 // 片段過寬，只能在字元之間強制斷開
+)
+
+// opportunity 換行演算法使用的斷點 (位元組位置)
+type opportunity struct {
+	pos       int
+	mandatory bool
+	penalty   int
+}
+
+// Wrap 將文字換行為每行不超過 maxCols 欄，行數最少且各行長度盡量平均；
+// 換行字元一定換行，maxCols <= 0 時只依換行字元切分。回傳的各行已去除前後空白
+func (s Segmenter) Wrap(text string, maxCols int) []string {
+	runes, offsets := decode(text)
+	opps := s.opportunities(runes, offsets)
+
+	var out []string
+	start := 0
+	var para []opportunity
+	for _, o := range opps {
+		if !o.mandatory {
+			para = append(para, o)
+			continue
+		}
+		out = append(out, s.wrapParagraph(text, start, o.pos, para, runes, offsets, maxCols)...)
+		start, para = o.pos, nil
+	}
+	out = append(out, s.wrapParagraph(text, start, len(text), para, runes, offsets, maxCols)...)
+
+	lines := out[:0]
+	for _, l := range out {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// opportunities 斷行機會與其成本
+func (s Segmenter) opportunities(runes []rune, offsets []int) []opportunity {
+	var out []opportunity
+	for _, b := range s.breaks(runes) {
+		o := opportunity{pos: offsets[b.Pos], mandatory: b.Mandatory}
+		if !b.Mandatory {
+			o.penalty = s.penalty(runes, b.Pos)
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// penalty 斷在第 i 個字元之前的成本：空白與標點之後最好；中日文字之間次之，
+// 但在虛詞之後、或換成其他文字系統時不加成本
+func (s Segmenter) penalty(runes []rune, i int) int {
+	prev, cur := runes[i-1], runes[i]
+	if !isIdeographic(prev) || !isIdeographic(cur) {
+		return penaltyNone
+	}
+	if strings.ContainsRune(s.particles, prev) && !strings.ContainsRune(s.particles, cur) {
+		return penaltyNone
+	}
+	if s.Lang == "ja" && unicode.Is(unicode.Hiragana, prev) && !unicode.Is(unicode.Hiragana, cur) {
+		// 平假名之後接漢字或片假名大致是詞的邊界 (漢字之後的平假名多為送假名，不算)
+		return penaltyNone
+	}
+	return penaltyIdeograph
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// wrapParagraph 以動態規劃換行 text[start:end]：先求最少行數，再求各行剩餘欄數平方和加上斷點成本最小
+func (s Segmenter) wrapParagraph(text string, start, end int, opps []opportunity, runes []rune, offsets []int, maxCols int) []string {
+	if maxCols <= 0 {
+		return []string{strings.TrimSpace(text[start:end])}
+	}
+	// 端點：段首、各斷行機會、段尾；任一片段過寬時在其中補上字元邊界
+	points := []opportunity{{pos: start}}
+	graphemes := graphemeBreaks(runes)
+	idx := runeIndex(offsets)
+	prev := start
+	for _, o := range append(opps, opportunity{pos: end}) {
+		if Width(strings.TrimSpace(text[prev:o.pos])) > maxCols {
+			for i := idx[prev] + 1; i < idx[o.pos]; i++ {
+				if graphemes[i] && !unicode.IsSpace(runes[i]) {
+					points = append(points, opportunity{pos: offsets[i], penalty: penaltyForced})
+				}
+			}
+		}
+		points = append(points, o)
+		prev = o.pos
+	}
+
+	type state struct {
+		lines int
+		cost  int
+		from  int
+	}
+	n := len(points)
+	best := make([]state, n)
+	for j := 1; j < n; j++ {
+		best[j] = state{lines: -1}
+		for i := j - 1; i >= 0; i-- {
+			if best[i].lines < 0 {
+				continue
+			}
+			w := Width(strings.TrimSpace(text[points[i].pos:points[j].pos]))
+			if w > maxCols {
+				if j-i > 1 {
+					break // 更早的起點只會更寬
+				}
+				// 單一字元叢集仍過寬，只能獨立成行
+			}
+			slack := max(maxCols-w, 0)
+			cand := state{lines: best[i].lines + 1, cost: best[i].cost + slack*slack + points[j].penalty*maxCols*maxCols/100, from: i}
+			if best[j].lines < 0 || cand.lines < best[j].lines || (cand.lines == best[j].lines && cand.cost < best[j].cost) {
+				best[j] = cand
+			}
+		}
+	}
+
+	var lines []string
+	for j := n - 1; j > 0; j = best[j].from {
+		lines = append(lines, strings.TrimSpace(text[points[best[j].from].pos:points[j].pos]))
+	}
+	for i, k := 0, len(lines)-1; i < k; i, k = i+1, k-1 {
+		lines[i], lines[k] = lines[k], lines[i]
+	}
+	return lines
+}
+
+// runeIndex 位元組位置對應的字元索引
+func runeIndex(offsets []int) map[int]int {
+	m := make(map[int]int, len(offsets))
+	for i, o := range offsets {
+		m[o] = i
+	}
+	return m
+}
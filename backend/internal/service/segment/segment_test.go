@@ -0,0 +1,205 @@
+package segment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"你好", 4},
+		{"AI工具", 6},
+		{"３５％", 6},
+		{"ｱｲｳ", 3}, // 半形片假名
+		{"é", 1},   // e + 組合重音符號
+		{"กี่", 1}, // 泰文子音加上母音與聲調符號
+		{"👍ok", 4}, // 表情符號
+		{"a​b", 2},
+	}
+	for _, c := range cases {
+		if got := Width(c.text); got != c.want {
+			t.Errorf("Width(%q) = %d，期望 %d", c.text, got, c.want)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"今天我們來聊聊短影音", 32},
+		{"Short videos are easy to make", 16},
+		{"用AI工具剪輯短影音", 32},
+		{"", 16},
+	}
+	for _, c := range cases {
+		if got := Columns(16, c.text); got != c.want {
+			t.Errorf("Columns(16, %q) = %d，期望 %d", c.text, got, c.want)
+		}
+	}
+}
+
+// breakString 以 | 標示斷行機會，方便比對
+func breakString(s Segmenter, text string) string {
+	var b strings.Builder
+	prev := 0
+	for _, o := range s.LineBreaks(text) {
+		b.WriteString(text[prev:o.Pos])
+		b.WriteByte('|')
+		prev = o.Pos
+	}
+	b.WriteString(text[prev:])
+	return b.String()
+}
+
+func TestLineBreaks(t *testing.T) {
+	cases := []struct {
+		locale string
+		text   string
+		want   string
+	}{
+		// 空白之後、連字號之後可斷行，縮寫的撇號與句尾標點不斷開
+		{"en", "Hello, world! It's state-of-the-art.", "Hello, |world! |It's |state-|of-|the-|art."},
+		// 貨幣、小數點、百分比與括號跟著數字
+		{"en", "Pay $3.14 (or 35%) now", "Pay |$3.14 |(or |35%) |now"},
+		// 漢字之間可斷行，句讀不置於行首，開括號不置於行尾
+		{"zh", "你好，世界。「測試」（括號）", "你|好，|世|界。|「測|試」|（括|號）"},
+		// 日文禁則：小假名與長音符號不置於行首
+		{"ja", "ちょっと待ってください。ラーメン", "ちょっ|と|待っ|て|く|だ|さ|い。|ラー|メ|ン"},
+		{"zh", "ちょっと", "ち|ょ|っ|と"},
+		// 韓文詞內不斷行，只在空白處斷行
+		{"ko", "오늘은 날씨가 좋네요.", "오늘은 |날씨가 |좋네요."},
+		{"zh", "오늘은", "오|늘|은"},
+		// 泰文以音節規則補上斷點
+		{"th", "เราไปปิกนิกที่สวน", "เรา|ไปปิกนิกที่สวน"},
+		// 不斷行空白、零寬空白與換行
+		{"en", "a b c​d\ne", "a b |c​|d\n|e"},
+		// 組合符號附著在前一字元
+		{"fr", "été x", "été |x"},
+	}
+	for _, c := range cases {
+		if got := breakString(For(c.locale), c.text); got != c.want {
+			t.Errorf("[%s] %q 斷點 = %q，期望 %q", c.locale, c.text, got, c.want)
+		}
+	}
+}
+
+func TestLineBreaksMandatory(t *testing.T) {
+	breaks := For("en").LineBreaks("one\r\ntwo three")
+	want := []Break{{Pos: 5, Mandatory: true}, {Pos: 9}}
+	if !reflect.DeepEqual(breaks, want) {
+		t.Errorf("斷點 = %+v，期望 %+v", breaks, want)
+	}
+}
+
+func TestWords(t *testing.T) {
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Don't pay 1,000.50 for e.g. this_one!", []string{"Don't", "pay", "1,000.50", "for", "e.g", "this_one"}},
+		{"用AI工具", []string{"用", "AI", "工", "具"}},
+		{"ラーメンを食べる", []string{"ラーメン", "を", "食", "べ", "る"}},
+		{"오늘은 날씨가", []string{"오늘은", "날씨가"}},
+		{"เราไปโรงเรียน", []string{"เรา", "ไป", "โรง", "เรียน"}},
+	}
+	for _, c := range cases {
+		if got := Words(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Words(%q) = %q，期望 %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cases := map[string]string{
+		"今天天氣很好":              "zh",
+		"今日はいい天気":             "ja",
+		"오늘은 날씨가 좋네요":         "ko",
+		"วันนี้อากาศดี":       "th",
+		"Hello world":         "",
+		"用 AI 工具 make videos": "zh",
+		"This video about 台北": "",
+	}
+	for text, want := range cases {
+		if got := Detect(text); got != want {
+			t.Errorf("Detect(%q) = %q，期望 %q", text, got, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		locale  string
+		text    string
+		maxCols int
+		want    []string
+	}{
+		// 不超過寬度時不換行
+		{"zh", "今天天氣真好", 16, []string{"今天天氣真好"}},
+		// 行數最少且長度平均，而不是把第一行塞滿
+		{"en", "The quick brown fox jumps over the lazy dog", 30, []string{"The quick brown fox", "jumps over the lazy dog"}},
+		// 數字與單位不斷開
+		{"zh", "外資預估2026年營收將年增35％", 16, []string{"外資預估2026", "年營收將年增35％"}},
+		{"zh", "我們可以在湖邊找個舒服的地方坐下來", 24, []string{"我們可以在湖邊找個", "舒服的地方坐下來"}},
+		// 日文送假名不與漢字分開
+		{"ja", "今日はとても良い天気ですね", 16, []string{"今日はとても", "良い天気ですね"}},
+		{"ko", "좋아하는 샌드위치를 꼭 가져오세요", 20, []string{"좋아하는 샌드위치를", "꼭 가져오세요"}},
+		// 單字過長時只能強制斷開
+		{"en", "Supercalifragilistic", 8, []string{"Superca", "lifragi", "listic"}},
+		// 換行字元一定換行
+		{"en", "line one\nline two", 40, []string{"line one", "line two"}},
+		{"en", "no limit at all", 0, []string{"no limit at all"}},
+	}
+	for _, c := range cases {
+		got := For(c.locale).Wrap(c.text, c.maxCols)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("[%s] Wrap(%q, %d) = %q，期望 %q", c.locale, c.text, c.maxCols, got, c.want)
+		}
+		if c.maxCols <= 0 {
+			continue
+		}
+		for _, l := range got {
+			if Width(l) > c.maxCols {
+				t.Errorf("[%s] %q 超過 %d 欄", c.locale, l, c.maxCols)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		locale  string
+		script  string
+		maxCols int
+		want    []string
+	}{
+		{"zh", "今天天氣真好，我們去公園野餐吧！記得帶上你最喜歡的三明治和水果。到時候我們可以在湖邊，找個舒服的地方坐下來。", 32,
+			[]string{"今天天氣真好我們去公園野餐吧", "記得帶上你最喜歡的三明治和水果", "到時候我們可以在湖邊", "找個舒服的地方坐下來"}},
+		{"en-US", "Welcome to the video. Today, we are going to talk about AI. Pi is 3.14, e.g. a constant...", 32,
+			[]string{"Welcome to the video", "Today", "we are going to talk about AI", "Pi is 3.14 e.g. a constant"}},
+		// 句尾的右引號留在同一句
+		{"zh", "他說：「我們走吧。」然後就離開了。", 32, []string{"他說「我們走吧」", "然後就離開了"}},
+		{"ja-JP", "今日はとても良い天気ですね。私たちは公園でピクニックをしましょう。", 24,
+			[]string{"今日はとても", "良い天気ですね", "私たちは公園で", "ピクニックをしましょう"}},
+		{"ko-KR", "오늘은 날씨가 정말 좋네요. 우리 공원에 소풍 가요!", 24,
+			[]string{"오늘은 날씨가", "정말 좋네요", "우리 공원에 소풍 가요"}},
+		// 泰文以空白分隔子句
+		{"th-TH", "วันนี้อากาศดีมาก เราไปปิกนิกกันเถอะ", 40, []string{"วันนี้อากาศดีมาก เราไปปิกนิกกันเถอะ"}},
+		{"th-TH", "วันนี้อากาศดีมาก เราไปปิกนิกกันเถอะ", 16, []string{"วันนี้อากาศดีมาก", "เราไปปิกนิกกันเถอะ"}},
+		// 千分位與時間的標點不切開，段落換行一定切開
+		{"en", "It costs 1,000 dollars at 10:30\nSee you", 40, []string{"It costs 1,000 dollars at 10:30", "See you"}},
+		{"", "", 16, nil},
+	}
+	for _, c := range cases {
+		got := For(c.locale).Split(c.script, c.maxCols)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("[%s] Split(%q, %d) = %q，期望 %q", c.locale, c.script, c.maxCols, got, c.want)
+		}
+	}
+}
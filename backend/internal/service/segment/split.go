@@ -0,0 +1,136 @@
+package segment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// piece 以標點切開的子句
+type piece struct {
+	text     string
+	sentence int  // 所屬句子的序號，只合併同一句的子句
+	spaced   bool // 原文子句之間有空白，合併時需補回
+}
+
+const (
+	sentenceMarks = "。！？!?…"
+	clauseMarks   = "，、；：,;:"
+	closingMarks  = "」』）】》〉”’\"')]"
+)
+
+// Split 將腳本切成字幕行：依句尾與子句標點 (及換行) 切分並移除這些標點，
+// 過寬的子句依斷行規則換行，同一句中相鄰的子句在寬度內合併。maxCols 為顯示欄數，<= 0 表示不限寬度也不合併
+func (s Segmenter) Split(script string, maxCols int) []string {
+	var lines []string
+	var cur string // 尚可合併的行
+	sentence := -1
+	flush := func() {
+		if cur != "" {
+			lines = append(lines, cur)
+		}
+		cur = ""
+	}
+	for _, p := range s.pieces(script) {
+		wrapped := s.Wrap(p.text, maxCols)
+		if len(wrapped) == 0 {
+			continue
+		}
+		if p.sentence != sentence || maxCols <= 0 {
+			flush()
+		}
+		sentence = p.sentence
+		if cur != "" {
+			// 與前一行合併後行數不變時採用合併結果
+			sep := ""
+			if s.spaced || p.spaced {
+				sep = " "
+			}
+			if joined := s.Wrap(cur+sep+p.text, maxCols); len(joined) <= len(wrapped) {
+				cur, wrapped = "", joined
+			} else {
+				flush()
+			}
+		}
+		lines = append(lines, wrapped[:len(wrapped)-1]...)
+		cur = wrapped[len(wrapped)-1]
+	}
+	flush()
+	return lines
+}
+
+// pieces 依句尾、子句標點與換行切開腳本；句尾標點後的右引號與括號留在前一個子句
+func (s Segmenter) pieces(script string) []piece {
+	runes := []rune(strings.TrimSpace(script))
+	var out []piece
+	var cur []rune
+	sentence := 0
+	spaced := false
+	flush := func(endSentence bool) {
+		text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(string(cur)), sentenceMarks+clauseMarks+"."))
+		if text != "" {
+			out = append(out, piece{text: text, sentence: sentence, spaced: spaced})
+			spaced = false
+		}
+		cur = cur[:0]
+		if endSentence {
+			sentence++
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			flush(true)
+		case strings.ContainsRune(sentenceMarks, r), r == '.' && s.sentenceDot(runes, i):
+			// 連續的句尾標點一起移除，之後的右引號與括號保留在此句
+			for i+1 < len(runes) && (strings.ContainsRune(sentenceMarks+".", runes[i+1])) {
+				i++
+			}
+			for i+1 < len(runes) && strings.ContainsRune(closingMarks, runes[i+1]) {
+				i++
+				cur = append(cur, runes[i])
+			}
+			flush(true)
+		case strings.ContainsRune(clauseMarks, r) && !betweenDigits(runes, i):
+			flush(false)
+		case r == ' ' && s.spaceCut && i > 0 && i+1 < len(runes) && isThai(runes[i-1]) && isThai(runes[i+1]):
+			flush(false)
+			spaced = true
+		default:
+			if unicode.IsSpace(r) && len(cur) == 0 && len(out) > 0 {
+				// 標點後接空白，合併時需要補回
+				spaced = true
+				continue
+			}
+			cur = append(cur, r)
+		}
+	}
+	flush(true)
+	return out
+}
+
+// sentenceDot 英文句點是否為句尾：小數點、縮寫後接小寫字母 (例如 e.g. this) 不算
+func (s Segmenter) sentenceDot(runes []rune, i int) bool {
+	if i+1 == len(runes) {
+		return true
+	}
+	next := runes[i+1]
+	if strings.ContainsRune(closingMarks, next) {
+		return true
+	}
+	if !unicode.IsSpace(next) {
+		return false
+	}
+	for _, r := range runes[i+1:] {
+		if !unicode.IsSpace(r) {
+			return !unicode.IsLower(r)
+		}
+	}
+	return true
+}
+
+// betweenDigits 千分位、時間等數字之間的逗號與冒號不切開
+func betweenDigits(runes []rune, i int) bool {
+	return i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) &&
+		strings.ContainsRune(",:", runes[i])
+}
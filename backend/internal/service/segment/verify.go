@@ -0,0 +1,198 @@
+package segment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinSimilarity 斷句結果與腳本的相似度下限，低於此值視為偏離原文過多，應重新產生
+const MinSimilarity = 0.85
+
+// Report 斷句結果與腳本比對的結果
+type Report struct {
+	Score      float64 `json:"score"`      // 綜合品質分數 (0~1)：相似度依超寬行數比例扣分
+	Similarity float64 `json:"similarity"` // 斷句內容與腳本的相似度 (0~1)，差異過大時為估計上限
+	Missing    int     `json:"missing"`    // 斷句遺漏、已補回的字數
+	Inserted   int     `json:"inserted"`   // 腳本沒有、已移除的字數
+	Overlong   int     `json:"overlong"`   // 超過寬度、已重新斷行的行數
+}
+
+// Drifted 是否偏離原文過多
+func (r Report) Drifted() bool {
+	return r.Similarity < MinSimilarity
+}
+
+// token 比對用的字元：只取文字、數字 (轉小寫) 與私用區字元 (行內標記的佔位字元)，忽略空白與標點
+type token struct {
+	r     rune
+	start int // 在原字串中的位元組位置
+	end   int
+}
+
+func tokenize(s string) []token {
+	var out []token
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Co, r) {
+			out = append(out, token{r: unicode.ToLower(r), start: i, end: i + len(string(r))})
+		}
+	}
+	return out
+}
+
+// Verify 將斷句結果 (例如 AI 的輸出) 與腳本逐字比對並修復：
+// 只採用斷句的切分位置，各行內容改取腳本原文 (移除腳本沒有的字、補回遺漏的片段，並依 Split 的規則移除句讀)，
+// 超過 maxCols 欄的行重新斷行。相似度低於 MinSimilarity 時回傳原本的 lines，由呼叫端決定是否重試
+func (s Segmenter) Verify(script string, lines []string, maxCols int) ([]string, Report) {
+	src := tokenize(script)
+	var out []token
+	owner := []int{} // out 中每個字元所屬的行
+	for i, line := range lines {
+		for _, t := range tokenize(line) {
+			out = append(out, t)
+			owner = append(owner, i)
+		}
+	}
+	total := len(src) + len(out)
+	if total == 0 {
+		return nil, Report{Score: 1, Similarity: 1}
+	}
+
+	// 編輯距離 D = 總字數 × (1 - 相似度)，超過下限對應的距離即不必再比下去
+	maxD := int(float64(total)*(1-MinSimilarity)) + 1
+	a := make([]rune, len(src))
+	for i, t := range src {
+		a[i] = t.r
+	}
+	b := make([]rune, len(out))
+	for i, t := range out {
+		b[i] = t.r
+	}
+	match, ok := matchRunes(a, b, maxD)
+	if !ok {
+		return lines, Report{Similarity: 1 - float64(maxD)/float64(total)}
+	}
+
+	matched := 0
+	first := make([]int, len(lines)) // 每行第一個與最後一個對應到腳本的字元
+	last := make([]int, len(lines))
+	for i := range lines {
+		first[i], last[i] = -1, -1
+	}
+	for j, i := range match {
+		if i < 0 {
+			continue
+		}
+		matched++
+		if first[owner[j]] < 0 {
+			first[owner[j]] = i
+		}
+		last[owner[j]] = i
+	}
+	report := Report{
+		Similarity: 2 * float64(matched) / float64(total),
+		Missing:    len(src) - matched,
+		Inserted:   len(out) - matched,
+	}
+	if report.Drifted() {
+		return lines, report
+	}
+
+	// 依每行最後一個對應字元切開腳本，切點延伸到其後的標點。
+	// 與下一行之間遺漏的片段依句讀分配：第一個句讀前接回上一行 (上一行未以句讀結尾時)，
+	// 句讀之間獨立成行，最後一段接到下一行開頭
+	var cuts []int
+	for i := 0; i < len(lines)-1; i++ {
+		if last[i] < 0 {
+			continue
+		}
+		next := len(script)
+		for k := i + 1; k < len(lines); k++ {
+			if first[k] >= 0 {
+				next = src[first[k]].start
+				break
+			}
+		}
+		end, ended := extendMarks(script, src[last[i]].end)
+		marks := s.markEnds(script, end, next)
+		if !ended && len(marks) > 0 {
+			end, marks = marks[0], marks[1:]
+		}
+		cuts = append(cuts, end)
+		cuts = append(cuts, marks...)
+	}
+	cuts = append(cuts, len(script))
+
+	var fixed []string
+	start := 0
+	for _, end := range cuts {
+		if end <= start {
+			continue
+		}
+		if text := s.stripMarks(script[start:end]); text != "" {
+			fixed = append(fixed, text)
+		}
+		start = end
+	}
+
+	result := make([]string, 0, len(fixed))
+	for _, line := range fixed {
+		if maxCols > 0 && Width(line) > maxCols {
+			report.Overlong++
+			result = append(result, s.Wrap(line, maxCols)...)
+			continue
+		}
+		result = append(result, line)
+	}
+	report.Score = report.Similarity
+	if len(fixed) > 0 {
+		report.Score *= 1 - 0.5*float64(report.Overlong)/float64(len(fixed))
+	}
+	return result, report
+}
+
+// stripMarks 依 Split 的規則移除句讀與換行，子句之間依語系補上空白
+func (s Segmenter) stripMarks(text string) string {
+	var b strings.Builder
+	for i, p := range s.pieces(text) {
+		if i > 0 && (s.spaced || p.spaced) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
+
+// extendMarks 由 pos 往後略過標點，遇到空白、文字或佔位字元為止；回傳新位置與是否經過句讀
+func extendMarks(script string, pos int) (int, bool) {
+	ended := false
+	for _, r := range script[pos:] {
+		if unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Co, r) {
+			break
+		}
+		if strings.ContainsRune(sentenceMarks+clauseMarks+".", r) {
+			ended = true
+		}
+		pos += len(string(r))
+	}
+	return pos, ended
+}
+
+// markEnds script[from:to] 中各句讀 (含其後的標點) 結束的位置，判斷方式與 Split 相同
+func (s Segmenter) markEnds(script string, from, to int) []int {
+	runes, offsets := decode(script)
+	idx := runeIndex(offsets)
+	var out []int
+	for i := idx[from]; i < idx[to]; i++ {
+		r := runes[i]
+		if r == '\n' || strings.ContainsRune(sentenceMarks, r) || (r == '.' && s.sentenceDot(runes, i)) ||
+			(strings.ContainsRune(clauseMarks, r) && !betweenDigits(runes, i)) {
+			end, _ := extendMarks(script, offsets[i+1])
+			if end > to {
+				end = to
+			}
+			out = append(out, end)
+			i = idx[end] - 1
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package segment
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchRunes(t *testing.T) {
+	a, b := []rune("abcabba"), []rune("cbabac")
+	match, ok := matchRunes(a, b, 20)
+	if !ok {
+		t.Fatal("應完成比對")
+	}
+	// 最長共同子序列長度為 4，且對應需遞增
+	matched, prev := 0, -1
+	for j, i := range match {
+		if i < 0 {
+			continue
+		}
+		if a[i] != b[j] || i <= prev {
+			t.Fatalf("對應錯誤 %v", match)
+		}
+		matched, prev = matched+1, i
+	}
+	if matched != 4 {
+		t.Errorf("對應 %d 字，期望 4：%v", matched, match)
+	}
+	if _, ok := matchRunes([]rune("abcdef"), []rune("uvwxyz"), 5); ok {
+		t.Error("編輯距離超過上限應放棄比對")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		name     string
+		locale   string
+		script   string
+		lines    []string
+		maxCols  int
+		want     []string
+		missing  int
+		inserted int
+		overlong int
+	}{
+		{
+			name:    "完全一致",
+			locale:  "zh",
+			script:  "今天天氣真好，我們去公園野餐吧！",
+			lines:   []string{"今天天氣真好", "我們去公園野餐吧"},
+			maxCols: 32,
+			want:    []string{"今天天氣真好", "我們去公園野餐吧"},
+		},
+		{
+			name:     "改寫與遺漏以原文為準",
+			locale:   "zh",
+			script:   "今天天氣真好，我們去公園野餐吧！記得帶上你最喜歡的三明治和水果。",
+			lines:    []string{"今天天氣很好我們去公園野餐吧", "記得帶上你最喜歡的水果"},
+			maxCols:  32,
+			want:     []string{"今天天氣真好我們去公園野餐吧", "記得帶上你最喜歡的三明治和水果"},
+			missing:  5,
+			inserted: 1,
+		},
+		{
+			name:    "英文句讀與空白",
+			locale:  "en",
+			script:  "Welcome to the video. Today we talk about AI. Stay tuned.",
+			lines:   []string{"Welcome to the video", "Today we talk about AI", "Stay tuned"},
+			maxCols: 40,
+			want:    []string{"Welcome to the video", "Today we talk about AI", "Stay tuned"},
+		},
+		{
+			name:    "遺漏的整句獨立成行",
+			locale:  "en",
+			script:  "Welcome to the video. Hi all! Stay tuned.",
+			lines:   []string{"Welcome to the video", "Stay tuned"},
+			maxCols: 40,
+			want:    []string{"Welcome to the video", "Hi all", "Stay tuned"},
+			missing: 5,
+		},
+		{
+			name:     "遺漏片段接回上一行後過寬",
+			locale:   "en",
+			script:   "Welcome to the video everyone. Stay tuned.",
+			lines:    []string{"Welcome to the video", "Stay tuned"},
+			maxCols:  20,
+			want:     []string{"Welcome to the", "video everyone", "Stay tuned"},
+			missing:  8,
+			overlong: 1,
+		},
+		{
+			name:    "遺漏的佔位字元補回",
+			locale:  "zh",
+			script:  "第一句話\uE000，第二句話。",
+			lines:   []string{"第一句話", "第二句話"},
+			maxCols: 32,
+			want:    []string{"第一句話\uE000", "第二句話"},
+			missing: 1,
+		},
+	}
+	for _, c := range cases {
+		got, report := For(c.locale).Verify(c.script, c.lines, c.maxCols)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s：Verify = %q，期望 %q", c.name, got, c.want)
+		}
+		if report.Missing != c.missing || report.Inserted != c.inserted || report.Overlong != c.overlong {
+			t.Errorf("%s：report = %+v，期望遺漏 %d、多出 %d、過寬 %d", c.name, report, c.missing, c.inserted, c.overlong)
+		}
+		if report.Drifted() || report.Score <= 0 || report.Score > 1 {
+			t.Errorf("%s：分數不正確 %+v", c.name, report)
+		}
+	}
+}
+
+func TestVerifyDrift(t *testing.T) {
+	lines := []string{"完全不同的內容", "這是模型自己編的"}
+	got, report := For("zh").Verify("今天天氣真好，我們去公園野餐吧！", lines, 32)
+	if !report.Drifted() {
+		t.Errorf("差異過大應視為偏離：%+v", report)
+	}
+	if !reflect.DeepEqual(got, lines) {
+		t.Errorf("偏離時應回傳原本的斷句，得到 %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package segment
+
+import "unicode"
+
+// wideRanges 東亞寬字元 (East Asian Width 為 W 或 F) 的主要區段，顯示寬度為 2 欄
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // 諺文字母 (初聲)
+	{0x231A, 0x231B},   // 手錶、沙漏
+	{0x2329, 0x232A},   // 角括號
+	{0x23E9, 0x23EC},   // 表情符號
+	{0x23F0, 0x23F3},   // 表情符號
+	{0x25FD, 0x25FE},   // 表情符號
+	{0x2614, 0x2615},   // 表情符號
+	{0x2648, 0x2653},   // 星座
+	{0x26AA, 0x26AB},   // 表情符號
+	{0x26BD, 0x26BE},   // 表情符號
+	{0x26C4, 0x26C5},   // 表情符號
+	{0x26F2, 0x26F5},   // 表情符號
+	{0x2705, 0x2705},   // 表情符號
+	{0x270A, 0x270B},   // 表情符號
+	{0x2728, 0x2728},   // 表情符號
+	{0x274C, 0x274C},   // 表情符號
+	{0x2753, 0x2757},   // 表情符號
+	{0x2795, 0x2797},   // 表情符號
+	{0x2B1B, 0x2B1C},   // 表情符號
+	{0x2B50, 0x2B55},   // 表情符號
+	{0x2E80, 0x303E},   // 部首、康熙部首、中日韓符號與標點
+	{0x3041, 0x33FF},   // 平假名、片假名、注音、中日韓相容字
+	{0x3400, 0x4DBF},   // 擴充 A
+	{0x4E00, 0x9FFF},   // 中日韓統一表意文字
+	{0xA000, 0xA4CF},   // 彝文
+	{0xA960, 0xA97F},   // 諺文字母擴充 A
+	{0xAC00, 0xD7A3},   // 諺文音節
+	{0xF900, 0xFAFF},   // 相容表意文字
+	{0xFE10, 0xFE19},   // 直排標點
+	{0xFE30, 0xFE6F},   // 相容形式、小寫變體
+	{0xFF00, 0xFF60},   // 全形 ASCII
+	{0xFFE0, 0xFFE6},   // 全形符號
+	{0x1F004, 0x1F004}, // 麻將牌
+	{0x1F0CF, 0x1F0CF}, // 撲克牌
+	{0x1F18E, 0x1F18E}, // 表情符號
+	{0x1F191, 0x1F19A}, // 表情符號
+	{0x1F200, 0x1F251}, // 圈字
+	{0x1F300, 0x1F64F}, // 表情符號
+	{0x1F680, 0x1F6FF}, // 交通與地圖符號
+	{0x1F7E0, 0x1F7EB}, // 幾何圖形
+	{0x1F90C, 0x1F9FF}, // 補充表情符號
+	{0x1FA70, 0x1FAFF}, // 表情符號擴充 A
+	{0x20000, 0x2FFFD}, // 擴充 B 之後
+	{0x30000, 0x3FFFD}, // 擴充 G 之後
+}
+
+// RuneWidth 字元的顯示寬度 (欄數)：全形與中日韓文字為 2，組合符號與控制字元為 0，其餘為 1
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0:
+		return 0
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x1100:
+		// 拉丁、希臘、西里爾等字母區不含寬字元，只需排除組合符號
+		if unicode.In(r, unicode.Mn, unicode.Me) {
+			return 0
+		}
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1160 && r <= 0x11FF:
+		// 諺文中聲與終聲與初聲組成同一個字
+		return 0
+	}
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch {
+		case r < wideRanges[m][0]:
+			hi = m
+		case r > wideRanges[m][1]:
+			lo = m + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
+
+// Width 字串的顯示寬度 (欄數)
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// Columns 將每行字數上限 (例如 subtitle_style.max_line_width) 換算為 text 的顯示欄數：
+// 以中日韓文字為主時一個字為 2 欄，其他語言沿用字元數 (一個字元 1 欄)
+func Columns(chars int, text string) int {
+	if IsCJK(text) {
+		return chars * 2
+	}
+	return chars
+}
+
+// IsCJK 文字是否以中日韓文字為主 (拉丁字母以四個字母粗估一個字)
+func IsCJK(text string) bool {
+	var cjk, other int
+	for _, r := range text {
+		switch {
+		case isIdeographic(r):
+			cjk++
+		case unicode.IsLetter(r):
+			other++
+		}
+	}
+	return cjk > 0 && cjk >= other/4
+}
@@ -0,0 +1,169 @@
+package segment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wordClass UAX #29 詞邊界類別 (子集)
+type wordClass uint8
+
+const (
+	wbOther        wordClass = iota
+	wbLetter                 // 以空白分詞的字母 (含諺文)
+	wbNumeric                // 數字
+	wbKatakana               // 片假名，連續時為一詞
+	wbIdeo                   // 漢字與平假名，每字為一詞
+	wbSA                     // 泰文等，以音節規則切分
+	wbExtend                 // 組合符號，附著在前一字元
+	wbMidLetter              // 字母之間不斷開，例如 can’t、l·l
+	wbMidNum                 // 數字之間不斷開，例如 1,000
+	wbMidNumLet              // 字母或數字之間皆不斷開，例如 e.g、3.14、don't
+	wbExtendNumLet           // 底線
+)
+
+func wordClassOf(r rune) wordClass {
+	switch r {
+	case '\'', '.', 0x2018, 0x2019, 0x2024, 0xFE52, 0xFF07, 0xFF0E:
+		return wbMidNumLet
+	case 0x00B7, 0x0387, 0x2027, 0xFE55:
+		return wbMidLetter
+	case ',', ';', 0x037E, 0x066C, 0xFE50, 0xFE54, 0xFF0C, 0xFF1B:
+		return wbMidNum
+	case '_', 0x203F, 0x2040, 0xFF3F:
+		return wbExtendNumLet
+	case 0x200D:
+		return wbExtend
+	case 0x30FC:
+		return wbKatakana
+	}
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me):
+		return wbExtend
+	case unicode.IsDigit(r):
+		return wbNumeric
+	case isSARune(r) && unicode.IsLetter(r):
+		return wbSA
+	case unicode.Is(unicode.Katakana, r):
+		return wbKatakana
+	case unicode.In(r, unicode.Han, unicode.Hiragana):
+		return wbIdeo
+	case unicode.IsLetter(r):
+		return wbLetter
+	}
+	return wbOther
+}
+
+// Words 依 UAX #29 詞邊界 (子集) 切出文字中的詞，略過空白與標點；
+// 漢字與平假名每字為一詞，片假名與其他文字的連續字母為一詞，泰文等以音節規則近似
+func Words(text string) []string {
+	runes := []rune(text)
+	cls := make([]wordClass, len(runes))
+	for i, r := range runes {
+		cls[i] = wordClassOf(r)
+	}
+	sa := syllableBreaks(runes)
+
+	var out []string
+	start := -1
+	base := wbOther // 目前詞最後一個非組合符號字元的類別
+	flush := func(end int) {
+		if start >= 0 {
+			out = append(out, string(runes[start:end]))
+		}
+		start, base = -1, wbOther
+	}
+	for i, c := range cls {
+		if c == wbExtend {
+			// WB4：組合符號附著在前一字元
+			continue
+		}
+		if start >= 0 && !sa[i] && joinWord(base, c, cls, i) {
+			// 中間符號兩側為同類字元 (joinWord 已確認)，保留前一字的類別
+			if c != wbMidLetter && c != wbMidNum && c != wbMidNumLet {
+				base = c
+			}
+			continue
+		}
+		flush(i)
+		if isWordClass(c) {
+			start, base = i, c
+		}
+	}
+	flush(len(runes))
+	return out
+}
+
+func isWordClass(c wordClass) bool {
+	switch c {
+	case wbLetter, wbNumeric, wbKatakana, wbIdeo, wbSA, wbExtendNumLet:
+		return true
+	}
+	return false
+}
+
+// joinWord 判斷 c (位於 i) 是否與前面的詞相連 (WB5 ~ WB13b)
+func joinWord(prev, c wordClass, cls []wordClass, i int) bool {
+	alnum := func(w wordClass) bool { return w == wbLetter || w == wbNumeric || w == wbSA }
+	switch {
+	case prev == wbIdeo || c == wbIdeo:
+		return false
+	case alnum(prev) && alnum(c): // WB5、WB8 ~ WB10
+		return true
+	case prev == wbKatakana && c == wbKatakana: // WB13
+		return true
+	case c == wbExtendNumLet && (alnum(prev) || prev == wbKatakana || prev == wbExtendNumLet): // WB13a
+		return true
+	case prev == wbExtendNumLet && (alnum(c) || c == wbKatakana): // WB13b
+		return true
+	}
+	// WB6、WB7、WB11、WB12：中間符號兩側皆為同類字元時不斷開
+	next := wbOther
+	for k := i + 1; k < len(cls); k++ {
+		if cls[k] != wbExtend {
+			next = cls[k]
+			break
+		}
+	}
+	switch c {
+	case wbMidLetter:
+		return isLetterish(prev) && isLetterish(next)
+	case wbMidNum:
+		return prev == wbNumeric && next == wbNumeric
+	case wbMidNumLet:
+		return (isLetterish(prev) && isLetterish(next)) || (prev == wbNumeric && next == wbNumeric)
+	}
+	return false
+}
+
+func isLetterish(c wordClass) bool {
+	return c == wbLetter || c == wbSA
+}
+
+// syllableBreaks 泰文沒有分詞空白，以音節規則近似詞邊界 (不含字典)：
+// 前置母音 (เ แ โ ใ ไ) 之前、以及 ะ ำ ๆ ฯ 與不發音符號 ์ 之後接子音處可斷開；
+// 回傳各字元之前是否為邊界
+func syllableBreaks(runes []rune) []bool {
+	out := make([]bool, len(runes)+1)
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		if !isThai(prev) || !isThai(cur) {
+			continue
+		}
+		switch {
+		case cur >= 0x0E40 && cur <= 0x0E44:
+			out[i] = true
+		case isThaiConsonant(cur) && strings.ContainsRune("ะำๆฯ์", prev):
+			out[i] = true
+		}
+	}
+	return out
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+func isThaiConsonant(r rune) bool {
+	return r >= 0x0E01 && r <= 0x0E2E
+}
@@ -4,14 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
-	"time"
-
-	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
 )
 
 // AzureProvider 實作 Azure 語音 REST API（v1/v2 通用）
@@ -29,43 +22,20 @@ func (a *AzureProvider) Synthesize(text, voice, locale string, speed, pitch floa
 	}
 	rate := fmt.Sprintf("%+.0f%%", (speed-1.0)*100)
 	pitchStr := fmt.Sprintf("%+.0f%%", pitch*100)
+	// 文字需轉義，否則腳本中的 & 或 < 會讓 SSML 無法解析
 	ssml := fmt.Sprintf(`<speak version='1.0' xml:lang='%s'><voice name='%s'><prosody rate='%s' pitch='%s'>%s</prosody></voice></speak>`,
-		locale, voice, rate, pitchStr, text)
+		xmlEscape(locale), xmlEscape(voice), rate, pitchStr, renderSSML(ParseMarkup(text), azureCaps))
 
 	url := fmt.Sprintf("https://%s.tts.speech.microsoft.com/cognitiveservices/v1", a.Region)
 	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(ssml))
 	req.Header.Set("Content-Type", "application/ssml+xml")
 	req.Header.Set("Ocp-Apim-Subscription-Key", a.Key)
 	req.Header.Set("X-Microsoft-OutputFormat", "riff-24khz-16bit-mono-pcm")
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	audio, err := doRequest("azure", req)
 	if err != nil {
 		return "", 0, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("azure tts 錯誤: %s", string(body))
-	}
-	tmp := filepath.Join(os.TempDir(), "azure_tts_"+strconv.FormatInt(time.Now().UnixNano(), 10)+".wav")
-	f, err := os.Create(tmp)
-	if err != nil {
-		return "", 0, err
-	}
-	defer f.Close()
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		return "", 0, err
-	}
-
-	info, _ := f.Stat()
-	if info.Size() < 100 {
-		// 檔案太小，可能是空檔或只有檔頭
-		content, _ := os.ReadFile(tmp)
-		return "", 0, fmt.Errorf("Azure TTS 回傳檔案太小 (%d bytes), 內容: %s", info.Size(), string(content))
-	}
-
-	dur, _ := utils.AudioDurationSeconds(tmp)
-	return tmp, dur, nil
+	return saveAudio("azure", ".wav", audio)
 }
 
 func (a *AzureProvider) ListVoices() ([]Voice, error) {
@@ -76,15 +46,9 @@ func (a *AzureProvider) ListVoices() ([]Voice, error) {
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("Ocp-Apim-Subscription-Key", a.Key)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	body, err := doRequest("azure", req)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to list voices: %s", resp.Status)
+		return nil, fmt.Errorf("failed to list voices: %w", err)
 	}
 
 	// Azure 返回 JSON 結構
@@ -96,7 +60,7 @@ func (a *AzureProvider) ListVoices() ([]Voice, error) {
 		Gender      string `json:"Gender"`
 	}
 	var raw []azureVoice
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+	if err := json.Unmarshal(body, &raw); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,190 @@
+package tts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// CachedProvider 以文字、語音與參數的雜湊快取合成結果，
+// 草稿預覽、重新算圖與正式輸出之間重複使用，不必重新呼叫 TTS
+type CachedProvider struct {
+	Provider
+	Name string // provider 名稱，納入快取鍵
+	Dir  string
+
+	MaxBytes int64         // 快取總大小上限，0 表示不限
+	MaxAge   time.Duration // 超過此時間未使用的結果會被清除，0 表示不限
+}
+
+// NewCachedProvider 包裝 provider 加上檔案快取
+func NewCachedProvider(p Provider, name, dir string) *CachedProvider {
+	return &CachedProvider{Provider: p, Name: name, Dir: dir}
+}
+
+// CacheDir TTS 快取目錄
+func CacheDir(cfg *config.Config) string {
+	return filepath.Join(cfg.StoragePath, "cache", "tts")
+}
+
+// IsCached path 是否為快取中的檔案 (由快取管理，使用後不可刪除)
+func IsCached(cfg *config.Config, path string) bool {
+	return cfg.TTSCache && filepath.Dir(path) == CacheDir(cfg)
+}
+
+func (c *CachedProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	key := c.key(text, voice, locale, speed, pitch)
+	if matches, _ := filepath.Glob(filepath.Join(c.Dir, key+".*")); len(matches) > 0 {
+		if dur, err := utils.AudioDurationSeconds(matches[0]); err == nil && dur > 0 {
+			// 更新修改時間作為最近使用時間，清理時較晚移除
+			now := time.Now()
+			_ = os.Chtimes(matches[0], now, now)
+			return matches[0], dur, nil
+		}
+	}
+
+	path, dur, err := c.Provider.Synthesize(text, voice, locale, speed, pitch)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return path, dur, nil
+	}
+	target := filepath.Join(c.Dir, key+filepath.Ext(path))
+	if err := c.store(path, target); err != nil {
+		log.Warn().Err(err).Str("file", path).Msg("寫入 TTS 快取失敗")
+		return path, dur, nil
+	}
+	c.maybePrune()
+	return target, dur, nil
+}
+
+// store 將 provider 產生的暫存檔移入快取：同一檔案系統直接改名；
+// 否則先複製到快取目錄的暫存檔再改名 (避免並行的工作讀到寫一半的檔案)，最後移除原檔
+func (c *CachedProvider) store(path, target string) error {
+	if err := os.Rename(path, target); err == nil {
+		return nil
+	}
+	f, err := os.CreateTemp(c.Dir, "tmp_*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := utils.CopyFile(path, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	_ = os.Remove(path)
+	return nil
+}
+
+// key 快取鍵：任何影響合成結果的參數都要納入
+func (c *CachedProvider) key(text, voice, locale string, speed, pitch float64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%.3f\x00%.3f", c.Name, voice, locale, text, speed, pitch)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// pruneInterval 同一個快取目錄兩次清理之間的最短間隔
+const pruneInterval = 10 * time.Minute
+
+var (
+	pruneMu   sync.Mutex
+	lastPrune = map[string]time.Time{}
+)
+
+// maybePrune 寫入快取後在背景清理，同一個目錄每 pruneInterval 最多一次
+func (c *CachedProvider) maybePrune() {
+	if c.MaxBytes <= 0 && c.MaxAge <= 0 {
+		return
+	}
+	pruneMu.Lock()
+	if time.Since(lastPrune[c.Dir]) < pruneInterval {
+		pruneMu.Unlock()
+		return
+	}
+	lastPrune[c.Dir] = time.Now()
+	pruneMu.Unlock()
+
+	go func() {
+		removed, err := PruneCache(c.Dir, c.MaxBytes, c.MaxAge)
+		if err != nil {
+			log.Warn().Err(err).Str("dir", c.Dir).Msg("清理 TTS 快取失敗")
+			return
+		}
+		if removed > 0 {
+			log.Info().Int("removed", removed).Str("dir", c.Dir).Msg("已清理 TTS 快取")
+		}
+	}()
+}
+
+// PruneCache 清理快取目錄，回傳移除的檔案數：先移除超過 maxAge 未使用的結果與中斷留下的暫存檔，
+// 總大小仍超過 maxBytes 時由最久未使用的開始移除。命中快取時會更新修改時間，因此以修改時間代表最近使用
+func PruneCache(dir string, maxBytes int64, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	type cached struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var files []cached
+	var total int64
+	removed := 0
+	now := time.Now()
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		age := now.Sub(info.ModTime())
+		// 暫存檔寫入只需數秒，留下超過一小時的是中斷的寫入
+		stale := strings.HasPrefix(e.Name(), "tmp_") && age > time.Hour
+		if stale || (maxAge > 0 && age > maxAge) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+			continue
+		}
+		files = append(files, cached{path: path, size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+	}
+	if maxBytes <= 0 || total <= maxBytes {
+		return removed, nil
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	for _, f := range files {
+		if total <= maxBytes {
+			break
+		}
+		if strings.HasPrefix(filepath.Base(f.path), "tmp_") {
+			continue
+		}
+		if os.Remove(f.path) == nil {
+			removed++
+			total -= f.size
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,79 @@
+package tts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fileProvider 每次合成都在 dir 寫出一個新檔案，模擬 provider 的暫存檔
+type fileProvider struct{ dir string }
+
+func (f fileProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	file, err := os.CreateTemp(f.dir, "tts_*.wav")
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	_, err = file.WriteString(text)
+	return file.Name(), 1, err
+}
+
+func (f fileProvider) ListVoices() ([]Voice, error) { return nil, nil }
+
+func TestCachedProviderMovesResult(t *testing.T) {
+	tmp, cache := t.TempDir(), t.TempDir()
+	c := NewCachedProvider(fileProvider{dir: tmp}, "fake", cache)
+	path, _, err := c.Synthesize("你好", "v", "zh-TW", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != cache {
+		t.Errorf("結果 %s 不在快取目錄", path)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "你好" {
+		t.Errorf("快取內容 = %q, %v", b, err)
+	}
+	// provider 的暫存檔已移入快取，不應留下
+	if left, _ := os.ReadDir(tmp); len(left) != 0 {
+		t.Errorf("暫存目錄留下 %d 個檔案", len(left))
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, size int, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("expired.wav", 10, 48*time.Hour)
+	write("oldest.wav", 100, 3*time.Hour)
+	write("older.wav", 100, 2*time.Hour)
+	write("recent.wav", 100, time.Minute)
+	write("tmp_stale", 10, 2*time.Hour)
+	write("tmp_writing", 10, time.Second)
+
+	removed, err := PruneCache(dir, 220, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("移除 %d 個檔案，期望 3", removed)
+	}
+	for name, want := range map[string]bool{
+		"expired.wav": false, "oldest.wav": false, "tmp_stale": false,
+		"older.wav": true, "recent.wav": true, "tmp_writing": true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s 存在 = %v，期望 %v", name, exists, want)
+		}
+	}
+}
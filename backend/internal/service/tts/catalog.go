@@ -0,0 +1,227 @@
+package tts
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+)
+
+// ProviderNames 支援的 provider，依自動挑選預設 provider 的優先順序排列
+var ProviderNames = []string{"azure_v1", "azure_v2", "google", "polly", "elevenlabs", "openai", "edge_tts", "local"}
+
+// ProviderStatus provider 是否已設定可用
+type ProviderStatus struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // 不可用的原因
+}
+
+// Providers 檢查各 provider 的設定：雲端服務需金鑰，local 需安裝 espeak-ng，Edge TTS 只需網路
+func Providers(cfg *config.Config) []ProviderStatus {
+	list := make([]ProviderStatus, 0, len(ProviderNames))
+	for _, name := range ProviderNames {
+		st := ProviderStatus{Name: name, Available: true}
+		switch name {
+		case "azure_v1", "azure_v2":
+			if cfg.AzureKey == "" || cfg.AzureRegion == "" {
+				st.Available, st.Reason = false, "未設定 AZURE_TTS_KEY 或 AZURE_TTS_REGION"
+			}
+		case "google":
+			if cfg.GoogleTTSKey == "" {
+				st.Available, st.Reason = false, "未設定 GOOGLE_TTS_KEY"
+			}
+		case "polly":
+			if cfg.PollyAccessKey == "" || cfg.PollySecretKey == "" || cfg.PollyRegion == "" {
+				st.Available, st.Reason = false, "未設定 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY 或 POLLY_REGION"
+			}
+		case "elevenlabs":
+			if cfg.ElevenLabsKey == "" {
+				st.Available, st.Reason = false, "未設定 ELEVENLABS_API_KEY"
+			}
+		case "openai":
+			// 自架的相容服務可不需金鑰
+			base := strings.TrimRight(cfg.OpenAITTSBaseURL, "/")
+			if cfg.OpenAITTSKey == "" && (base == "" || base == openAIBaseURL) {
+				st.Available, st.Reason = false, "未設定 OPENAI_TTS_KEY"
+			}
+		case "local":
+			if _, err := exec.LookPath("espeak-ng"); err != nil {
+				st.Available, st.Reason = false, "找不到 espeak-ng"
+			}
+		}
+		list = append(list, st)
+	}
+	return list
+}
+
+// ProviderAvailable 回傳 provider 是否可用，不可用時附上原因
+func ProviderAvailable(cfg *config.Config, name string) (bool, string) {
+	for _, st := range Providers(cfg) {
+		if st.Name == name {
+			return st.Available, st.Reason
+		}
+	}
+	return false, fmt.Sprintf("未知的 TTS provider: %s", name)
+}
+
+// DefaultProvider 未指定 provider 時使用：TTS_DEFAULT_PROVIDER 可用就用它，否則挑第一個可用的
+func DefaultProvider(cfg *config.Config) string {
+	if ok, _ := ProviderAvailable(cfg, cfg.TTSDefaultProvider); ok {
+		return cfg.TTSDefaultProvider
+	}
+	for _, st := range Providers(cfg) {
+		if st.Available {
+			return st.Name
+		}
+	}
+	return "edge_tts"
+}
+
+// CatalogVoice 語音目錄項目
+type CatalogVoice struct {
+	Voice
+	Provider string `json:"provider"`
+}
+
+// VoiceFilter 語音目錄篩選條件，空白表示不限
+type VoiceFilter struct {
+	Provider string
+	Locale   string // zh 可比對 zh-TW、zh-CN 等
+	Gender   string
+}
+
+type catalogEntry struct {
+	mu      sync.Mutex
+	voices  []Voice
+	fetched time.Time
+}
+
+// Catalog 各 provider 語音清單的快取，逾時後重新查詢；查詢失敗時沿用舊資料
+type Catalog struct {
+	cfg *config.Config
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*catalogEntry
+}
+
+// NewCatalog 建立語音目錄，ttl 為 0 時每次都重新查詢
+func NewCatalog(cfg *config.Config) *Catalog {
+	return &Catalog{cfg: cfg, ttl: cfg.TTSVoiceCacheTTL, entries: map[string]*catalogEntry{}}
+}
+
+// Voices 取得單一 provider 的語音清單，refresh 為 true 時略過快取
+func (c *Catalog) Voices(provider string, refresh bool) ([]Voice, error) {
+	c.mu.Lock()
+	e, ok := c.entries[provider]
+	if !ok {
+		e = &catalogEntry{}
+		c.entries[provider] = e
+	}
+	c.mu.Unlock()
+
+	// 同一 provider 同時只查詢一次，其他請求等待結果
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !refresh && e.voices != nil && time.Since(e.fetched) < c.ttl {
+		return e.voices, nil
+	}
+	p, err := GetProvider(provider, c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	voices, err := p.ListVoices()
+	if err != nil {
+		if e.voices != nil {
+			log.Warn().Err(err).Str("provider", provider).Msg("查詢語音清單失敗，沿用快取")
+			return e.voices, nil
+		}
+		return nil, err
+	}
+	e.voices, e.fetched = voices, time.Now()
+	return voices, nil
+}
+
+// Search 依條件篩選語音；未指定 provider 時合併所有可用 provider，
+// 個別 provider 查詢失敗不影響其他 provider，錯誤以 map 回傳
+func (c *Catalog) Search(f VoiceFilter, refresh bool) ([]CatalogVoice, map[string]string) {
+	var names []string
+	if f.Provider != "" {
+		names = []string{f.Provider}
+	} else {
+		for _, st := range Providers(c.cfg) {
+			if st.Available {
+				names = append(names, st.Name)
+			}
+		}
+	}
+
+	list := []CatalogVoice{}
+	errs := map[string]string{}
+	for _, name := range names {
+		voices, err := c.Voices(name, refresh)
+		if err != nil {
+			errs[name] = err.Error()
+			continue
+		}
+		for _, v := range voices {
+			if f.matches(v) {
+				list = append(list, CatalogVoice{Voice: v, Provider: name})
+			}
+		}
+	}
+	return list, errs
+}
+
+func (f VoiceFilter) matches(v Voice) bool {
+	if f.Gender != "" && !strings.EqualFold(v.Gender, f.Gender) {
+		return false
+	}
+	if f.Locale != "" {
+		loc := strings.ToLower(v.Locale)
+		want := strings.ToLower(f.Locale)
+		if loc != want && !strings.HasPrefix(loc, want+"-") {
+			return false
+		}
+	}
+	return true
+}
+
+// PickVoice 依語系挑選語音：優先使用指定的 provider，語系完全相符優先於只有語言相符，
+// 再依 gender 挑選；指定的 provider 沒有該語言的語音時改從其他可用 provider 挑選
+func (c *Catalog) PickVoice(provider, locale, gender string) (CatalogVoice, error) {
+	lang, _, _ := strings.Cut(locale, "-")
+	var candidates []CatalogVoice
+	if provider != "" {
+		candidates, _ = c.Search(VoiceFilter{Provider: provider, Locale: lang}, false)
+	}
+	if len(candidates) == 0 {
+		candidates, _ = c.Search(VoiceFilter{Locale: lang}, false)
+	}
+	best, bestScore := -1, -1
+	for i, v := range candidates {
+		score := 0
+		if v.Provider == provider {
+			score += 4
+		}
+		if strings.EqualFold(v.Locale, locale) {
+			score += 2
+		}
+		if gender != "" && strings.EqualFold(v.Gender, gender) {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return CatalogVoice{}, fmt.Errorf("找不到 %s 的語音", locale)
+	}
+	return candidates[best], nil
+}
@@ -0,0 +1,75 @@
+package tts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+)
+
+func TestVoiceFilter(t *testing.T) {
+	v := Voice{Name: "zh-TW-YunJheNeural", Locale: "zh-TW", Gender: "Male"}
+	cases := []struct {
+		f    VoiceFilter
+		want bool
+	}{
+		{VoiceFilter{}, true},
+		{VoiceFilter{Locale: "zh"}, true},
+		{VoiceFilter{Locale: "ZH-tw", Gender: "male"}, true},
+		{VoiceFilter{Locale: "zh-CN"}, false},
+		{VoiceFilter{Locale: "z"}, false},
+		{VoiceFilter{Gender: "Female"}, false},
+	}
+	for _, c := range cases {
+		if got := c.f.matches(v); got != c.want {
+			t.Errorf("%+v: got %v, want %v", c.f, got, c.want)
+		}
+	}
+}
+
+func TestDefaultProvider(t *testing.T) {
+	cfg := &config.Config{TTSDefaultProvider: "azure_v1"}
+	// 未設定 Azure 金鑰時不可選為預設
+	if ok, reason := ProviderAvailable(cfg, "azure_v1"); ok || reason == "" {
+		t.Errorf("azure_v1 應不可用，得到 %v %q", ok, reason)
+	}
+	if got := DefaultProvider(cfg); got != "edge_tts" {
+		t.Errorf("DefaultProvider = %s, want edge_tts", got)
+	}
+	cfg.AzureKey, cfg.AzureRegion = "key", "eastasia"
+	if got := DefaultProvider(cfg); got != "azure_v1" {
+		t.Errorf("DefaultProvider = %s, want azure_v1", got)
+	}
+}
+
+func TestPickVoice(t *testing.T) {
+	cached := func(voices ...Voice) *catalogEntry {
+		return &catalogEntry{voices: append([]Voice{}, voices...), fetched: time.Now()}
+	}
+	c := &Catalog{cfg: &config.Config{}, ttl: time.Hour, entries: map[string]*catalogEntry{
+		"edge_tts": cached(
+			Voice{Name: "en-GB-SoniaNeural", Locale: "en-GB", Gender: "Female"},
+			Voice{Name: "en-US-GuyNeural", Locale: "en-US", Gender: "Male"},
+			Voice{Name: "en-US-JennyNeural", Locale: "en-US", Gender: "Female"},
+			Voice{Name: "ja-JP-NanamiNeural", Locale: "ja-JP", Gender: "Female"},
+		),
+		// 安裝 espeak-ng 時 local 也可用，避免測試查詢實際語音
+		"local": cached(),
+	}}
+	cases := []struct {
+		provider, locale, gender, want string
+	}{
+		{"edge_tts", "en-US", "Female", "en-US-JennyNeural"},
+		{"edge_tts", "en-US", "", "en-US-GuyNeural"},
+		{"edge_tts", "en-AU", "Female", "en-GB-SoniaNeural"},
+	}
+	for _, tc := range cases {
+		v, err := c.PickVoice(tc.provider, tc.locale, tc.gender)
+		if err != nil || v.Name != tc.want {
+			t.Errorf("PickVoice(%s, %s, %s) = %s, %v，期望 %s", tc.provider, tc.locale, tc.gender, v.Name, err, tc.want)
+		}
+	}
+	if _, err := c.PickVoice("edge_tts", "ko-KR", ""); err == nil {
+		t.Error("沒有韓語語音時應回傳錯誤")
+	}
+}
@@ -0,0 +1,174 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+)
+
+// Result 單句合成結果
+type Result struct {
+	Path     string
+	Duration float64
+	Provider string // 實際合成的 provider
+	Voice    string // 實際使用的語音
+}
+
+type chainLink struct {
+	name     string
+	provider Provider
+}
+
+// Chain 依序嘗試的 TTS provider 備援鏈 (例如 azure_v1 → edge_tts → local)：
+// 每個 provider 各自限流與重試，失敗才換下一個，並依語系與性別對應備援 provider 的語音
+type Chain struct {
+	links  []chainLink
+	gender string
+
+	Lexicon *Lexicon // 團隊發音字典，合成前套用
+
+	mu     sync.Mutex
+	voices map[string]string // 備援 provider 對應到的語音
+}
+
+// NewChain 建立備援鏈，primary 為任務指定的 provider，fallback 依序接在後面 (重複的會略過)
+// gender 為空時從 primary 的語音清單查詢
+func NewChain(cfg *config.Config, primary string, fallback []string, gender string) (*Chain, error) {
+	c := &Chain{gender: gender, voices: map[string]string{}}
+	seen := map[string]bool{}
+	for i, name := range append([]string{primary}, fallback...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		p, err := GetProvider(name, cfg)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			log.Warn().Err(err).Str("provider", name).Msg("略過未知的備援 TTS provider")
+			continue
+		}
+		p = &resilientProvider{
+			Provider: p,
+			name:     name,
+			slots:    slotsFor(name, cfg.Concurrency(name)),
+			limiter:  limiterFor(name, cfg.TTSRateLimits[name]),
+			retry:    RetryPolicy{MaxRetries: cfg.TTSMaxRetries, BaseDelay: 500 * time.Millisecond, MaxDelay: 8 * time.Second},
+		}
+		if cfg.TTSCache {
+			cp := NewCachedProvider(p, name, CacheDir(cfg))
+			cp.MaxBytes, cp.MaxAge = int64(cfg.TTSCacheMaxMB)<<20, cfg.TTSCacheMaxAge
+			p = cp
+		}
+		c.links = append(c.links, chainLink{name: name, provider: p})
+	}
+	return c, nil
+}
+
+// ChainOf 以已建立的 provider 組成只有一個 provider 的備援鏈，不另外加上限流、重試與快取
+func ChainOf(name string, p Provider) *Chain {
+	return &Chain{links: []chainLink{{name: name, provider: p}}, voices: map[string]string{}}
+}
+
+// SynthesizeLine 合成單句，依序嘗試備援鏈並回傳實際使用的 provider 與語音
+func (c *Chain) SynthesizeLine(text, voice, locale string, speed, pitch float64) (Result, error) {
+	text = c.Lexicon.Apply(text)
+	var errs []error
+	for i, l := range c.links {
+		v := voice
+		if i > 0 {
+			v = c.fallbackVoice(l, voice, locale)
+		}
+		path, dur, err := l.provider.Synthesize(text, v, locale, speed, pitch)
+		if err == nil {
+			if i > 0 {
+				log.Warn().Str("provider", l.name).Str("voice", v).Msg("TTS 改用備援 provider")
+			}
+			return Result{Path: path, Duration: dur, Provider: l.name, Voice: v}, nil
+		}
+		log.Warn().Err(err).Str("provider", l.name).Msg("TTS provider 合成失敗")
+		errs = append(errs, fmt.Errorf("%s: %w", l.name, err))
+	}
+	return Result{}, fmt.Errorf("所有 TTS provider 都失敗: %w", errors.Join(errs...))
+}
+
+// Synthesize 實作 Provider 介面
+func (c *Chain) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	r, err := c.SynthesizeLine(text, voice, locale, speed, pitch)
+	return r.Path, r.Duration, err
+}
+
+// ListVoices 回傳主要 provider 的語音
+func (c *Chain) ListVoices() ([]Voice, error) {
+	return c.links[0].provider.ListVoices()
+}
+
+// fallbackVoice 為備援 provider 挑選語系與性別相符的語音，查詢失敗時交給 provider 使用預設語音
+func (c *Chain) fallbackVoice(l chainLink, voice, locale string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.voices[l.name]; ok {
+		return v
+	}
+	if c.gender == "" && voice != "" {
+		if list, err := c.links[0].provider.ListVoices(); err == nil {
+			for _, v := range list {
+				if v.Name == voice {
+					c.gender = v.Gender
+					break
+				}
+			}
+		}
+	}
+	if locale == "" {
+		// 語音名稱通常以語系開頭，例如 zh-TW-HsiaoChenNeural
+		if parts := strings.SplitN(voice, "-", 3); len(parts) == 3 {
+			locale = parts[0] + "-" + parts[1]
+		}
+	}
+	mapped := ""
+	if list, err := l.provider.ListVoices(); err == nil {
+		mapped = MapVoice(list, locale, c.gender)
+	}
+	c.voices[l.name] = mapped
+	return mapped
+}
+
+// MapVoice 依語系與性別挑選語音：語系與性別皆符合 > 語系符合 > 同語言且性別符合 > 同語言
+func MapVoice(voices []Voice, locale, gender string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	var byLocale, byLangGender, byLang string
+	for _, v := range voices {
+		vlang, _, _ := strings.Cut(v.Locale, "-")
+		sameGender := gender == "" || strings.EqualFold(v.Gender, gender)
+		switch {
+		case strings.EqualFold(v.Locale, locale) && sameGender:
+			return v.Name
+		case strings.EqualFold(v.Locale, locale):
+			if byLocale == "" {
+				byLocale = v.Name
+			}
+		case lang != "" && strings.EqualFold(vlang, lang) && sameGender:
+			if byLangGender == "" {
+				byLangGender = v.Name
+			}
+		case lang != "" && strings.EqualFold(vlang, lang):
+			if byLang == "" {
+				byLang = v.Name
+			}
+		}
+	}
+	for _, v := range []string{byLocale, byLangGender, byLang} {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	errs   []error // 依序回傳的錯誤，用完後成功
+	calls  int
+	voice  string
+	voices []Voice
+}
+
+func (f *fakeProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	f.calls++
+	f.voice = voice
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	return "/tmp/" + voice + ".wav", 1.5, nil
+}
+
+func (f *fakeProvider) ListVoices() ([]Voice, error) { return f.voices, nil }
+
+func TestMapVoice(t *testing.T) {
+	voices := []Voice{
+		{Name: "zh-CN-YunxiNeural", Locale: "zh-CN", Gender: "Male"},
+		{Name: "zh-TW-HsiaoChenNeural", Locale: "zh-TW", Gender: "Female"},
+		{Name: "zh-TW-YunJheNeural", Locale: "zh-TW", Gender: "Male"},
+		{Name: "en-US-AriaNeural", Locale: "en-US", Gender: "Female"},
+	}
+	cases := []struct{ locale, gender, want string }{
+		{"zh-TW", "Male", "zh-TW-YunJheNeural"},
+		{"zh-TW", "female", "zh-TW-HsiaoChenNeural"},
+		{"zh-TW", "", "zh-TW-HsiaoChenNeural"},
+		{"zh-HK", "Male", "zh-CN-YunxiNeural"},
+		{"en-GB", "Male", "en-US-AriaNeural"},
+		{"ja-JP", "Female", ""},
+	}
+	for _, c := range cases {
+		if got := MapVoice(voices, c.locale, c.gender); got != c.want {
+			t.Errorf("MapVoice(%s, %s) = %q, want %q", c.locale, c.gender, got, c.want)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{Provider: "azure", Code: 429}, true},
+		{fmt.Errorf("wrap: %w", &StatusError{Provider: "azure", Code: 503}), true},
+		{&StatusError{Provider: "azure", Code: 401}, false},
+		{errors.New("read tcp: connection reset by peer"), true},
+		{errors.New("Azure TTS key or region is missing"), false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := IsTransient(c.err); got != c.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestChainFallback(t *testing.T) {
+	retry := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	primary := &fakeProvider{
+		errs:   []error{&StatusError{Code: 500}, &StatusError{Code: 500}, &StatusError{Code: 500}},
+		voices: []Voice{{Name: "zh-TW-YunJheNeural", Locale: "zh-TW", Gender: "Male"}},
+	}
+	backup := &fakeProvider{voices: []Voice{
+		{Name: "cmn+f3", Locale: "zh-TW", Gender: "Female"},
+		{Name: "cmn+m3", Locale: "zh-TW", Gender: "Male"},
+	}}
+	c := &Chain{voices: map[string]string{}, links: []chainLink{
+		{name: "azure_v1", provider: &resilientProvider{Provider: primary, name: "azure_v1", retry: retry}},
+		{name: "local", provider: &resilientProvider{Provider: backup, name: "local", retry: retry}},
+	}}
+
+	res, err := c.SynthesizeLine("你好", "zh-TW-YunJheNeural", "zh-TW", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 3 {
+		t.Errorf("主要 provider 應嘗試 1 次加重試 2 次，實際 %d 次", primary.calls)
+	}
+	if res.Provider != "local" || res.Voice != "cmn+m3" || backup.voice != "cmn+m3" {
+		t.Errorf("應改用備援並對應男聲，得到 %+v", res)
+	}
+
+	// 非暫時性錯誤不重試，直接換下一個
+	primary.errs = []error{errors.New("Azure TTS key or region is missing")}
+	primary.calls = 0
+	if _, err := c.SynthesizeLine("你好", "zh-TW-YunJheNeural", "zh-TW", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if primary.calls != 1 {
+		t.Errorf("設定錯誤不應重試，實際呼叫 %d 次", primary.calls)
+	}
+}
+
+func TestChainFallbackLocale(t *testing.T) {
+	retry := RetryPolicy{}
+	primary := &fakeProvider{errs: []error{errors.New("down")}}
+	backup := &fakeProvider{voices: []Voice{
+		{Name: "en-US-Guy", Locale: "en-US", Gender: "Male"},
+		{Name: "ja-JP-Keita", Locale: "ja-JP", Gender: "Male"},
+	}}
+	c := &Chain{gender: "Male", voices: map[string]string{}, links: []chainLink{
+		{name: "azure_v1", provider: &resilientProvider{Provider: primary, name: "azure_v1", retry: retry}},
+		{name: "edge_tts", provider: &resilientProvider{Provider: backup, name: "edge_tts", retry: retry}},
+	}}
+	// 指定性別但未指定語系時，仍由語音名稱推得語系
+	res, err := c.SynthesizeLine("こんにちは", "ja-JP-NanamiNeural", "", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Voice != "ja-JP-Keita" {
+		t.Errorf("備援語音 = %q，期望同語系的 ja-JP-Keita", res.Voice)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		b.Wait()
+	}
+	// 前 2 個令牌立即可用，之後每 10ms 補充 1 個
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Errorf("限流沒有生效，耗時 %s", d)
+	}
+}
+
+// slowProvider 記錄同時進行中的合成數量
+type slowProvider struct {
+	active, peak atomic.Int32
+}
+
+func (s *slowProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return "/tmp/" + text + ".wav", 1, nil
+}
+
+func (s *slowProvider) ListVoices() ([]Voice, error) { return nil, nil }
+
+func TestResilientSlots(t *testing.T) {
+	slow := &slowProvider{}
+	r := &resilientProvider{Provider: slow, name: "test", slots: make(chan struct{}, 2)}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, _, err := r.Synthesize(fmt.Sprint(i), "v", "zh-TW", 1, 0); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if p := slow.peak.Load(); p > 2 {
+		t.Errorf("同時進行的合成不應超過 2 個，實際 %d 個", p)
+	}
+}
@@ -4,10 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
 	"github.com/lib-x/edgetts"
@@ -43,7 +40,7 @@ func (e *EdgeTTSProvider) Synthesize(text, voice, locale string, speed, pitch fl
 
 	// 使用 buffer 接收音訊資料
 	var buf bytes.Buffer
-	if err := speech.AddSingleTask(text, &buf); err != nil {
+	if err := speech.AddSingleTask(edgeText(text, locale), &buf); err != nil {
 		return "", 0, fmt.Errorf("Edge TTS 新增任務失敗: %w", err)
 	}
 
@@ -56,8 +53,17 @@ func (e *EdgeTTSProvider) Synthesize(text, voice, locale string, speed, pitch fl
 	}
 
 	// 寫入暫存檔案 (Edge TTS 輸出為 MP3 格式)
-	tmp := filepath.Join(os.TempDir(), "edge_tts_"+strconv.FormatInt(time.Now().UnixNano(), 10)+".mp3")
-	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+	// 並行合成時以 CreateTemp 取得唯一檔名
+	f, err := os.CreateTemp("", "edge_tts_*.mp3")
+	if err != nil {
+		return "", 0, fmt.Errorf("Edge TTS 建立檔案失敗: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
 		return "", 0, fmt.Errorf("Edge TTS 寫入檔案失敗: %w", err)
 	}
 
@@ -65,6 +71,12 @@ func (e *EdgeTTSProvider) Synthesize(text, voice, locale string, speed, pitch fl
 	return tmp, dur, nil
 }
 
+// edgeText Edge TTS 不接受自訂 SSML，標記轉為純文字；
+// 套件組成 SSML 時會以 XML 轉義文字，這裡不可再轉義
+func edgeText(text, locale string) string {
+	return renderPlain(ParseMarkup(text), locale)
+}
+
 func (e *EdgeTTSProvider) ListVoices() ([]Voice, error) {
 	speech, err := edgetts.NewSpeech()
 	if err != nil {
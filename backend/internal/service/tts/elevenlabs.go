@@ -0,0 +1,97 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	elevenLabsBaseURL = "https://api.elevenlabs.io"
+	// elevenLabsDefaultVoice 未指定語音時使用 (Rachel)
+	elevenLabsDefaultVoice = "21m00Tcm4TlvDQ8ikWAM"
+)
+
+// ElevenLabsProvider 實作 ElevenLabs text-to-speech API，多語模型可直接合成各語系
+type ElevenLabsProvider struct {
+	Key     string
+	Model   string // 預設 eleven_multilingual_v2
+	BaseURL string // 預設 elevenLabsBaseURL，測試時指向替身伺服器
+}
+
+func (e *ElevenLabsProvider) base() string {
+	if e.BaseURL != "" {
+		return strings.TrimRight(e.BaseURL, "/")
+	}
+	return elevenLabsBaseURL
+}
+
+func (e *ElevenLabsProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	if e.Key == "" {
+		return "", 0, fmt.Errorf("ElevenLabs API key is missing")
+	}
+	if voice == "" {
+		voice = elevenLabsDefaultVoice
+	}
+	model := e.Model
+	if model == "" {
+		model = "eleven_multilingual_v2"
+	}
+	// 只支援 break 標籤；語速範圍 0.7 ~ 1.2，不支援音高
+	body, _ := json.Marshal(map[string]interface{}{
+		"text":     renderBreaks(ParseMarkup(text), locale),
+		"model_id": model,
+		"voice_settings": map[string]float64{
+			"stability":        0.5,
+			"similarity_boost": 0.75,
+			"speed":            min(max(speed, 0.7), 1.2),
+		},
+	})
+	req, _ := http.NewRequest(http.MethodPost, e.base()+"/v1/text-to-speech/"+url.PathEscape(voice)+"?output_format=mp3_44100_128", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/mpeg")
+	req.Header.Set("xi-api-key", e.Key)
+	audio, err := doRequest("elevenlabs", req)
+	if err != nil {
+		return "", 0, err
+	}
+	return saveAudio("elevenlabs", ".mp3", audio)
+}
+
+func (e *ElevenLabsProvider) ListVoices() ([]Voice, error) {
+	if e.Key == "" {
+		return nil, fmt.Errorf("ElevenLabs API key is missing")
+	}
+	req, _ := http.NewRequest(http.MethodGet, e.base()+"/v1/voices", nil)
+	req.Header.Set("xi-api-key", e.Key)
+	body, err := doRequest("elevenlabs", req)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Voices []struct {
+			VoiceID string            `json:"voice_id"`
+			Name    string            `json:"name"`
+			Labels  map[string]string `json:"labels"`
+		} `json:"voices"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	var voices []Voice
+	for _, v := range raw.Voices {
+		// 語音本身多語，labels.language 只是錄製時的語言，可能為空
+		locale := v.Labels["language"]
+		gender := titleCase(v.Labels["gender"])
+		voices = append(voices, Voice{
+			Name:        v.VoiceID,
+			DisplayName: fmt.Sprintf("%s (%s, %s)", v.Name, v.Labels["accent"], gender),
+			Locale:      locale,
+			Gender:      gender,
+		})
+	}
+	return voices, nil
+}
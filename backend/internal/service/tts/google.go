@@ -0,0 +1,97 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const googleBaseURL = "https://texttospeech.googleapis.com/v1"
+
+// GoogleProvider 實作 Google Cloud Text-to-Speech REST API (API key 驗證)
+type GoogleProvider struct {
+	Key     string
+	BaseURL string // 預設 googleBaseURL，測試時指向替身伺服器
+}
+
+func (g *GoogleProvider) base() string {
+	if g.BaseURL != "" {
+		return strings.TrimRight(g.BaseURL, "/")
+	}
+	return googleBaseURL
+}
+
+func (g *GoogleProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	if g.Key == "" {
+		return "", 0, fmt.Errorf("Google TTS key is missing")
+	}
+	// 語言代碼以語音名稱為準 (例如 cmn-TW-Wavenet-A)，未指定語音時由語系換算
+	lang := cloudLanguage(locale)
+	if parts := strings.SplitN(voice, "-", 3); len(parts) == 3 {
+		lang = parts[0] + "-" + parts[1]
+	}
+	payload := map[string]interface{}{
+		"input": map[string]string{"ssml": "<speak>" + renderSSML(ParseMarkup(text), googleCaps) + "</speak>"},
+		"voice": map[string]string{"languageCode": lang, "name": voice},
+		"audioConfig": map[string]interface{}{
+			"audioEncoding":   "LINEAR16",
+			"sampleRateHertz": 24000,
+			"speakingRate":    speed,
+			// pitch 以半音為單位 (-20 ~ 20)，+1.0 約為一個八度
+			"pitch": min(max(pitch*12, -20), 20),
+		},
+	}
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPost, g.base()+"/text:synthesize?key="+url.QueryEscape(g.Key), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := doRequest("google", req)
+	if err != nil {
+		return "", 0, err
+	}
+	var out struct {
+		AudioContent []byte `json:"audioContent"` // base64，LINEAR16 含 WAV 檔頭
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return "", 0, fmt.Errorf("Google TTS 回應格式錯誤: %w", err)
+	}
+	return saveAudio("google", ".wav", out.AudioContent)
+}
+
+func (g *GoogleProvider) ListVoices() ([]Voice, error) {
+	if g.Key == "" {
+		return nil, fmt.Errorf("Google TTS key is missing")
+	}
+	req, _ := http.NewRequest(http.MethodGet, g.base()+"/voices?key="+url.QueryEscape(g.Key), nil)
+	body, err := doRequest("google", req)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Voices []struct {
+			LanguageCodes []string `json:"languageCodes"`
+			Name          string   `json:"name"`
+			SsmlGender    string   `json:"ssmlGender"`
+		} `json:"voices"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	var voices []Voice
+	for _, v := range raw.Voices {
+		locale := ""
+		if len(v.LanguageCodes) > 0 {
+			locale = standardLocale(v.LanguageCodes[0])
+		}
+		gender := titleCase(v.SsmlGender)
+		voices = append(voices, Voice{
+			Name:        v.Name,
+			DisplayName: fmt.Sprintf("%s (%s, %s)", v.Name, locale, gender),
+			Locale:      locale,
+			Gender:      gender,
+		})
+	}
+	return voices, nil
+}
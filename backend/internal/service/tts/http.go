@@ -0,0 +1,69 @@
+package tts
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// httpClient 雲端 provider 共用的 HTTP 客戶端：連線可重複使用，並限制各階段的等待時間
+var httpClient = &http.Client{
+	Timeout: 60 * time.Second,
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 45 * time.Second,
+		MaxIdleConnsPerHost:   8,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// maxResponseBytes 回應大小上限，避免異常回應佔滿記憶體
+const maxResponseBytes = 64 << 20
+
+// doRequest 送出請求並讀取回應內容，狀態碼非 2xx 時回傳 StatusError (供重試判斷)
+func doRequest(provider string, req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{Provider: provider, Code: resp.StatusCode, Body: string(body)}
+	}
+	return body, nil
+}
+
+// saveAudio 將合成的音訊寫入唯一的暫存檔並回傳長度
+func saveAudio(provider, ext string, data []byte) (string, float64, error) {
+	if len(data) < 100 {
+		// 檔案太小，可能是空檔或只有檔頭
+		return "", 0, fmt.Errorf("%s 回傳音訊太小 (%d bytes)", provider, len(data))
+	}
+	// 並行合成時以 CreateTemp 取得唯一檔名
+	f, err := os.CreateTemp("", provider+"_tts_*"+ext)
+	if err != nil {
+		return "", 0, err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", 0, err
+	}
+	dur, _ := utils.AudioDurationSeconds(tmp)
+	return tmp, dur, nil
+}
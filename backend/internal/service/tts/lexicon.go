@@ -0,0 +1,164 @@
+package tts
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LexiconEntry 發音字典項目：品牌名稱、縮寫等固定唸法
+type LexiconEntry struct {
+	Term          string `json:"term"`                     // 要替換唸法的字詞
+	Alias         string `json:"alias,omitempty"`          // 改唸的文字，例如 WHO → 世界衛生組織
+	Phoneme       string `json:"phoneme,omitempty"`        // 音標，支援的 provider 優先使用，其餘改唸 alias
+	Alphabet      string `json:"alphabet,omitempty"`       // 音標字母表：ipa (預設)、sapi、x-microsoft-ups
+	SayAs         string `json:"say_as,omitempty"`         // 指定讀法，例如縮寫逐字唸用 characters
+	CaseSensitive bool   `json:"case_sensitive,omitempty"` // 區分大小寫
+}
+
+// Lexicon 團隊的發音字典，合成前套用到每一句
+type Lexicon struct {
+	Team      string         `json:"team"`
+	Entries   []LexiconEntry `json:"entries"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+var teamPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidTeam 團隊名稱只允許英數、底線與連字號 (用於檔名)
+func ValidTeam(team string) bool {
+	return teamPattern.MatchString(team)
+}
+
+// Validate 檢查字典內容並填入預設值
+func (l *Lexicon) Validate() error {
+	seen := map[string]bool{}
+	for i := range l.Entries {
+		e := &l.Entries[i]
+		e.Term = strings.TrimSpace(e.Term)
+		e.Alias = strings.TrimSpace(e.Alias)
+		e.Phoneme = strings.TrimSpace(e.Phoneme)
+		e.SayAs = strings.ToLower(strings.TrimSpace(e.SayAs))
+		if e.Term == "" {
+			return fmt.Errorf("entries[%d].term 不可空白", i)
+		}
+		if e.Alias == "" && e.Phoneme == "" && e.SayAs == "" {
+			return fmt.Errorf("entries[%d] 需指定 alias、phoneme 或 say_as", i)
+		}
+		if strings.ContainsAny(e.Alias+e.Phoneme, "[]|") {
+			return fmt.Errorf("entries[%d] 的 alias 與 phoneme 不可包含方括號或 |", i)
+		}
+		if e.Phoneme != "" {
+			if e.Alphabet == "" {
+				e.Alphabet = "ipa"
+			}
+			e.Alphabet = strings.ToLower(e.Alphabet)
+			if !slices.Contains(PhonemeAlphabets, e.Alphabet) {
+				return fmt.Errorf("entries[%d].alphabet 不支援: %s", i, e.Alphabet)
+			}
+		}
+		if e.SayAs != "" && !slices.Contains(SayAsValues, e.SayAs) {
+			return fmt.Errorf("entries[%d].say_as 不支援: %s", i, e.SayAs)
+		}
+		key := e.Term
+		if !e.CaseSensitive {
+			key = strings.ToLower(key)
+		}
+		if seen[key] {
+			return fmt.Errorf("entries[%d].term 重複: %s", i, e.Term)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Apply 將字典套用到含行內標記的文字：標記外的字詞換成 phoneme、sub 或 say-as 標記，
+// 已在標記內的文字不再處理。英數字詞需完整比對，避免 AI 被套用到 SAID 之類的字
+func (l *Lexicon) Apply(s string) string {
+	if l == nil || len(l.Entries) == 0 {
+		return s
+	}
+	// 較長的字詞優先，例如 "GPT-4o" 先於 "GPT"
+	entries := append([]LexiconEntry(nil), l.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return len(entries[i].Term) > len(entries[j].Term) })
+
+	var out []Node
+	for _, n := range ParseMarkup(s) {
+		if n.Kind != NodeText {
+			out = append(out, n)
+			continue
+		}
+		out = append(out, applyEntries(n.Text, entries)...)
+	}
+	return FormatMarkup(out)
+}
+
+// applyEntries 在純文字中尋找字典字詞並拆成節點
+func applyEntries(text string, entries []LexiconEntry) []Node {
+	var nodes []Node
+	start := 0
+	for i := 0; i < len(text); {
+		e, ok := matchEntry(text, i, entries)
+		if !ok {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		if start < i {
+			nodes = append(nodes, Node{Kind: NodeText, Text: text[start:i]})
+		}
+		term := text[i : i+len(e.Term)]
+		switch {
+		case e.Phoneme != "":
+			// 不支援音標的 provider 改唸 alias，沒有 alias 則唸原文
+			nodes = append(nodes, Node{Kind: NodePhoneme, Text: term, Value: e.Phoneme, Alphabet: e.Alphabet, Alias: e.Alias})
+		case e.Alias != "":
+			nodes = append(nodes, Node{Kind: NodeSub, Text: term, Value: e.Alias})
+		default:
+			nodes = append(nodes, Node{Kind: NodeSayAs, Text: term, Value: e.SayAs})
+		}
+		i += len(e.Term)
+		start = i
+	}
+	if start < len(text) {
+		nodes = append(nodes, Node{Kind: NodeText, Text: text[start:]})
+	}
+	return nodes
+}
+
+// matchEntry 檢查 text[i:] 是否以某個字典字詞開頭
+func matchEntry(text string, i int, entries []LexiconEntry) (LexiconEntry, bool) {
+	for _, e := range entries {
+		end := i + len(e.Term)
+		if end > len(text) {
+			continue
+		}
+		cand := text[i:end]
+		if cand != e.Term && (e.CaseSensitive || !strings.EqualFold(cand, e.Term)) {
+			continue
+		}
+		// 英數字詞前後不可緊接英數字
+		if r, _ := utf8.DecodeRuneInString(e.Term); isWordRune(r) {
+			if prev, _ := utf8.DecodeLastRuneInString(text[:i]); i > 0 && isWordRune(prev) {
+				continue
+			}
+		}
+		if r, _ := utf8.DecodeLastRuneInString(e.Term); isWordRune(r) {
+			if next, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(next) {
+				continue
+			}
+		}
+		return e, true
+	}
+	return LexiconEntry{}, false
+}
+
+// isWordRune 拉丁字母與數字 (中日文字之間沒有空白，不做邊界判斷)
+func isWordRune(r rune) bool {
+	return r < unicode.MaxLatin1 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
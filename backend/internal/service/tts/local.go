@@ -0,0 +1,103 @@
+package tts
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// localVoices espeak-ng 語言對應，每個語系提供男女兩種變體
+var localVoices = []struct {
+	Locale string
+	Lang   string
+}{
+	{"zh-TW", "cmn"},
+	{"zh-CN", "cmn"},
+	{"zh-HK", "yue"},
+	{"en-US", "en-us"},
+	{"en-GB", "en-gb"},
+	{"ja-JP", "ja"},
+	{"ko-KR", "ko"},
+	{"es-ES", "es"},
+	{"fr-FR", "fr"},
+	{"de-DE", "de"},
+	{"vi-VN", "vi"},
+	{"th-TH", "th"},
+	{"id-ID", "id"},
+}
+
+// LocalProvider 使用 espeak-ng 離線合成，音質較差但不需網路與金鑰，作為備援鏈的最後一站
+type LocalProvider struct {
+	Bin string // 執行檔，預設 espeak-ng
+}
+
+func (l *LocalProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	bin := l.Bin
+	if bin == "" {
+		bin = "espeak-ng"
+	}
+	if voice == "" {
+		voice = localVoiceFor(locale, "Female")
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+	// espeak-ng 語速單位為每分鐘字數 (預設 175)，音高 0~99 (預設 50)
+	wpm := int(175 * speed)
+	p := min(max(int(50+pitch*50), 0), 99)
+
+	f, err := os.CreateTemp("", "local_tts_*.wav")
+	if err != nil {
+		return "", 0, err
+	}
+	tmp := f.Name()
+	f.Close()
+	// -m 讓 espeak-ng 解析 SSML (支援 break、emphasis、sub 與逐字讀)
+	ssml := "<speak>" + renderSSML(ParseMarkup(text), espeakCaps) + "</speak>"
+	if out, err := utils.RunCmdTimeout(time.Minute, bin, "-m", "-v", voice, "-s", strconv.Itoa(wpm), "-p", strconv.Itoa(p), "-w", tmp, ssml); err != nil {
+		return "", 0, fmt.Errorf("espeak-ng 合成失敗: %v / %s", err, out)
+	}
+	dur, _ := utils.AudioDurationSeconds(tmp)
+	return tmp, dur, nil
+}
+
+func (l *LocalProvider) ListVoices() ([]Voice, error) {
+	var voices []Voice
+	for _, v := range localVoices {
+		for _, g := range []string{"Female", "Male"} {
+			voices = append(voices, Voice{
+				Name:        localVoiceFor(v.Locale, g),
+				DisplayName: fmt.Sprintf("espeak-ng %s (%s, %s)", v.Lang, v.Locale, g),
+				Locale:      v.Locale,
+				Gender:      g,
+			})
+		}
+	}
+	return voices, nil
+}
+
+// localVoiceFor 依語系與性別組出 espeak-ng 語音名稱，例如 cmn+f3
+func localVoiceFor(locale, gender string) string {
+	lang := ""
+	for _, v := range localVoices {
+		if strings.EqualFold(v.Locale, locale) {
+			lang = v.Lang
+			break
+		}
+	}
+	if lang == "" {
+		lang, _, _ = strings.Cut(strings.ToLower(locale), "-")
+		if lang == "" {
+			lang = "en-us"
+		}
+	}
+	variant := "+f3"
+	if strings.EqualFold(gender, "male") {
+		variant = "+m3"
+	}
+	return lang + variant
+}
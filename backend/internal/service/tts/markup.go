@@ -0,0 +1,486 @@
+package tts
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 腳本行內標記 (需在任務設定 tts.markup 開啟)：
+//
+//	[pause=500ms] 或 [pause=1.5s]   停頓，省略數值為 500ms，最長 5 秒
+//	[emphasis]重點[/emphasis]       強調，可指定 [emphasis=strong|moderate|reduced]
+//	[say-as=date]2024/5/1[/say-as]  指定讀法 (cardinal、ordinal、digits、characters、date、time、telephone)
+//	[phoneme=ipa:ˈdʒiːoʊ]GEO[/phoneme] 指定發音，字母表可為 ipa (預設)、sapi、x-microsoft-ups；
+//	                                 可加上 "|別名" 給不支援音標的 provider 改唸，例如 [phoneme=ipa:ˈdʒiːoʊ|吉歐]
+//	[sub=世界衛生組織]WHO[/sub]      以別名取代唸法
+//
+// 字面上的 "[" 以 "[[" 表示。各 provider 依能力轉譯：Azure 輸出完整 SSML，
+// espeak-ng 使用不含 phoneme 的 SSML 子集，Edge TTS 只接受純文字，停頓改以標點近似
+
+// NodeKind 標記節點種類
+type NodeKind int
+
+const (
+	NodeText NodeKind = iota
+	NodePause
+	NodeEmphasis
+	NodeSayAs
+	NodePhoneme
+	NodeSub
+)
+
+// Node 解析後的標記節點；區段標記 (emphasis、say-as、phoneme、sub) 只包住純文字
+type Node struct {
+	Kind     NodeKind
+	Text     string        // 文字，或區段標記包住的文字
+	Value    string        // emphasis 強度、say-as 讀法、phoneme 音標、sub 別名
+	Alphabet string        // phoneme 字母表
+	Alias    string        // phoneme 不支援時改唸的文字
+	Pause    time.Duration // pause 長度
+}
+
+// MaxPause 單一停頓的上限 (Azure break 最長 5 秒)
+const MaxPause = 5 * time.Second
+
+const defaultPause = 500 * time.Millisecond
+
+// SayAsValues 支援的 say-as 讀法
+var SayAsValues = []string{"cardinal", "ordinal", "digits", "characters", "date", "time", "telephone"}
+
+// PhonemeAlphabets 支援的音標字母表
+var PhonemeAlphabets = []string{"ipa", "sapi", "x-microsoft-ups"}
+
+var emphasisLevels = []string{"strong", "moderate", "reduced"}
+
+var spanTags = map[string]NodeKind{
+	"emphasis": NodeEmphasis,
+	"say-as":   NodeSayAs,
+	"phoneme":  NodePhoneme,
+	"sub":      NodeSub,
+}
+
+// ParseMarkup 解析行內標記。無法辨識的方括號視為一般文字，未關閉的區段延伸到結尾，多餘的結束標記忽略
+func ParseMarkup(s string) []Node {
+	var nodes []Node
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Node{Kind: NodeText, Text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		if s[i] != '[' {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			text.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+		if strings.HasPrefix(s[i:], "[[") {
+			text.WriteByte('[')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(s[i:], ']')
+		if end < 0 {
+			text.WriteString(s[i:])
+			break
+		}
+		tag := s[i+1 : i+end]
+		next := i + end + 1
+		name, value, _ := strings.Cut(tag, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(name, "/") {
+			if _, ok := spanTags[name[1:]]; ok {
+				i = next
+				continue
+			}
+		}
+		if name == "pause" {
+			if d, ok := parsePause(value); ok {
+				flush()
+				nodes = append(nodes, Node{Kind: NodePause, Pause: d})
+				i = next
+				continue
+			}
+		}
+		if kind, ok := spanTags[name]; ok {
+			if n, ok := spanNode(kind, value); ok {
+				closing := "[/" + name + "]"
+				inner := s[next:]
+				if j := strings.Index(inner, closing); j >= 0 {
+					inner = inner[:j]
+					next += j + len(closing)
+				} else {
+					next = len(s)
+				}
+				flush()
+				n.Text = strings.ReplaceAll(inner, "[[", "[")
+				nodes = append(nodes, n)
+				i = next
+				continue
+			}
+		}
+		// 不是標記，保留原文
+		text.WriteString(s[i:next])
+		i = next
+	}
+	flush()
+	return nodes
+}
+
+// parsePause 解析停頓長度，例如 500ms、1.5s、800 (毫秒)
+func parsePause(v string) (time.Duration, bool) {
+	if v == "" {
+		return defaultPause, true
+	}
+	v = strings.ToLower(v)
+	var d time.Duration
+	switch {
+	case strings.HasSuffix(v, "ms"):
+		n, err := strconv.ParseFloat(strings.TrimSuffix(v, "ms"), 64)
+		if err != nil {
+			return 0, false
+		}
+		d = time.Duration(n * float64(time.Millisecond))
+	case strings.HasSuffix(v, "s"):
+		n, err := strconv.ParseFloat(strings.TrimSuffix(v, "s"), 64)
+		if err != nil {
+			return 0, false
+		}
+		d = time.Duration(n * float64(time.Second))
+	default:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		d = time.Duration(n * float64(time.Millisecond))
+	}
+	if d <= 0 {
+		return 0, false
+	}
+	return min(d, MaxPause), true
+}
+
+// spanNode 檢查區段標記的參數
+func spanNode(kind NodeKind, value string) (Node, bool) {
+	n := Node{Kind: kind}
+	switch kind {
+	case NodeEmphasis:
+		if value == "" {
+			value = "moderate"
+		}
+		n.Value = strings.ToLower(value)
+		return n, slices.Contains(emphasisLevels, n.Value)
+	case NodeSayAs:
+		n.Value = strings.ToLower(value)
+		return n, slices.Contains(SayAsValues, n.Value)
+	case NodePhoneme:
+		n.Alphabet = "ipa"
+		if a, ph, ok := strings.Cut(value, ":"); ok && slices.Contains(PhonemeAlphabets, strings.ToLower(a)) {
+			n.Alphabet, value = strings.ToLower(a), ph
+		}
+		n.Value, n.Alias, _ = strings.Cut(value, "|")
+		return n, n.Value != ""
+	case NodeSub:
+		n.Value = value
+		return n, value != ""
+	}
+	return n, false
+}
+
+// FormatMarkup 將節點轉回行內標記文字
+func FormatMarkup(nodes []Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case NodeText:
+			b.WriteString(EscapeMarkup(n.Text))
+		case NodePause:
+			fmt.Fprintf(&b, "[pause=%dms]", n.Pause.Milliseconds())
+		case NodeEmphasis:
+			fmt.Fprintf(&b, "[emphasis=%s]%s[/emphasis]", n.Value, EscapeMarkup(n.Text))
+		case NodeSayAs:
+			fmt.Fprintf(&b, "[say-as=%s]%s[/say-as]", n.Value, EscapeMarkup(n.Text))
+		case NodePhoneme:
+			value := n.Value
+			if n.Alias != "" {
+				value += "|" + n.Alias
+			}
+			fmt.Fprintf(&b, "[phoneme=%s:%s]%s[/phoneme]", n.Alphabet, value, EscapeMarkup(n.Text))
+		case NodeSub:
+			fmt.Fprintf(&b, "[sub=%s]%s[/sub]", n.Value, EscapeMarkup(n.Text))
+		}
+	}
+	return b.String()
+}
+
+// EscapeMarkup 讓一般文字中的 "[" 不被當成標記
+func EscapeMarkup(s string) string {
+	return strings.ReplaceAll(s, "[", "[[")
+}
+
+// StripMarkup 移除標記，回傳字幕顯示用的文字 (sub 顯示原文而非別名)
+func StripMarkup(s string) string {
+	var b strings.Builder
+	for _, n := range ParseMarkup(s) {
+		b.WriteString(n.Text)
+	}
+	return b.String()
+}
+
+// MapText 只對標記外的文字套用 f；標記與區段內的文字 (例如 say-as 的日期 2024/5/1) 保持不變
+func MapText(s string, f func(string) string) string {
+	nodes := ParseMarkup(s)
+	for i := range nodes {
+		if nodes[i].Kind == NodeText {
+			nodes[i].Text = f(nodes[i].Text)
+		}
+	}
+	return FormatMarkup(nodes)
+}
+
+// PauseMarkup 將一般文字轉為只含停頓的行內標記：合法的 [pause=…] 保留，其餘方括號都視為文字。
+// 未開啟行內標記的任務仍可在腳本中指定停頓
+func PauseMarkup(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '[')
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		if end := strings.IndexByte(s, ']'); end > 0 {
+			name, value, _ := strings.Cut(s[1:end], "=")
+			if strings.EqualFold(strings.TrimSpace(name), "pause") {
+				if d, ok := parsePause(strings.TrimSpace(value)); ok {
+					fmt.Fprintf(&b, "[pause=%dms]", d.Milliseconds())
+					s = s[end+1:]
+					continue
+				}
+			}
+		}
+		b.WriteString("[[")
+		s = s[1:]
+	}
+	return b.String()
+}
+
+// PlainPauses 將 PauseMarkup 的結果轉回一般文字，停頓標記保留
+func PlainPauses(s string) string {
+	var b strings.Builder
+	for _, n := range ParseMarkup(s) {
+		if n.Kind == NodePause {
+			fmt.Fprintf(&b, "[pause=%dms]", n.Pause.Milliseconds())
+			continue
+		}
+		b.WriteString(n.Text)
+	}
+	return b.String()
+}
+
+// TrimPauses 移除句首與句尾的停頓標記並回傳其長度；
+// 句子前後的靜音會在修剪時被切掉，需改由句間停頓處理
+func TrimPauses(s string) (lead, trail time.Duration, rest string) {
+	nodes := ParseMarkup(s)
+	blank := func(n Node) bool { return n.Kind == NodeText && strings.TrimSpace(n.Text) == "" }
+	trimmed := false
+	for len(nodes) > 0 && (nodes[0].Kind == NodePause || blank(nodes[0])) {
+		lead += nodes[0].Pause
+		nodes = nodes[1:]
+		trimmed = true
+	}
+	for len(nodes) > 0 && (nodes[len(nodes)-1].Kind == NodePause || blank(nodes[len(nodes)-1])) {
+		trail += nodes[len(nodes)-1].Pause
+		nodes = nodes[:len(nodes)-1]
+		trimmed = true
+	}
+	if !trimmed {
+		return 0, 0, s
+	}
+	if len(nodes) > 0 && nodes[0].Kind == NodeText {
+		nodes[0].Text = strings.TrimLeftFunc(nodes[0].Text, unicode.IsSpace)
+	}
+	if n := len(nodes) - 1; n >= 0 && nodes[n].Kind == NodeText {
+		nodes[n].Text = strings.TrimRightFunc(nodes[n].Text, unicode.IsSpace)
+	}
+	return lead, trail, FormatMarkup(nodes)
+}
+
+// placeholderBase 斷句前以私用區字元暫代標記，避免標記被斷開或加上空白
+const placeholderBase = 0xE000
+
+// ProtectMarkup 將每個標記換成一個私用區字元，回傳替換後的文字與原本的標記
+func ProtectMarkup(s string) (string, []string) {
+	var b strings.Builder
+	var tags []string
+	for _, n := range ParseMarkup(s) {
+		if n.Kind == NodeText {
+			b.WriteString(EscapeMarkup(n.Text))
+			continue
+		}
+		full := FormatMarkup([]Node{n})
+		if n.Kind == NodePause {
+			tags = append(tags, full)
+			b.WriteRune(rune(placeholderBase + len(tags) - 1))
+			continue
+		}
+		// 區段標記拆成開頭與結尾，包住的文字仍參與斷句
+		open := full[:strings.IndexByte(full, ']')+1]
+		closing := full[strings.LastIndex(full, "[/"):]
+		tags = append(tags, open)
+		b.WriteRune(rune(placeholderBase + len(tags) - 1))
+		b.WriteString(EscapeMarkup(n.Text))
+		tags = append(tags, closing)
+		b.WriteRune(rune(placeholderBase + len(tags) - 1))
+	}
+	return b.String(), tags
+}
+
+// RestoreMarkup 將斷句後各行的私用區字元換回標記；
+// 若有標記遺失或重複 (例如 AI 斷句改寫了文字) 回傳 false
+func RestoreMarkup(lines []string, tags []string) ([]string, bool) {
+	seen := make([]bool, len(tags))
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		var b strings.Builder
+		for _, r := range line {
+			idx := int(r) - placeholderBase
+			if idx < 0 || idx >= len(tags) {
+				b.WriteRune(r)
+				continue
+			}
+			if seen[idx] {
+				return nil, false
+			}
+			seen[idx] = true
+			b.WriteString(tags[idx])
+		}
+		out[i] = b.String()
+	}
+	for _, ok := range seen {
+		if !ok {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// ssmlCaps provider 支援的 SSML 元素
+type ssmlCaps struct {
+	emphasis  bool
+	alphabets []string // 支援的音標字母表，空白表示不支援 phoneme
+	sayAs     []string // 支援的 say-as 讀法
+}
+
+var (
+	azureCaps  = ssmlCaps{emphasis: true, alphabets: PhonemeAlphabets, sayAs: SayAsValues}
+	googleCaps = ssmlCaps{emphasis: true, alphabets: []string{"ipa"}, sayAs: []string{"cardinal", "ordinal", "characters", "date", "time", "telephone"}}
+	pollyCaps  = ssmlCaps{alphabets: []string{"ipa"}, sayAs: SayAsValues} // neural 語音不支援 emphasis
+	espeakCaps = ssmlCaps{emphasis: true, sayAs: []string{"characters"}}
+)
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// xmlEscape 轉義 SSML 文字與屬性值
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// renderSSML 依 provider 能力輸出 SSML 片段 (不含 speak 外層)，不支援的元素退回純文字
+func renderSSML(nodes []Node, caps ssmlCaps) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		text := xmlEscape(n.Text)
+		switch n.Kind {
+		case NodeText:
+			b.WriteString(text)
+		case NodePause:
+			fmt.Fprintf(&b, `<break time="%dms"/>`, n.Pause.Milliseconds())
+		case NodeEmphasis:
+			if caps.emphasis {
+				fmt.Fprintf(&b, `<emphasis level="%s">%s</emphasis>`, n.Value, text)
+			} else {
+				b.WriteString(text)
+			}
+		case NodeSayAs:
+			if slices.Contains(caps.sayAs, n.Value) {
+				fmt.Fprintf(&b, `<say-as interpret-as="%s">%s</say-as>`, n.Value, text)
+			} else {
+				b.WriteString(text)
+			}
+		case NodePhoneme:
+			switch {
+			case slices.Contains(caps.alphabets, n.Alphabet):
+				fmt.Fprintf(&b, `<phoneme alphabet="%s" ph="%s">%s</phoneme>`, n.Alphabet, xmlEscape(n.Value), text)
+			case n.Alias != "":
+				fmt.Fprintf(&b, `<sub alias="%s">%s</sub>`, xmlEscape(n.Alias), text)
+			default:
+				b.WriteString(text)
+			}
+		case NodeSub:
+			fmt.Fprintf(&b, `<sub alias="%s">%s</sub>`, xmlEscape(n.Value), text)
+		}
+	}
+	return b.String()
+}
+
+// renderBreaks 給只認得 break 標籤的 provider (ElevenLabs)：停頓保留為 break，其餘同 renderPlain
+func renderBreaks(nodes []Node, locale string) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		if n.Kind == NodePause {
+			fmt.Fprintf(&b, `<break time="%.1fs" />`, n.Pause.Seconds())
+			continue
+		}
+		b.WriteString(renderPlain([]Node{n}, locale))
+	}
+	return b.String()
+}
+
+// renderPlain 給只接受純文字的 provider：停頓改用標點、sub 改唸別名、逐字讀改為以空白隔開
+func renderPlain(nodes []Node, locale string) string {
+	cjk := strings.HasPrefix(locale, "zh") || strings.HasPrefix(locale, "ja")
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case NodePause:
+			switch {
+			case n.Pause < 400*time.Millisecond && cjk:
+				b.WriteString("，")
+			case n.Pause < 400*time.Millisecond:
+				b.WriteString(", ")
+			case cjk:
+				b.WriteString("。")
+			default:
+				b.WriteString(". ")
+			}
+		case NodeSub:
+			b.WriteString(n.Value)
+		case NodePhoneme:
+			if n.Alias != "" {
+				b.WriteString(n.Alias)
+			} else {
+				b.WriteString(n.Text)
+			}
+		case NodeSayAs:
+			if n.Value == "characters" || n.Value == "digits" {
+				b.WriteString(strings.Join(strings.Split(n.Text, ""), " "))
+			} else {
+				b.WriteString(n.Text)
+			}
+		default:
+			b.WriteString(n.Text)
+		}
+	}
+	return b.String()
+}
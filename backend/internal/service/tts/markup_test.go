@@ -0,0 +1,115 @@
+package tts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderSSML(t *testing.T) {
+	cases := []struct {
+		in, azure, espeak, plain string
+	}{
+		{"A&B <tag>", "A&amp;B &lt;tag&gt;", "A&amp;B &lt;tag&gt;", "A&B <tag>"},
+		{"等一下[pause=1.5s]好", `等一下<break time="1500ms"/>好`, `等一下<break time="1500ms"/>好`, "等一下。好"},
+		{"[emphasis]重點[/emphasis]", `<emphasis level="moderate">重點</emphasis>`, `<emphasis level="moderate">重點</emphasis>`, "重點"},
+		{"[say-as=characters]API[/say-as]", `<say-as interpret-as="characters">API</say-as>`, `<say-as interpret-as="characters">API</say-as>`, "A P I"},
+		{"[say-as=date]2024/5/1[/say-as]", `<say-as interpret-as="date">2024/5/1</say-as>`, "2024/5/1", "2024/5/1"},
+		{"[phoneme=ipa:ˈdʒiːoʊ|吉歐]GEO[/phoneme]", `<phoneme alphabet="ipa" ph="ˈdʒiːoʊ">GEO</phoneme>`, `<sub alias="吉歐">GEO</sub>`, "吉歐"},
+		{"[sub=世界衛生組織]WHO[/sub]", `<sub alias="世界衛生組織">WHO</sub>`, `<sub alias="世界衛生組織">WHO</sub>`, "世界衛生組織"},
+		{"[[注意] [unknown] [/sub]", "[注意] [unknown] ", "[注意] [unknown] ", "[注意] [unknown] "},
+		{"[emphasis]沒有結尾", `<emphasis level="moderate">沒有結尾</emphasis>`, `<emphasis level="moderate">沒有結尾</emphasis>`, "沒有結尾"},
+	}
+	for _, c := range cases {
+		nodes := ParseMarkup(c.in)
+		if got := renderSSML(nodes, azureCaps); got != c.azure {
+			t.Errorf("azure %q: got %q, want %q", c.in, got, c.azure)
+		}
+		if got := renderSSML(nodes, espeakCaps); got != c.espeak {
+			t.Errorf("espeak %q: got %q, want %q", c.in, got, c.espeak)
+		}
+		if got := renderPlain(nodes, "zh-TW"); got != c.plain {
+			t.Errorf("plain %q: got %q, want %q", c.in, got, c.plain)
+		}
+	}
+}
+
+func TestStripAndEscapeMarkup(t *testing.T) {
+	in := "看[pause]這個[sub=世界衛生組織]WHO[/sub]報告"
+	if got := StripMarkup(in); got != "看這個WHO報告" {
+		t.Errorf("StripMarkup = %q", got)
+	}
+	// 未開啟標記時，文字中的方括號經轉義後原樣保留
+	raw := "[pause=1s] 不是停頓"
+	if got := StripMarkup(EscapeMarkup(raw)); got != raw {
+		t.Errorf("EscapeMarkup 後應保留原文，得到 %q", got)
+	}
+	// 區段內的文字保持不變
+	if got := MapText("Read[pause]THE[sub=世界衛生組織]WHO[/sub]Report", strings.ToLower); got != "read[pause=500ms]the[sub=世界衛生組織]WHO[/sub]report" {
+		t.Errorf("MapText = %q", got)
+	}
+}
+
+func TestProtectMarkup(t *testing.T) {
+	script, tags := ProtectMarkup("第一句[pause=300ms]，[emphasis]第二句[/emphasis]。")
+	if strings.Contains(script, "[") || len(tags) != 3 {
+		t.Fatalf("標記應換成佔位字元: %q %v", script, tags)
+	}
+	parts := strings.SplitAfter(script, "，")
+	lines, ok := RestoreMarkup(parts, tags)
+	if !ok {
+		t.Fatal("還原失敗")
+	}
+	want := []string{"第一句[pause=300ms]，", "[emphasis=moderate]第二句[/emphasis]。"}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+	// 佔位字元遺失時回報失敗
+	if _, ok := RestoreMarkup([]string{"第一句，第二句。"}, tags); ok {
+		t.Error("遺失標記應回傳 false")
+	}
+}
+
+func TestPauseMarkup(t *testing.T) {
+	got := PauseMarkup("陣列 a[0] 很重要[pause=1s]接著[emphasis]說明[/emphasis]")
+	want := "陣列 a[[0] 很重要[pause=1000ms]接著[[emphasis]說明[[/emphasis]"
+	if got != want {
+		t.Errorf("PauseMarkup =\n%q\nwant\n%q", got, want)
+	}
+	if plain := PlainPauses(got); plain != "陣列 a[0] 很重要[pause=1000ms]接著[emphasis]說明[/emphasis]" {
+		t.Errorf("PlainPauses = %q", plain)
+	}
+
+	lead, trail, rest := TrimPauses("[pause=300ms] 開頭[pause=200ms]中間[pause=1s][pause=500ms]")
+	if lead != 300*time.Millisecond || trail != 1500*time.Millisecond || rest != "開頭[pause=200ms]中間" {
+		t.Errorf("TrimPauses = %v, %v, %q", lead, trail, rest)
+	}
+	if _, _, rest := TrimPauses("a[[b"); rest != "a[[b" {
+		t.Errorf("沒有停頓時應原樣回傳，得到 %q", rest)
+	}
+}
+
+func TestLexiconApply(t *testing.T) {
+	lex := &Lexicon{Entries: []LexiconEntry{
+		{Term: "AI", SayAs: "characters"},
+		{Term: "GPT-4o", Alias: "GPT four oh"},
+		{Term: "Nginx", Phoneme: "ˈɛndʒɪnˈɛks", Alias: "engine x"},
+		{Term: "台積電", Alias: "台積電公司", CaseSensitive: true},
+	}}
+	if err := lex.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got := lex.Apply("ai 與 SAID 不同，GPT-4o 跑在 nginx 上[pause]台積電[sub=x]AI[/sub]")
+	want := "[say-as=characters]ai[/say-as] 與 SAID 不同，[sub=GPT four oh]GPT-4o[/sub] 跑在 " +
+		"[phoneme=ipa:ˈɛndʒɪnˈɛks|engine x]nginx[/phoneme] 上[pause=500ms][sub=台積電公司]台積電[/sub][sub=x]AI[/sub]"
+	if got != want {
+		t.Errorf("Apply =\n%q\nwant\n%q", got, want)
+	}
+
+	bad := &Lexicon{Entries: []LexiconEntry{{Term: "X"}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("沒有 alias、phoneme、say_as 應驗證失敗")
+	}
+}
@@ -0,0 +1,80 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// openAIVoices OpenAI 內建語音 (多語，依文字自動判斷語言)
+var openAIVoices = []string{"alloy", "ash", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer"}
+
+// OpenAIProvider 實作 OpenAI 相容的 /audio/speech API，
+// 可指向 OpenAI 或其他相容服務 (自架伺服器可不需金鑰)
+type OpenAIProvider struct {
+	Key     string
+	BaseURL string   // 預設 openAIBaseURL
+	Model   string   // 預設 tts-1
+	Voices  []string // 相容服務的語音清單，未設定使用 OpenAI 內建語音
+}
+
+func (o *OpenAIProvider) base() string {
+	if o.BaseURL != "" {
+		return strings.TrimRight(o.BaseURL, "/")
+	}
+	return openAIBaseURL
+}
+
+func (o *OpenAIProvider) voices() []string {
+	if len(o.Voices) > 0 {
+		return o.Voices
+	}
+	return openAIVoices
+}
+
+func (o *OpenAIProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	if o.Key == "" && o.base() == openAIBaseURL {
+		return "", 0, fmt.Errorf("OpenAI TTS key is missing")
+	}
+	if voice == "" {
+		voice = o.voices()[0]
+	}
+	model := o.Model
+	if model == "" {
+		model = "tts-1"
+	}
+	// 只接受純文字；語速範圍 0.25 ~ 4，不支援音高
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":           model,
+		"input":           renderPlain(ParseMarkup(text), locale),
+		"voice":           voice,
+		"response_format": "wav",
+		"speed":           min(max(speed, 0.25), 4),
+	})
+	req, _ := http.NewRequest(http.MethodPost, o.base()+"/audio/speech", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if o.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+o.Key)
+	}
+	audio, err := doRequest("openai", req)
+	if err != nil {
+		return "", 0, err
+	}
+	return saveAudio("openai", ".wav", audio)
+}
+
+// ListVoices 相容 API 沒有列出語音的端點，回傳設定的語音清單
+func (o *OpenAIProvider) ListVoices() ([]Voice, error) {
+	var voices []Voice
+	for _, name := range o.voices() {
+		voices = append(voices, Voice{
+			Name:        name,
+			DisplayName: fmt.Sprintf("%s (multilingual)", name),
+		})
+	}
+	return voices, nil
+}
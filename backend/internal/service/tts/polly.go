@@ -0,0 +1,119 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pollyDefaultVoices 未指定語音時依語系挑選的 Polly 語音
+var pollyDefaultVoices = map[string]string{
+	"en-US": "Joanna",
+	"en-GB": "Amy",
+	"zh-CN": "Zhiyu",
+	"zh-TW": "Zhiyu",
+	"ja-JP": "Takumi",
+	"ko-KR": "Seoyeon",
+	"es-ES": "Lucia",
+	"fr-FR": "Lea",
+	"de-DE": "Vicki",
+}
+
+// PollyProvider 實作 Amazon Polly REST API，以 SigV4 簽署請求
+type PollyProvider struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	Engine       string // neural (預設)、standard、generative
+	Endpoint     string // 預設 https://polly.{region}.amazonaws.com，測試時指向替身伺服器
+
+	now func() time.Time // 簽章時間，測試時固定
+}
+
+func (p *PollyProvider) endpoint() string {
+	if p.Endpoint != "" {
+		return strings.TrimRight(p.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://polly.%s.amazonaws.com", p.Region)
+}
+
+func (p *PollyProvider) engine() string {
+	if p.Engine == "" {
+		return "neural"
+	}
+	return p.Engine
+}
+
+// do 簽署並送出請求
+func (p *PollyProvider) do(method, path string, body []byte) ([]byte, error) {
+	if p.AccessKey == "" || p.SecretKey == "" || p.Region == "" {
+		return nil, fmt.Errorf("Polly credentials or region is missing")
+	}
+	req, _ := http.NewRequest(method, p.endpoint()+path, bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+	signV4(req, body, awsCredentials{AccessKey: p.AccessKey, SecretKey: p.SecretKey, SessionToken: p.SessionToken}, p.Region, "polly", now())
+	return doRequest("polly", req)
+}
+
+func (p *PollyProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	if voice == "" {
+		if voice = pollyDefaultVoices[locale]; voice == "" {
+			voice = "Joanna"
+		}
+	}
+	// neural 語音只支援 prosody rate，不支援 pitch
+	ssml := fmt.Sprintf(`<speak><prosody rate="%.0f%%">%s</prosody></speak>`, speed*100, renderSSML(ParseMarkup(text), pollyCaps))
+	body, _ := json.Marshal(map[string]string{
+		"Engine":       p.engine(),
+		"OutputFormat": "mp3",
+		"SampleRate":   "24000",
+		"Text":         ssml,
+		"TextType":     "ssml",
+		"VoiceId":      voice,
+	})
+	audio, err := p.do(http.MethodPost, "/v1/speech", body)
+	if err != nil {
+		return "", 0, err
+	}
+	return saveAudio("polly", ".mp3", audio)
+}
+
+func (p *PollyProvider) ListVoices() ([]Voice, error) {
+	body, err := p.do(http.MethodGet, "/v1/voices?Engine="+p.engine(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Voices []struct {
+			Gender       string `json:"Gender"`
+			ID           string `json:"Id"`
+			LanguageCode string `json:"LanguageCode"`
+			LanguageName string `json:"LanguageName"`
+			Name         string `json:"Name"`
+		} `json:"Voices"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	var voices []Voice
+	for _, v := range raw.Voices {
+		locale := standardLocale(v.LanguageCode)
+		voices = append(voices, Voice{
+			Name:        v.ID,
+			DisplayName: fmt.Sprintf("%s (%s, %s)", v.Name, locale, v.Gender),
+			Locale:      locale,
+			Gender:      v.Gender,
+		})
+	}
+	return voices, nil
+}
@@ -2,6 +2,7 @@ package tts
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Reggie-pan/go-shorts-generator/internal/config"
 )
@@ -13,6 +14,7 @@ type Voice struct {
 	Gender      string `json:"gender"`
 }
 
+// Provider 語音合成服務。text 可含行內標記 (見 ParseMarkup)，由各 provider 依能力轉譯
 type Provider interface {
 	Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error)
 	ListVoices() ([]Voice, error)
@@ -22,9 +24,58 @@ func GetProvider(name string, cfg *config.Config) (Provider, error) {
 	switch name {
 	case "azure_v1", "azure_v2":
 		return &AzureProvider{Key: cfg.AzureKey, Region: cfg.AzureRegion}, nil
+	case "google":
+		return &GoogleProvider{Key: cfg.GoogleTTSKey}, nil
+	case "polly":
+		return &PollyProvider{
+			AccessKey:    cfg.PollyAccessKey,
+			SecretKey:    cfg.PollySecretKey,
+			SessionToken: cfg.PollySessionToken,
+			Region:       cfg.PollyRegion,
+			Engine:       cfg.PollyEngine,
+		}, nil
+	case "elevenlabs":
+		return &ElevenLabsProvider{Key: cfg.ElevenLabsKey, Model: cfg.ElevenLabsModel}, nil
+	case "openai":
+		return &OpenAIProvider{Key: cfg.OpenAITTSKey, BaseURL: cfg.OpenAITTSBaseURL, Model: cfg.OpenAITTSModel, Voices: cfg.OpenAITTSVoices}, nil
 	case "edge_tts":
 		return &EdgeTTSProvider{}, nil
+	case "local":
+		return &LocalProvider{}, nil
 	default:
 		return nil, fmt.Errorf("未知的 TTS provider: %s", name)
 	}
 }
+
+// cloudLocales 雲端服務使用的中文語言代碼與一般語系的對應
+var cloudLocales = map[string]string{
+	"zh-TW": "cmn-TW",
+	"zh-CN": "cmn-CN",
+	"zh-HK": "yue-HK",
+}
+
+// cloudLanguage 將語系換成雲端服務的語言代碼，例如 zh-TW → cmn-TW
+func cloudLanguage(locale string) string {
+	if code, ok := cloudLocales[locale]; ok {
+		return code
+	}
+	return locale
+}
+
+// standardLocale 將雲端服務的語言代碼換回一般語系，讓語音目錄可用同一套語系篩選
+func standardLocale(code string) string {
+	for locale, c := range cloudLocales {
+		if strings.EqualFold(c, code) {
+			return locale
+		}
+	}
+	return code
+}
+
+// titleCase 將 FEMALE、female 等統一為 Female
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
+}
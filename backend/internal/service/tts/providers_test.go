@@ -0,0 +1,226 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorded 替身伺服器收到的請求
+type recorded struct {
+	method, path, query string
+	header              http.Header
+	body                []byte
+}
+
+// replayServer 依 "METHOD /path" 回放 testdata 中錄好的回應，並記錄收到的請求
+func replayServer(t *testing.T, routes map[string]string) (*httptest.Server, *[]recorded) {
+	t.Helper()
+	var reqs []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, recorded{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery, header: r.Header.Clone(), body: body})
+		file, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.Error(w, `{"error":"not recorded"}`, http.StatusNotFound)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Errorf("讀取錄製回應失敗: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+// assertAudio 確認輸出檔案內容與回放的音訊相同
+func assertAudio(t *testing.T, path string) {
+	t.Helper()
+	defer os.Remove(path)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(filepath.Join("testdata", "sample.wav"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("音訊內容不符 (%d bytes, want %d)", len(got), len(want))
+	}
+}
+
+func decodeBody(t *testing.T, b []byte) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("請求內容不是 JSON: %v\n%s", err, b)
+	}
+	return m
+}
+
+func TestGoogleProvider(t *testing.T) {
+	srv, reqs := replayServer(t, map[string]string{
+		"POST /v1/text:synthesize": "google_synthesize.json",
+		"GET /v1/voices":           "google_voices.json",
+	})
+	g := &GoogleProvider{Key: "k&1", BaseURL: srv.URL + "/v1"}
+
+	path, _, err := g.Synthesize("A&B[pause=300ms]", "", "zh-TW", 1.2, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAudio(t, path)
+	req := (*reqs)[0]
+	if req.query != "key=k%261" {
+		t.Errorf("API key 應以 query 傳遞並編碼，得到 %s", req.query)
+	}
+	body := decodeBody(t, req.body)
+	if ssml := body["input"].(map[string]interface{})["ssml"]; ssml != `<speak>A&amp;B<break time="300ms"/></speak>` {
+		t.Errorf("ssml = %v", ssml)
+	}
+	if lang := body["voice"].(map[string]interface{})["languageCode"]; lang != "cmn-TW" {
+		t.Errorf("languageCode = %v", lang)
+	}
+	if pitch := body["audioConfig"].(map[string]interface{})["pitch"]; pitch != 6.0 {
+		t.Errorf("pitch = %v", pitch)
+	}
+
+	voices, err := g.ListVoices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 3 || voices[1].Locale != "zh-TW" || voices[1].Gender != "Male" {
+		t.Errorf("語音清單解析錯誤: %+v", voices)
+	}
+}
+
+func TestPollyProvider(t *testing.T) {
+	srv, reqs := replayServer(t, map[string]string{
+		"POST /v1/speech": "sample.wav",
+		"GET /v1/voices":  "polly_voices.json",
+	})
+	p := &PollyProvider{
+		AccessKey: "AKID", SecretKey: "secret", SessionToken: "token", Region: "ap-northeast-1", Endpoint: srv.URL,
+		now: func() time.Time { return time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC) },
+	}
+
+	path, _, err := p.Synthesize("[emphasis]重點[/emphasis]<1>", "", "zh-TW", 1.1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAudio(t, path)
+	req := (*reqs)[0]
+	auth := req.header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240501/ap-northeast-1/polly/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature=") {
+		t.Errorf("Authorization = %s", auth)
+	}
+	if req.header.Get("X-Amz-Security-Token") != "token" {
+		t.Error("缺少 session token")
+	}
+	body := decodeBody(t, req.body)
+	// neural 不支援 emphasis，改為純文字
+	if body["Text"] != `<speak><prosody rate="110%">重點&lt;1&gt;</prosody></speak>` || body["VoiceId"] != "Zhiyu" || body["Engine"] != "neural" {
+		t.Errorf("請求內容 = %v", body)
+	}
+
+	voices, err := p.ListVoices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*reqs)[1].query != "Engine=neural" {
+		t.Errorf("query = %s", (*reqs)[1].query)
+	}
+	if len(voices) != 2 || voices[0].Name != "Zhiyu" || voices[0].Locale != "zh-CN" {
+		t.Errorf("語音清單解析錯誤: %+v", voices)
+	}
+}
+
+func TestElevenLabsProvider(t *testing.T) {
+	srv, reqs := replayServer(t, map[string]string{
+		"POST /v1/text-to-speech/pNInz6obpgDQGcFmaJgB": "sample.wav",
+		"GET /v1/voices": "elevenlabs_voices.json",
+	})
+	e := &ElevenLabsProvider{Key: "xi", BaseURL: srv.URL}
+
+	path, _, err := e.Synthesize("Hi[pause=1.5s][sub=W H O]WHO[/sub]", "pNInz6obpgDQGcFmaJgB", "en-US", 1.5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAudio(t, path)
+	req := (*reqs)[0]
+	if req.header.Get("xi-api-key") != "xi" {
+		t.Error("缺少 xi-api-key")
+	}
+	body := decodeBody(t, req.body)
+	if body["text"] != `Hi<break time="1.5s" />W H O` || body["model_id"] != "eleven_multilingual_v2" {
+		t.Errorf("請求內容 = %v", body)
+	}
+	if speed := body["voice_settings"].(map[string]interface{})["speed"]; speed != 1.2 {
+		t.Errorf("語速應限制在 1.2，得到 %v", speed)
+	}
+
+	voices, err := e.ListVoices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 2 || voices[0].Gender != "Female" || voices[1].Locale != "en" {
+		t.Errorf("語音清單解析錯誤: %+v", voices)
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	srv, reqs := replayServer(t, map[string]string{"POST /v1/audio/speech": "sample.wav"})
+	// 自架的相容服務可不需金鑰
+	o := &OpenAIProvider{BaseURL: srv.URL + "/v1/", Voices: []string{"narrator"}}
+
+	path, _, err := o.Synthesize("你好[pause=200ms]世界", "", "zh-TW", 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAudio(t, path)
+	req := (*reqs)[0]
+	if req.header.Get("Authorization") != "" {
+		t.Error("未設定金鑰時不應送出 Authorization")
+	}
+	body := decodeBody(t, req.body)
+	if body["input"] != "你好，世界" || body["voice"] != "narrator" || body["model"] != "tts-1" || body["response_format"] != "wav" {
+		t.Errorf("請求內容 = %v", body)
+	}
+
+	// 錯誤狀態碼轉為 StatusError，讓重試機制判斷
+	o.BaseURL = srv.URL + "/missing"
+	if _, _, err := o.Synthesize("x", "", "en-US", 1, 0); err == nil || IsTransient(err) {
+		t.Errorf("404 應為非暫時性錯誤，得到 %v", err)
+	}
+}
+
+func TestEdgeTextEscapedOnce(t *testing.T) {
+	text := edgeText("A&B", "en-US")
+	// 與 lib-x/edgetts 的 makeSsml 相同：文字放在 prosody 的 chardata，由 xml.MarshalIndent 轉義
+	type prosody struct {
+		Rate string `xml:"rate,attr"`
+		Text string `xml:",chardata"`
+	}
+	type speak struct {
+		XMLName xml.Name `xml:"speak"`
+		Prosody prosody  `xml:"voice>prosody"`
+	}
+	out, err := xml.MarshalIndent(speak{Prosody: prosody{Rate: "+0%", Text: text}}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssml := string(out)
+	if n := strings.Count(ssml, "&amp;"); n != 1 || strings.Contains(ssml, "&amp;amp;") {
+		t.Errorf("& 應只轉義一次: %s", ssml)
+	}
+}
@@ -0,0 +1,171 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StatusError provider 回傳非成功的 HTTP 狀態
+type StatusError struct {
+	Provider string
+	Code     int
+	Body     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s tts 錯誤 (HTTP %d): %s", e.Provider, e.Code, e.Body)
+}
+
+// IsTransient 判斷錯誤是否值得重試：限流 (429)、伺服器錯誤 (5xx)、逾時與連線中斷
+// 金鑰錯誤、參數錯誤等重試也不會成功，直接交給下一個 provider
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == 429 || se.Code == 408 || se.Code >= 500
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	// 第三方套件 (例如 edge-tts 的 websocket) 的錯誤沒有型別，只能比對訊息
+	msg := strings.ToLower(err.Error())
+	for _, k := range []string{"timeout", "connection reset", "broken pipe", "unexpected eof", "too many requests", "websocket: close"} {
+		if strings.Contains(msg, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenBucket 令牌桶限流：每秒補充 rate 個令牌，最多累積 burst 個
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait 取得一個令牌，不足時等待
+func (b *tokenBucket) Wait() {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// limiters 各 provider 共用的限流器，跨任務生效
+var limiters = struct {
+	sync.Mutex
+	m map[string]*tokenBucket
+}{m: map[string]*tokenBucket{}}
+
+// limiterFor 取得 provider 的限流器，rate <= 0 表示不限流
+func limiterFor(name string, rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	limiters.Lock()
+	defer limiters.Unlock()
+	b, ok := limiters.m[name]
+	if !ok || b.rate != rate {
+		b = newTokenBucket(rate, max(1, int(rate)))
+		limiters.m[name] = b
+	}
+	return b
+}
+
+// slots 各 provider 共用的並行上限，跨任務生效
+var slots = struct {
+	sync.Mutex
+	m map[string]chan struct{}
+}{m: map[string]chan struct{}{}}
+
+// slotsFor 取得 provider 的並行槽位
+func slotsFor(name string, n int) chan struct{} {
+	slots.Lock()
+	defer slots.Unlock()
+	ch, ok := slots.m[name]
+	if !ok || cap(ch) != n {
+		ch = make(chan struct{}, n)
+		slots.m[name] = ch
+	}
+	return ch
+}
+
+// RetryPolicy 指數退避重試設定
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// backoff 第 attempt 次重試前的等待時間 (指數成長，加上一半的隨機抖動)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// resilientProvider 在 provider 外加上並行上限、限流與暫時性錯誤重試
+type resilientProvider struct {
+	Provider
+	name    string
+	slots   chan struct{} // nil 表示不限制並行數
+	limiter *tokenBucket
+	retry   RetryPolicy
+}
+
+func (r *resilientProvider) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	if r.slots != nil {
+		r.slots <- struct{}{}
+		defer func() { <-r.slots }()
+	}
+	for attempt := 0; ; attempt++ {
+		if r.limiter != nil {
+			r.limiter.Wait()
+		}
+		path, dur, err := r.Provider.Synthesize(text, voice, locale, speed, pitch)
+		if err == nil {
+			return path, dur, nil
+		}
+		if !IsTransient(err) || attempt >= r.retry.MaxRetries {
+			return "", 0, err
+		}
+		delay := r.retry.backoff(attempt)
+		log.Warn().Err(err).Str("provider", r.name).Int("retry", attempt+1).Dur("delay", delay).Msg("TTS 暫時性錯誤，稍後重試")
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// lexiconPath 團隊發音字典保存在 lexicons/{team}.json
+func (s *Store) lexiconPath(team string) string {
+	return filepath.Join(filepath.Dir(s.path), "lexicons", team+".json")
+}
+
+// GetLexicon 讀取團隊發音字典，不存在時回傳 os.ErrNotExist
+func (s *Store) GetLexicon(team string) (*tts.Lexicon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, err := os.ReadFile(s.lexiconPath(team))
+	if err != nil {
+		return nil, err
+	}
+	var l tts.Lexicon
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// PutLexicon 整份取代團隊發音字典
+func (s *Store) PutLexicon(l *tts.Lexicon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.lexiconPath(l.Team)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, _ := json.MarshalIndent(l, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -56,6 +57,27 @@ func (s *Store) UpdateJob(r *job.Record) error {
 	return s.persist()
 }
 
+// ErrStatusChanged 任務已不在預期的狀態 (例如已被其他請求處理)
+var ErrStatusChanged = errors.New("任務狀態已變更")
+
+// UpdateJobIf 任務狀態為 from 時才以 fn 修改並保存，整個過程持有鎖，作為狀態轉換的 compare-and-set；
+// 狀態不符時回傳 ErrStatusChanged。fn 回傳錯誤時不保存，因此應在確定成功後才修改欄位
+func (s *Store) UpdateJobIf(id string, from job.Status, fn func(r *job.Record) error) (*job.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.data[id]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	if r.Status != from {
+		return r, ErrStatusChanged
+	}
+	if err := fn(r); err != nil {
+		return r, err
+	}
+	return r, s.persist()
+}
+
 func (s *Store) GetJob(id string) (*job.Record, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 時間戳支援 hh:mm:ss,mmm (SRT) 與 [hh:]mm:ss.mmm (VTT)
+	cueTimingRe = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+	// 移除 <i>、<b>、<v 講者>、<00:00:01.000> 等標籤與 ASS 覆寫碼 {\an8}
+	cueTagRe = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+)
+
+// ParseCaptions 解析 SRT 或 WebVTT 字幕，依內容自動判斷格式
+func ParseCaptions(data string) ([]SubtitleSegment, error) {
+	data = strings.TrimPrefix(data, "\uFEFF")
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+	if strings.HasPrefix(strings.TrimSpace(data), "WEBVTT") {
+		return parseCues(data, true)
+	}
+	return parseCues(data, false)
+}
+
+// parseCues 以空行切分區塊，找出含 "-->" 的時間行，其後至區塊結束為字幕文字
+func parseCues(data string, vtt bool) ([]SubtitleSegment, error) {
+	var segments []SubtitleSegment
+	blocks := strings.Split(data, "\n\n")
+	for _, block := range blocks {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		if len(lines) == 0 {
+			continue
+		}
+		if vtt {
+			head := strings.TrimSpace(lines[0])
+			// 跳過檔頭與 NOTE/STYLE/REGION 區塊
+			if strings.HasPrefix(head, "WEBVTT") || strings.HasPrefix(head, "NOTE") || head == "STYLE" || head == "REGION" {
+				continue
+			}
+		}
+
+		timingIdx := -1
+		for i, l := range lines {
+			if strings.Contains(l, "-->") {
+				timingIdx = i
+				break
+			}
+		}
+		if timingIdx < 0 {
+			continue
+		}
+		m := cueTimingRe.FindStringSubmatch(lines[timingIdx])
+		if m == nil {
+			return nil, fmt.Errorf("無法解析字幕時間: %s", strings.TrimSpace(lines[timingIdx]))
+		}
+		start, err := parseCueTime(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseCueTime(m[2])
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("字幕結束時間需晚於開始時間: %s", strings.TrimSpace(lines[timingIdx]))
+		}
+
+		var text []string
+		for _, l := range lines[timingIdx+1:] {
+			l = strings.TrimSpace(cueTagRe.ReplaceAllString(l, ""))
+			if l != "" {
+				text = append(text, l)
+			}
+		}
+		if len(text) == 0 {
+			continue
+		}
+		segments = append(segments, SubtitleSegment{
+			Text:  strings.Join(text, "\n"),
+			Start: start,
+			End:   end,
+		})
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("字幕檔沒有任何字幕")
+	}
+	return segments, nil
+}
+
+// parseCueTime 將 hh:mm:ss,mmm / mm:ss.mmm 轉為毫秒
+func parseCueTime(s string) (int, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	main, frac, _ := strings.Cut(s, ".")
+	parts := strings.Split(main, ":")
+	total := 0
+	for _, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("無法解析字幕時間: %s", s)
+		}
+		total = total*60 + v
+	}
+	// 毫秒不足三位數時補零 (例如 .5 = 500ms)
+	for len(frac) < 3 {
+		frac += "0"
+	}
+	ms, err := strconv.Atoi(frac[:3])
+	if err != nil {
+		return 0, fmt.Errorf("無法解析字幕時間: %s", s)
+	}
+	return total*1000 + ms, nil
+}
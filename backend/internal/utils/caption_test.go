@@ -0,0 +1,56 @@
+package utils
+
+import "testing"
+
+func TestParseCaptionsSRT(t *testing.T) {
+	srt := "\uFEFF1\r\n00:00:01,000 --> 00:00:02,500\r\n第一句\r\n\r\n2\r\n00:00:03,000 --> 00:00:05,040\r\n<i>second</i>\r\nline\r\n"
+	segs, err := ParseCaptions(srt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 {
+		t.Fatalf("應有 2 句，得到 %d: %+v", len(segs), segs)
+	}
+	if segs[0].Text != "第一句" || segs[0].Start != 1000 || segs[0].End != 2500 {
+		t.Errorf("第一句錯誤 %+v", segs[0])
+	}
+	if segs[1].Text != "second\nline" || segs[1].Start != 3000 || segs[1].End != 5040 {
+		t.Errorf("第二句錯誤 %+v", segs[1])
+	}
+}
+
+func TestParseCaptionsVTT(t *testing.T) {
+	vtt := `WEBVTT - demo
+
+NOTE 這是註解
+
+intro
+00:01.5 --> 00:03.000 align:start position:10%
+<v Host>Hello there
+
+01:00:00.000 --> 01:00:01.250
+{\an8}最後一句
+`
+	segs, err := ParseCaptions(vtt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 {
+		t.Fatalf("應有 2 句，得到 %d: %+v", len(segs), segs)
+	}
+	if segs[0].Text != "Hello there" || segs[0].Start != 1500 || segs[0].End != 3000 {
+		t.Errorf("第一句錯誤 %+v", segs[0])
+	}
+	if segs[1].Text != "最後一句" || segs[1].Start != 3600000 || segs[1].End != 3601250 {
+		t.Errorf("第二句錯誤 %+v", segs[1])
+	}
+}
+
+func TestParseCaptionsInvalid(t *testing.T) {
+	if _, err := ParseCaptions("hello world"); err == nil {
+		t.Error("沒有字幕時應回傳錯誤")
+	}
+	if _, err := ParseCaptions("1\n00:00:02,000 --> 00:00:01,000\nbad\n"); err == nil {
+		t.Error("結束早於開始應回傳錯誤")
+	}
+}
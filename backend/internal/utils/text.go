@@ -3,6 +3,7 @@ package utils
 import (
 	"regexp"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -18,6 +19,8 @@ func AutoSpacing(s string) string {
 }
 
 // SplitScript 簡單斷句，如果太長以字數分段
+//
+// Deprecated: 只以字數計算且只認得中文標點，改用 segment.Segmenter.Split
 func SplitScript(script string, maxLen int) []string {
 	clean := strings.TrimSpace(script)
 	if clean == "" {
@@ -140,27 +143,231 @@ func BuildTimeline(lines []string, durations []int) []SubtitleSegment {
 
 // BuildTimelineFloat 依 TTS 時長 (秒) 與字幕行，計算時間軸 (ms)，使用 float64 減少累積誤差
 func BuildTimelineFloat(lines []string, durations []float64) []SubtitleSegment {
+	return BuildTimelinePaced(lines, durations, nil, 0)
+}
+
+// BuildTimelinePaced 依各句語音長度與句後停頓計算時間軸 (ms)。
+// 字幕在停頓中最多再停留 hold 秒，停頓更長時先清空畫面，下一句開始時再出現
+func BuildTimelinePaced(lines []string, speech, gaps []float64, hold float64) []SubtitleSegment {
 	segments := []SubtitleSegment{}
 	var cursor float64
 	for i, line := range lines {
-		dur := 0.0
-		if i < len(durations) {
-			dur = durations[i]
+		dur, gap := 0.0, 0.0
+		if i < len(speech) {
+			dur = speech[i]
 		}
-		if dur == 0 {
+		if i < len(gaps) {
+			gap = gaps[i]
+		}
+		if dur == 0 && gap == 0 {
 			dur = 1.0
 		}
+		show := dur + gap
+		if gaps != nil && gap > hold {
+			show = dur + hold
+		}
 
-		startMs := int(cursor * 1000)
-		endMs := int((cursor + dur) * 1000)
-
-		seg := SubtitleSegment{
+		segments = append(segments, SubtitleSegment{
 			Text:  line,
-			Start: startMs,
-			End:   endMs,
-		}
-		segments = append(segments, seg)
-		cursor += dur
+			Start: int(cursor * 1000),
+			End:   int((cursor + show) * 1000),
+		})
+		cursor += dur + gap
 	}
 	return segments
 }
+
+// Boundary 句子結尾在原腳本中的斷點類型，決定句後停頓長度
+type Boundary int
+
+const (
+	BoundaryNone      Boundary = iota // 句子過長被強制切開，或找不到對應位置
+	BoundaryComma                     // 逗號、頓號、分號、冒號
+	BoundarySentence                  // 句號、問號、驚嘆號、刪節號或換行
+	BoundaryParagraph                 // 空行分段
+)
+
+// LineBoundaries 將斷句結果依序對回原腳本，判斷每句結尾的標點類型 (斷句會移除標點)。
+// 只比對文字與數字，AI 斷句小幅改寫時仍能對齊；最後一句視為句尾
+func LineBoundaries(script string, lines []string) []Boundary {
+	src := []rune(script)
+	pos := 0
+	out := make([]Boundary, len(lines))
+	for i, line := range lines {
+		matched := false
+		for _, r := range line {
+			if !isWordRune(r) {
+				continue
+			}
+			// 只往後找一小段，避免改寫過的字把位置帶到很後面
+			for j := pos; j < len(src) && j < pos+16; j++ {
+				if unicode.ToLower(src[j]) == unicode.ToLower(r) {
+					pos, matched = j+1, true
+					break
+				}
+			}
+		}
+		if !matched {
+			out[i] = BoundaryNone
+			continue
+		}
+		out[i] = boundaryAt(src, pos)
+	}
+	if n := len(out); n > 0 && out[n-1] < BoundarySentence {
+		out[n-1] = BoundarySentence
+	}
+	return out
+}
+
+// boundaryAt 檢查 pos 之後到下一個文字之前的標點與換行
+func boundaryAt(src []rune, pos int) Boundary {
+	b := BoundaryNone
+	newlines := 0
+	for _, r := range src[pos:] {
+		if isWordRune(r) {
+			break
+		}
+		switch {
+		case r == '\n':
+			newlines++
+		case strings.ContainsRune("。！？.!?…", r):
+			b = max(b, BoundarySentence)
+		case strings.ContainsRune("，、；：,;:", r):
+			b = max(b, BoundaryComma)
+		}
+	}
+	switch {
+	case newlines >= 2:
+		return BoundaryParagraph
+	case newlines == 1:
+		return max(b, BoundarySentence)
+	}
+	return b
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// SpeechUnits 計算語速用的字數：中日韓文字每字一單位，其他語言每個單字一單位
+func SpeechUnits(s string) int {
+	n := 0
+	inWord := false
+	for _, r := range s {
+		switch {
+		case isCJKRune(r):
+			n++
+			inWord = false
+		case isWordRune(r):
+			if !inWord {
+				n++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+// SplitSentences 依句尾標點與換行切分句子，標點保留在句尾
+func SplitSentences(text string) []string {
+	var out []string
+	var cur []rune
+	runes := []rune(text)
+	flush := func() {
+		if s := strings.TrimSpace(string(cur)); s != "" {
+			out = append(out, s)
+		}
+		cur = cur[:0]
+	}
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		cur = append(cur, r)
+		switch {
+		case strings.ContainsRune("。！？!?…", r):
+			// 連續的句尾標點與右引號一起保留
+			if i+1 < len(runes) && strings.ContainsRune("。！？!?…」』”\"'）)", runes[i+1]) {
+				continue
+			}
+			flush()
+		case r == '.':
+			// 小數點與縮寫後接非空白，不視為句尾
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) {
+				flush()
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// SplitClauses 依逗號、頓號、分號、冒號切分子句，標點保留在子句尾
+func SplitClauses(sentence string) []string {
+	var out []string
+	start := 0
+	for i, r := range sentence {
+		if strings.ContainsRune("，、；：,;:", r) {
+			end := i + utf8.RuneLen(r)
+			if s := strings.TrimSpace(sentence[start:end]); s != "" {
+				out = append(out, s)
+			}
+			start = end
+		}
+	}
+	if s := strings.TrimSpace(sentence[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// SplitProportional 將一句文字依 weights 的比例切成多段 (例如依原文字幕長度分配譯文)；
+// 含空白或不含中日韓文字時以單字為單位切分，否則以字元為單位，文字足夠時每段至少一個單位
+func SplitProportional(text string, weights []int) []string {
+	n := len(weights)
+	out := make([]string, n)
+	if n == 0 {
+		return out
+	}
+	text = strings.TrimSpace(text)
+	var tokens []string
+	sep := ""
+	if strings.ContainsAny(text, " \t") || !strings.ContainsFunc(text, isCJKRune) {
+		tokens, sep = strings.Fields(text), " "
+	} else {
+		for _, r := range text {
+			tokens = append(tokens, string(r))
+		}
+	}
+	var totalWeight, totalLen float64
+	for _, w := range weights {
+		totalWeight += float64(max(w, 1))
+	}
+	for _, t := range tokens {
+		totalLen += float64(utf8.RuneCountInString(t))
+	}
+
+	parts := make([][]string, n)
+	j, cum := 0, 0.0
+	target := totalLen * float64(max(weights[0], 1)) / totalWeight
+	for i, tok := range tokens {
+		l := float64(utf8.RuneCountInString(tok))
+		for j < n-1 && len(parts[j]) > 0 && (cum+l/2 > target || len(tokens)-i <= n-1-j) {
+			j++
+			target += totalLen * float64(max(weights[j], 1)) / totalWeight
+		}
+		parts[j] = append(parts[j], tok)
+		cum += l
+	}
+	for i, p := range parts {
+		out[i] = strings.Join(p, sep)
+	}
+	return out
+}
@@ -83,3 +83,58 @@ func TestSplitScriptSmart(t *testing.T) {
 		t.Errorf("Expected 'This', got '%s'", lines3[0])
 	}
 }
+
+func TestLineBoundaries(t *testing.T) {
+	script := "今天天氣很好，我們去公園。\n\n下午下雨了！\n回家休息吧"
+	lines := []string{"今天天氣很好", "我們去公園", "下午下雨了", "回家", "休息吧"}
+	got := LineBoundaries(script, lines)
+	want := []Boundary{BoundaryComma, BoundaryParagraph, BoundarySentence, BoundaryNone, BoundarySentence}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 句 %q 斷點 = %d，期望 %d", i, lines[i], got[i], want[i])
+		}
+	}
+}
+
+func TestBuildTimelinePaced(t *testing.T) {
+	segs := BuildTimelinePaced([]string{"a", "b", "c"}, []float64{1, 2, 1}, []float64{0.2, 1.5, 0.4}, 0.5)
+	want := [][2]int{{0, 1200}, {1200, 3700}, {4700, 6100}}
+	for i, w := range want {
+		if segs[i].Start != w[0] || segs[i].End != w[1] {
+			t.Errorf("第 %d 段 = %d-%d，期望 %d-%d", i, segs[i].Start, segs[i].End, w[0], w[1])
+		}
+	}
+}
+
+func TestSpeechUnits(t *testing.T) {
+	if n := SpeechUnits("用 AI 工具 make videos, 快 3 倍"); n != 9 {
+		t.Errorf("SpeechUnits = %d，期望 9", n)
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := SplitSentences("今天很好！我們走吧。\nPi is 3.14. Really?! Yes")
+	want := []string{"今天很好！", "我們走吧。", "Pi is 3.14.", "Really?!", "Yes"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SplitSentences = %q", got)
+	}
+}
+
+func TestSplitProportional(t *testing.T) {
+	cases := []struct {
+		text    string
+		weights []int
+		want    []string
+	}{
+		{"Today we talk about video", []int{1, 1}, []string{"Today we talk", "about video"}},
+		{"我們今天去公園", []int{2, 5}, []string{"我們", "今天去公園"}},
+		{"Hi", []int{3, 1}, []string{"Hi", ""}},
+		{"a b", []int{9, 1, 1}, []string{"a", "b", ""}},
+	}
+	for _, c := range cases {
+		got := SplitProportional(c.text, c.weights)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("SplitProportional(%q, %v) = %q，期望 %q", c.text, c.weights, got, c.want)
+		}
+	}
+}
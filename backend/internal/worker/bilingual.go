@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// addSecondary 產生雙語字幕的第二行：有對照稿時依句子對齊，否則以 AI 逐句翻譯；
+// 失敗時只記錄警告，仍輸出單語字幕
+func (w *Worker) addSecondary(rec *job.Record, tl *media.Timeline) {
+	b := rec.Request.Bilingual
+	lines := make([]string, len(tl.Lines))
+	for i, l := range tl.Lines {
+		lines[i] = l.Text
+	}
+
+	var secondary []string
+	var err error
+	if strings.TrimSpace(b.Script) != "" {
+		script := ""
+		if rec.Request.Script != "" {
+			script = tts.StripMarkup(lineMarkup(rec, rec.Request.Script))
+		}
+		secondary, err = alignParallel(script, lines, b.Script)
+		if err != nil && b.Locale != "" {
+			log.Warn().Err(err).Str("job", rec.ID).Msg("對照稿無法對齊字幕，改用 AI 翻譯")
+			secondary = nil
+		}
+	}
+	if secondary == nil && b.Locale != "" {
+		secondary, err = w.translateLines(rec, lines, b.Locale)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("job", rec.ID).Msg("產生雙語字幕失敗，只輸出原文字幕")
+		return
+	}
+	for i := range tl.Lines {
+		tl.Lines[i].Secondary = secondary[i]
+	}
+
+	// 第二行字型需能顯示譯文，替代字型寫回任務設定，審閱後合成時沿用
+	style := &rec.Request.SubtitleStyle
+	font := style.SecondaryLine().Font
+	if chosen := media.FontForText(font, strings.Join(secondary, ""), b.Locale); chosen != font {
+		if style.Secondary == nil {
+			style.Secondary = &job.SecondaryStyle{}
+		}
+		style.Secondary.Font = chosen
+		log.Info().Str("job", rec.ID).Str("font", chosen).Msg("雙語字幕第二行改用可顯示譯文的字型")
+	}
+}
+
+// translateLines 以 AI 逐句翻譯字幕並套用詞彙表，失敗時重試
+func (w *Worker) translateLines(rec *job.Record, lines []string, locale string) ([]string, error) {
+	if w.aiClient == nil {
+		return nil, errors.New("未設定 AI，無法翻譯字幕")
+	}
+	var out []string
+	err := retryAI("AI 逐句翻譯失敗", locale, func() (err error) {
+		out, err = w.aiClient.TranslateLines(lines, rec.Request.TTS.Locale, locale, glossaryFor(rec, locale))
+		return err
+	})
+	return out, err
+}
+
+// alignParallel 將對照稿對齊到字幕：句數相同時逐句對應；否則依原腳本的句尾把字幕分組，
+// 每組對應對照稿的一句，再依子句或字幕長度比例切分譯文
+func alignParallel(script string, lines []string, parallel string) ([]string, error) {
+	sentences := utils.SplitSentences(parallel)
+	if len(sentences) == len(lines) {
+		return sentences, nil
+	}
+
+	var groups [][]int
+	var cur []int
+	for i, bound := range utils.LineBoundaries(script, lines) {
+		cur = append(cur, i)
+		if bound >= utils.BoundarySentence {
+			groups = append(groups, cur)
+			cur = nil
+		}
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	if len(groups) != len(sentences) {
+		return nil, fmt.Errorf("對照稿有 %d 句，原文有 %d 句 (%d 行字幕)，無法對齊", len(sentences), len(groups), len(lines))
+	}
+
+	out := make([]string, len(lines))
+	for g, group := range groups {
+		// 譯文的子句數與字幕行數相同時直接對應，否則依長度比例切分
+		parts := utils.SplitClauses(sentences[g])
+		if len(parts) != len(group) {
+			weights := make([]int, len(group))
+			for k, i := range group {
+				weights[k] = utf8.RuneCountInString(lines[i])
+			}
+			parts = utils.SplitProportional(sentences[g], weights)
+		}
+		for k, part := range parts {
+			out[group[k]] = part
+		}
+	}
+	return out, nil
+}
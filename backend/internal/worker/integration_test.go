@@ -59,7 +59,7 @@ func TestProcessPipeline(t *testing.T) {
 	if err := store.InsertJob(rec); err != nil {
 		t.Fatal(err)
 	}
-	if err := w.process(rec); err != nil {
+	if _, err := w.process(rec); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := os.Stat(filepath.Join(rec.BasePath, "output.mp4")); err != nil {
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// renderLanguages 主要成品完成後依 languages 設定輸出各語言版本，
+// 尚未輸出的語言標記為 pending；單一語言失敗只記錄在結果中，不影響主要成品
+func (w *Worker) renderLanguages(rec *job.Record, materials []media.PreparedMaterial) {
+	rec.Renditions = make([]job.RenditionResult, len(rec.Request.Languages))
+	for i, l := range rec.Request.Languages {
+		rec.Renditions[i] = job.RenditionResult{Locale: l.Locale, Pending: true}
+	}
+	_ = w.store.UpdateJob(rec)
+	for i, l := range rec.Request.Languages {
+		// 任務取消時工作目錄已被移除，不再輸出也不寫回結果
+		if w.queue.IsCanceled(rec.ID) {
+			log.Info().Str("job", rec.ID).Msg("任務已取消，停止輸出語言版本")
+			return
+		}
+		result := w.renderLanguage(rec, materials, l)
+		if w.queue.IsCanceled(rec.ID) {
+			return
+		}
+		rec.Renditions[i] = result
+		rec.UpdatedAt = time.Now()
+		_ = w.store.UpdateJob(rec)
+	}
+}
+
+// renderLanguage 翻譯腳本、挑選目標語系的語音與字型，再以相同素材與流程重新配音並合成，
+// 輸出到 lang/{locale}/output.mp4
+func (w *Worker) renderLanguage(rec *job.Record, materials []media.PreparedMaterial, l job.LanguageSetting) job.RenditionResult {
+	result := job.RenditionResult{Locale: l.Locale}
+	fail := func(err error) job.RenditionResult {
+		log.Warn().Err(err).Str("job", rec.ID).Str("locale", l.Locale).Msg("語言版本輸出失敗")
+		result.Error = err.Error()
+		return result
+	}
+	if w.aiClient == nil {
+		return fail(errors.New("未設定 AI，無法翻譯腳本"))
+	}
+	dir := filepath.Join(rec.BasePath, "lang", l.Locale)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fail(err)
+	}
+
+	log.Info().Str("job", rec.ID).Str("locale", l.Locale).Msg("翻譯腳本")
+	script, err := w.translateScript(rec, l.Locale)
+	if err != nil {
+		return fail(fmt.Errorf("翻譯腳本失敗: %w", err))
+	}
+	_ = os.WriteFile(filepath.Join(dir, "script.txt"), []byte(script), 0o644)
+
+	provider, voice := l.Provider, l.Voice
+	if provider == "" {
+		provider = rec.Request.TTS.Provider
+	}
+	if voice == "" {
+		v, err := w.catalog.PickVoice(provider, l.Locale, rec.Request.TTS.Gender)
+		if err != nil {
+			return fail(err)
+		}
+		provider, voice = v.Provider, v.Name
+	}
+	result.Provider, result.Voice = provider, voice
+
+	// 字幕字型需能顯示譯文，例如繁中字型沒有假名或韓文
+	font := l.Font
+	if font == "" {
+		font = rec.Request.SubtitleStyle.Font
+	}
+	font = media.FontForText(font, tts.StripMarkup(lineMarkup(rec, script)), l.Locale)
+	result.Font = font
+	log.Info().Str("job", rec.ID).Str("locale", l.Locale).Str("provider", provider).Str("voice", voice).Str("font", font).Msg("合成語言版本")
+
+	req := rec.Request.ForLanguage(l, script, provider, voice, font)
+	req.SubtitleStyle.MaxLineWidth = translatedLineWidth(req.SubtitleStyle.MaxLineWidth, segment.IsCJK(rec.Request.Script), segment.IsCJK(script))
+	lrec := &job.Record{
+		ID:        rec.ID,
+		Status:    job.StatusRunning,
+		CreatedAt: rec.CreatedAt,
+		Request:   req,
+		BasePath:  dir,
+		Rendition: l.Locale,
+	}
+	tl, voiceOut, err := w.synthesizeVoice(lrec)
+	if err != nil {
+		return fail(err)
+	}
+	if err := media.SaveTimeline(dir, tl); err != nil {
+		return fail(fmt.Errorf("保存字幕時間軸失敗: %w", err))
+	}
+	if err := w.render(lrec, materials, tl, voiceOut); err != nil {
+		return fail(err)
+	}
+	result.URL = fmt.Sprintf("/api/v1/jobs/%s/renditions/%s", rec.ID, l.Locale)
+	return result
+}
+
+// translatedLineWidth 字幕每行字數上限隨語言調整：拉丁字母約為中日韓文字一半寬，
+// 由中日韓翻成其他語言時上限加倍，反之減半
+func translatedLineWidth(width int, fromCJK, toCJK bool) int {
+	switch {
+	case fromCJK && !toCJK:
+		return width * 2
+	case !fromCJK && toCJK:
+		return max(width/2, 8)
+	}
+	return width
+}
+
+// translateScript 以 AI 翻譯腳本並套用詞彙表，失敗時重試
+func (w *Worker) translateScript(rec *job.Record, locale string) (string, error) {
+	var script string
+	err := retryAI("AI 翻譯失敗", locale, func() (err error) {
+		script, err = w.aiClient.Translate(rec.Request.Script, rec.Request.TTS.Locale, locale, glossaryFor(rec, locale))
+		return err
+	})
+	return script, err
+}
+
+// glossaryFor 任務詞彙表在目標語系的固定譯法 (原文 → 譯文)
+func glossaryFor(rec *job.Record, locale string) map[string]string {
+	glossary := map[string]string{}
+	for _, g := range rec.Request.Glossary {
+		glossary[g.Term] = g.Translation(locale)
+	}
+	return glossary
+}
+
+// aiMaxRetries AI 呼叫失敗後的重試次數
+const aiMaxRetries = 3
+
+// retryAI 執行 AI 呼叫，失敗時每 5 秒重試，回傳最後一次的錯誤
+func retryAI(what, locale string, call func() error) error {
+	var err error
+	for i := 0; i <= aiMaxRetries; i++ {
+		if err = call(); err == nil {
+			return nil
+		}
+		if i < aiMaxRetries {
+			log.Warn().Err(err).Int("retry", i+1).Str("locale", locale).Msg(what + "，5秒後重試")
+			time.Sleep(5 * time.Second)
+		}
+	}
+	return err
+}
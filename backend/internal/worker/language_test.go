@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/storage"
+)
+
+// languageJob 建立主要成品已完成、設定兩個語言版本的任務
+func languageJob(t *testing.T) (*Worker, *job.Record) {
+	t.Helper()
+	w, _, rec := fakeWorker(t, 1)
+	store, err := storage.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.store, w.queue = store, NewQueue(1)
+	rec.Status = job.StatusSuccess
+	rec.Request.Languages = []job.LanguageSetting{{Locale: "en-US"}, {Locale: "ja-JP"}}
+	if err := store.InsertJob(rec); err != nil {
+		t.Fatal(err)
+	}
+	return w, rec
+}
+
+func TestRenderLanguagesKeepsMainResult(t *testing.T) {
+	w, rec := languageJob(t)
+	// 未設定 AI 時各語言都會失敗，只記錄在結果中
+	w.renderLanguages(rec, nil)
+	if rec.Status != job.StatusSuccess {
+		t.Errorf("任務狀態 = %s，期望維持 success", rec.Status)
+	}
+	if len(rec.Renditions) != 2 {
+		t.Fatalf("語言版本 = %+v", rec.Renditions)
+	}
+	for _, rd := range rec.Renditions {
+		if rd.Pending || rd.Error == "" {
+			t.Errorf("語言版本 %+v 應已完成並記錄錯誤", rd)
+		}
+	}
+}
+
+func TestRenderLanguagesCanceled(t *testing.T) {
+	w, rec := languageJob(t)
+	w.queue.Cancel(rec.ID)
+	w.renderLanguages(rec, nil)
+	for _, rd := range rec.Renditions {
+		if !rd.Pending || rd.Error != "" {
+			t.Errorf("取消後不應輸出語言版本: %+v", rd)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+)
+
+// applyReview 讀取審閱後的時間軸，只重新合成文字有修改的句子，再依時間窗組裝語音
+func (w *Worker) applyReview(rec *job.Record) (*media.Timeline, string, error) {
+	tl, err := media.LoadTimeline(rec.BasePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("讀取字幕時間軸失敗: %w", err)
+	}
+
+	// 自備旁白只需更新字幕，語音沿用原旁白
+	if rec.Request.Voiceover.Provided() {
+		for i := range tl.Lines {
+			tl.Lines[i].Dirty = false
+		}
+		return tl, filepath.Join(rec.BasePath, "voice.wav"), nil
+	}
+
+	if err := w.resynthesizeDirty(rec, tl); err != nil {
+		return nil, "", err
+	}
+
+	rec.Progress = 35
+	w.saveProgress(rec)
+
+	voiceOut, err := media.BuildVoiceTrack(rec.BasePath, tl.Lines, fitToCues(rec.Request))
+	if err != nil {
+		return nil, "", err
+	}
+	return tl, voiceOut, nil
+}
+
+// resynthesizeDirty 重新合成文字有修改的句子並更新時間軸：
+// 新語音比原時間窗長時往後推移後續句子 (匯入字幕的時間軸除外)
+func (w *Worker) resynthesizeDirty(rec *job.Record, tl *media.Timeline) error {
+	var dirty []int
+	var texts []string
+	for i, line := range tl.Lines {
+		if line.Dirty {
+			log.Info().Str("job", rec.ID).Int("line", i).Str("text", line.Text).Msg("重新合成修改後的句子")
+			dirty = append(dirty, i)
+			texts = append(texts, line.SpeechText())
+		}
+	}
+	var results []lineAudio
+	if len(dirty) > 0 {
+		provider, err := w.ttsProvider(rec)
+		if err != nil {
+			return err
+		}
+		if results, err = w.synthesizeLines(provider, rec, dirty, texts, nil); err != nil {
+			return err
+		}
+		// 沿用首次合成時為達語速目標的倍率，避免修改的句子語速不一致
+		if tl.Tempo > 0 && tl.Tempo != 1 {
+			w.applyTempo(rec, results, tl.Tempo)
+		}
+	}
+
+	// 依原順序套用新語音，時間推移需逐句累加
+	for k, i := range dirty {
+		line := &tl.Lines[i]
+		dur := results[k].dur
+		line.Audio = results[k].path
+		line.AudioSec = dur
+		line.Provider = results[k].provider
+		line.Dirty = false
+
+		// 新語音比原時間窗長時，往後推移後續句子，避免語音被截斷
+		// 匯入字幕的時間軸以字幕檔為準，改用加速塞進時間窗
+		if fitToCues(rec.Request) {
+			continue
+		}
+		if overflow := int(dur*1000) - (line.End - line.Start); overflow > 0 {
+			line.End += overflow
+			for j := i + 1; j < len(tl.Lines); j++ {
+				tl.Lines[j].Start += overflow
+				tl.Lines[j].End += overflow
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/config"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// fakeTTS 記錄送出的文字，並以固定長度回傳空白的語音檔 (無法量測時沿用回報的長度)
+type fakeTTS struct {
+	mu    sync.Mutex
+	dir   string
+	dur   float64
+	texts []string
+}
+
+func (f *fakeTTS) Synthesize(text, voice, locale string, speed, pitch float64) (string, float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.texts = append(f.texts, text)
+	path := filepath.Join(f.dir, fmt.Sprintf("tts_%d.wav", len(f.texts)))
+	return path, f.dur, os.WriteFile(path, nil, 0o644)
+}
+
+func (f *fakeTTS) ListVoices() ([]tts.Voice, error) { return nil, nil }
+
+// fakeWorker 建立使用 fakeTTS 的 worker 與任務
+func fakeWorker(t *testing.T, dur float64) (*Worker, *fakeTTS, *job.Record) {
+	t.Helper()
+	dir := t.TempDir()
+	fake := &fakeTTS{dir: t.TempDir(), dur: dur}
+	w := &Worker{cfg: &config.Config{}, chain: tts.ChainOf("fake", fake)}
+	return w, fake, &job.Record{ID: "job1", BasePath: dir}
+}
+
+func reviewTimeline() *media.Timeline {
+	return &media.Timeline{Lines: []media.TimelineLine{
+		{SubtitleLine: media.SubtitleLine{Text: "第一句", Start: 0, End: 1000}, Audio: "a0.wav", AudioSec: 0.9},
+		{SubtitleLine: media.SubtitleLine{Text: "改過的第二句", Start: 1000, End: 2000}, Audio: "a1.wav", AudioSec: 0.9, Dirty: true},
+		{SubtitleLine: media.SubtitleLine{Text: "第三句", Start: 2000, End: 3000}, Audio: "a2.wav", AudioSec: 0.9},
+	}}
+}
+
+func TestResynthesizeDirty(t *testing.T) {
+	w, fake, rec := fakeWorker(t, 1.5)
+	tl := reviewTimeline()
+	if err := w.resynthesizeDirty(rec, tl); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.texts) != 1 || fake.texts[0] != "改過的第二句" {
+		t.Errorf("重新合成的文字 = %q，期望只有修改的句子", fake.texts)
+	}
+	if l := tl.Lines[0]; l.Audio != "a0.wav" || l.End != 1000 {
+		t.Errorf("未修改的句子不應變動: %+v", l)
+	}
+	l := tl.Lines[1]
+	if l.Dirty || l.Audio == "a1.wav" || l.AudioSec != 1.5 || l.Provider != "fake" {
+		t.Errorf("修改的句子未套用新語音: %+v", l)
+	}
+	// 新語音長 500ms，時間窗與後續句子一併往後推
+	if l.End != 2500 {
+		t.Errorf("修改的句子結束於 %d，期望 2500", l.End)
+	}
+	if l := tl.Lines[2]; l.Start != 2500 || l.End != 3500 || l.Audio != "a2.wav" {
+		t.Errorf("後續句子 = %+v，期望推移到 2500~3500", l)
+	}
+}
+
+func TestResynthesizeDirtyFitToCues(t *testing.T) {
+	w, _, rec := fakeWorker(t, 1.5)
+	rec.Request.Transcript.Content = "1\n00:00:00,000 --> 00:00:01,000\n第一句\n"
+	tl := reviewTimeline()
+	if err := w.resynthesizeDirty(rec, tl); err != nil {
+		t.Fatal(err)
+	}
+	// 匯入字幕的時間軸以字幕檔為準，不推移
+	if tl.Lines[1].End != 2000 || tl.Lines[2].Start != 2000 {
+		t.Errorf("時間軸不應推移: %+v", tl.Lines)
+	}
+	if tl.Lines[1].AudioSec != 1.5 || tl.Lines[1].Dirty {
+		t.Errorf("修改的句子未套用新語音: %+v", tl.Lines[1])
+	}
+}
+
+func TestResynthesizeDirtyNoEdits(t *testing.T) {
+	w, fake, rec := fakeWorker(t, 1.5)
+	tl := reviewTimeline()
+	tl.Lines[1].Dirty = false
+	if err := w.resynthesizeDirty(rec, tl); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.texts) != 0 {
+		t.Errorf("沒有修改時不應呼叫 TTS: %q", fake.texts)
+	}
+}
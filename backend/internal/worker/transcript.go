@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+)
+
+// fitToCues 匯入字幕且為 stretch 模式時，語音需加速塞進字幕時間窗
+func fitToCues(req job.JobCreateRequest) bool {
+	return req.Transcript.Provided() && req.Transcript.Fit != "pad"
+}
+
+// synthesizeTranscript 依匯入字幕逐句配音，略過斷句，時間軸完全沿用字幕檔
+func (w *Worker) synthesizeTranscript(rec *job.Record) (*media.Timeline, string, error) {
+	cues, err := media.LoadTranscript(rec.Request.Transcript)
+	if err != nil {
+		return nil, "", err
+	}
+	provider, err := w.ttsProvider(rec)
+	if err != nil {
+		return nil, "", err
+	}
+
+	log.Info().Str("job", rec.ID).Int("cues", len(cues)).Msg("依匯入字幕開始 TTS 合成")
+	texts := make([]string, len(cues))
+	for i, cue := range cues {
+		texts[i] = cue.Text
+	}
+	results, err := w.synthesizeLines(provider, rec, lineRange(0, len(cues)), texts, w.ttsProgress(rec, 0, len(cues)))
+	if err != nil {
+		return nil, "", err
+	}
+	tl := &media.Timeline{}
+	for i, cue := range cues {
+		// 開啟行內標記時字幕顯示移除標記後的文字
+		text, speech := cue.Text, ""
+		if rec.Request.TTS.Markup {
+			if text = tts.StripMarkup(cue.Text); text != cue.Text {
+				speech = cue.Text
+			}
+		}
+		tl.Lines = append(tl.Lines, media.TimelineLine{
+			SubtitleLine: media.SubtitleLine{
+				Text:  text,
+				Start: cue.Start,
+				End:   cue.End,
+			},
+			Audio:    results[i].path,
+			AudioSec: results[i].dur,
+			Provider: results[i].provider,
+			Speech:   speech,
+		})
+	}
+
+	voiceOut, err := media.BuildVoiceTrack(rec.BasePath, tl.Lines, fitToCues(rec.Request))
+	if err != nil {
+		return nil, "", err
+	}
+	return tl, voiceOut, nil
+}
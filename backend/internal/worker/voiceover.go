@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/align"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
+	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
+)
+
+// alignVoiceover 使用自備旁白取代 TTS，將斷句後的字幕對齊到旁白
+func (w *Worker) alignVoiceover(rec *job.Record) (*media.Timeline, string, error) {
+	voiceOut, err := media.PrepareVoiceover(rec.BasePath, rec.Request.Voiceover)
+	if err != nil {
+		return nil, "", err
+	}
+	// 旁白已錄好，停頓由旁白本身決定，字幕只需移除標記
+	script, _ := w.segmentScript(rec)
+	var lines []string
+	for _, line := range script {
+		if text := strings.TrimSpace(tts.StripMarkup(lineMarkup(rec, line))); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, "", fmt.Errorf("腳本斷句後沒有任何字幕")
+	}
+
+	aligner, err := align.GetAligner(rec.Request.Voiceover.Aligner, w.cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	log.Info().Str("job", rec.ID).Str("aligner", rec.Request.Voiceover.Aligner).Int("lines", len(lines)).Msg("對齊字幕與自備旁白")
+	durations, err := aligner.Align(voiceOut, lines)
+	if err != nil {
+		return nil, "", fmt.Errorf("字幕對齊失敗: %w", err)
+	}
+
+	rec.Progress = 35
+	w.saveProgress(rec)
+
+	tl := &media.Timeline{}
+	for _, s := range utils.BuildTimelineFloat(lines, durations) {
+		tl.Lines = append(tl.Lines, media.TimelineLine{
+			SubtitleLine: media.SubtitleLine{Text: s.Text, Start: s.Start, End: s.End},
+		})
+	}
+	return tl, voiceOut, nil
+}
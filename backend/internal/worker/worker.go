@@ -1,10 +1,13 @@
 package worker
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -13,6 +16,7 @@ import (
 	"github.com/Reggie-pan/go-shorts-generator/internal/config"
 	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
 	"github.com/Reggie-pan/go-shorts-generator/internal/service/media"
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/segment"
 	"github.com/Reggie-pan/go-shorts-generator/internal/service/tts"
 	"github.com/Reggie-pan/go-shorts-generator/internal/storage"
 	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
@@ -23,10 +27,12 @@ type Worker struct {
 	store    *storage.Store
 	queue    *Queue
 	aiClient *ai.Client
+	catalog  *tts.Catalog // 語言版本依語系挑選語音
+	chain    *tts.Chain   // 固定使用的 TTS 備援鏈 (測試用)，nil 時依任務設定建立
 }
 
 func NewWorker(cfg *config.Config, store *storage.Store, q *Queue, aiClient *ai.Client) *Worker {
-	return &Worker{cfg: cfg, store: store, queue: q, aiClient: aiClient}
+	return &Worker{cfg: cfg, store: store, queue: q, aiClient: aiClient, catalog: tts.NewCatalog(cfg)}
 }
 
 func (w *Worker) Run() {
@@ -45,7 +51,12 @@ func (w *Worker) Run() {
 		rec.UpdatedAt = time.Now()
 		_ = w.store.UpdateJob(rec)
 		log.Info().Str("job", rec.ID).Msg("開始處理任務")
-		if err := w.process(rec); err != nil {
+		materials, err := w.process(rec)
+		if errors.Is(err, errAwaitingReview) {
+			rec.Status = job.StatusReview
+			rec.Progress = 35
+			log.Info().Str("job", rec.ID).Msg("字幕時間軸已產生，等待審閱")
+		} else if err != nil {
 			rec.Status = job.StatusFailed
 			rec.ErrorMessage = err.Error()
 			rec.Progress = 0
@@ -58,112 +69,178 @@ func (w *Worker) Run() {
 		}
 		rec.UpdatedAt = time.Now()
 		_ = w.store.UpdateJob(rec)
+		// 主要成品先標記完成並保存，語言版本在之後輸出，不延遲主要結果
+		if err == nil && len(rec.Request.Languages) > 0 {
+			w.renderLanguages(rec, materials)
+		}
 	}
 }
 
-func (w *Worker) process(rec *job.Record) error {
+// errAwaitingReview 審閱模式下 TTS 與時間軸完成，暫停等待使用者確認
+var errAwaitingReview = errors.New("等待審閱字幕時間軸")
+
+// process 輸出主要成品，回傳準備好的素材供語言版本沿用
+func (w *Worker) process(rec *job.Record) ([]media.PreparedMaterial, error) {
 	base := rec.BasePath
 	if err := os.MkdirAll(base, 0o755); err != nil {
-		return err
+		return nil, err
 	}
+	// 審閱模式也先準備素材，讓下載失敗等問題在審閱前就回報
 	log.Info().Str("job", rec.ID).Msg("準備素材")
 	materials, err := media.PrepareMaterials(base, rec.Request.Materials)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// 影片入出點在建立任務時只檢查數值，素材長度在下載後才對照
+	if err := media.CheckMaterialRanges(materials, rec.Request.Materials); err != nil {
+		return nil, err
 	}
 	rec.Progress = 15
 	_ = w.store.UpdateJob(rec)
 
-	log.Info().Str("job", rec.ID).Msg("AI 斷句中...")
-	var lines []string
-	if w.aiClient != nil {
-		maxRetries := 3
-		for i := 0; i <= maxRetries; i++ {
-			lines, err = w.aiClient.SegmentText(rec.Request.Script, rec.Request.SubtitleStyle.MaxLineWidth)
-			if err == nil {
-				break
-			}
-			if i < maxRetries {
-				log.Warn().Err(err).Int("retry", i+1).Msg("AI 斷句失敗，5秒後重試")
-				time.Sleep(5 * time.Second)
-			}
-		}
+	var tl *media.Timeline
+	var voiceOut string
+	if rec.Reviewed {
+		log.Info().Str("job", rec.ID).Msg("套用審閱後的字幕時間軸")
+		tl, voiceOut, err = w.applyReview(rec)
+	} else {
+		tl, voiceOut, err = w.synthesizeVoice(rec)
 	}
-	if w.aiClient == nil || err != nil {
-		if err != nil {
-			log.Error().Err(err).Msg("AI 斷句失敗，降級使用規則斷句")
-		} else {
-			log.Info().Msg("無 AI 客戶端，使用規則斷句")
-		}
-		lines = utils.SplitScript(rec.Request.Script, rec.Request.SubtitleStyle.MaxLineWidth)
+	if err != nil {
+		return nil, err
 	}
-	for i, line := range lines {
-		lines[i] = utils.AutoSpacing(line)
+	// 雙語字幕的第二行隨時間軸保存，審閱時可一併修改
+	if !rec.Reviewed && rec.Request.Bilingual.Enabled() {
+		w.addSecondary(rec, tl)
 	}
-	provider, err := tts.GetProvider(rec.Request.TTS.Provider, w.cfg)
-	if err != nil {
-		return err
+	rec.TTSProviders = tl.ProviderUsage()
+	if err := media.SaveTimeline(base, tl); err != nil {
+		return nil, fmt.Errorf("保存字幕時間軸失敗: %w", err)
+	}
+	if rec.Request.Review && !rec.Reviewed {
+		return nil, errAwaitingReview
+	}
+	if err := w.render(rec, materials, tl, voiceOut); err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+// saveProgress 保存任務進度；語言版本是暫時的衍生任務，進度不寫回
+func (w *Worker) saveProgress(rec *job.Record) {
+	if rec.Rendition != "" {
+		return
 	}
+	_ = w.store.UpdateJob(rec)
+}
 
-	// 建立標準靜音檔 (0.2s, PCM 24k, Mono)
-	silencePath := filepath.Join(base, "silence.wav")
-	if _, err := utils.RunCmd("ffmpeg", "-y", "-f", "lavfi", "-i", "anullsrc=r=24000:cl=mono", "-t", "0.2", "-c:a", "pcm_s16le", silencePath); err != nil {
-		return fmt.Errorf("建立靜音檔失敗: %w", err)
+// synthesizeVoice 斷句並逐句合成語音，回傳字幕時間軸與合併後的 voice.wav
+func (w *Worker) synthesizeVoice(rec *job.Record) (*media.Timeline, string, error) {
+	if rec.Request.Transcript.Provided() {
+		return w.synthesizeTranscript(rec)
+	}
+	if rec.Request.Voiceover.Provided() {
+		return w.alignVoiceover(rec)
+	}
+	base := rec.BasePath
+	script, bounds := w.segmentScript(rec)
+	provider, err := w.ttsProvider(rec)
+	if err != nil {
+		return nil, "", err
+	}
+	pacing := rec.Request.Pacing.WithDefaults()
+	lines, gaps, leadIn := w.paceLines(rec, pacing, script, bounds)
+	if len(lines) == 0 {
+		return nil, "", fmt.Errorf("腳本斷句後沒有任何字幕")
 	}
-	silenceDur, _ := utils.AudioDurationSeconds(silencePath)
 
-	var audioParts []string
-	var durations []float64
 	log.Info().Str("job", rec.ID).Int("lines", len(lines)).Msg("開始 TTS 合成")
-	for i, line := range lines {
-		// 1. 文本清洗 (Sanitization)
-		ttsText := strings.ReplaceAll(line, "\n", ", ")
-		ttsText = strings.ReplaceAll(ttsText, "\\", "")
-		ttsText = strings.ReplaceAll(ttsText, "/", "")
-		ttsText = strings.ReplaceAll(ttsText, "*", "")
-
-		path, _, err := provider.Synthesize(ttsText, rec.Request.TTS.Voice, rec.Request.TTS.Locale, rec.Request.TTS.Speed, rec.Request.TTS.Pitch)
+	var results []lineAudio
+	if maxSec := rec.Request.Preview.MaxSeconds; maxSec > 0 {
+		// 草稿只輸出前 N 秒：每次合成一批，累積長度足夠後略過其餘句子
+		batch := w.cfg.Concurrency(rec.Request.TTS.Provider)
+		voiced := leadIn
+		for start := 0; start < len(lines) && voiced < maxSec; start += batch {
+			end := min(start+batch, len(lines))
+			part, err := w.synthesizeLines(provider, rec, lineRange(start, end), lines[start:end], w.ttsProgress(rec, start, len(lines)))
+			if err != nil {
+				return nil, "", err
+			}
+			for k, r := range part {
+				if voiced >= maxSec {
+					break
+				}
+				results = append(results, r)
+				voiced += r.dur + gaps[start+k]
+			}
+		}
+		if len(results) < len(lines) {
+			log.Info().Str("job", rec.ID).Int("lines", len(results)).Msg("草稿預覽已達長度上限，略過其餘句子")
+			lines, gaps = lines[:len(results)], gaps[:len(results)]
+		}
+	} else {
+		results, err = w.synthesizeLines(provider, rec, lineRange(0, len(lines)), lines, w.ttsProgress(rec, 0, len(lines)))
 		if err != nil {
-			return err
+			return nil, "", err
 		}
+	}
 
-		// 2. 強制重編碼與修剪 (Re-encode & Trim)
-		// 強制轉為 pcm_s16le 24000Hz mono，確保與靜音檔一致以便 concat 拼接
-		trimmedPath := strings.TrimSuffix(path, filepath.Ext(path)) + fmt.Sprintf("_%d_processed.wav", i)
-		filter := "silenceremove=start_periods=1:start_duration=0:start_threshold=-50dB:detection=peak,areverse,silenceremove=start_periods=1:start_duration=0:start_threshold=-50dB:detection=peak,areverse"
+	// 字幕顯示移除行內標記後的文字，標記保留給重新合成使用
+	display := make([]string, len(lines))
+	for i, line := range lines {
+		display[i] = tts.StripMarkup(lineMarkup(rec, line))
+	}
 
-		if out, err := utils.RunCmd("ffmpeg", "-y", "-i", path, "-af", filter, "-c:a", "pcm_s16le", "-ar", "24000", "-ac", "1", trimmedPath); err != nil {
-			log.Warn().Err(err).Str("output", out).Msg("音訊處理失敗，使用原始檔")
-			trimmedPath = path
+	tempo := 1.0
+	if pacing.TargetCPM > 0 {
+		tempo = matchTempo(results, display, pacing.TargetCPM)
+		if tempo != 1 {
+			log.Info().Str("job", rec.ID).Float64("target_cpm", pacing.TargetCPM).Float64("tempo", tempo).Msg("調整語速以符合目標")
+			w.applyTempo(rec, results, tempo)
 		}
+	}
 
-		dur, _ := utils.AudioDurationSeconds(trimmedPath)
-
-		// 3. 記錄片段與長度
-		// 每句後面都加一段靜音
-		audioParts = append(audioParts, trimmedPath)
-		audioParts = append(audioParts, silencePath)
-
-		// 字幕長度 = 語音長度 + 靜音長度
-		// 這樣字幕會顯示直到下一句開始
-		durations = append(durations, dur+silenceDur)
+	// 3. 記錄片段與長度
+	// 每句後面依斷點類型加上不同長度的靜音，同長度共用一個靜音檔
+	silences := map[int]silence{}
+	silenceFor := func(sec float64) (silence, error) {
+		ms := int(math.Round(sec * 1000))
+		if s, ok := silences[ms]; ok || ms <= 0 {
+			return s, nil
+		}
+		s, err := makeSilence(base, ms)
+		if err != nil {
+			return s, fmt.Errorf("建立靜音檔失敗: %w", err)
+		}
+		silences[ms] = s
+		return s, nil
+	}
 
-		// Update Progress: 15% -> 35%
-		// TTS processing usually takes some time, so we update progress here.
-		if len(lines) > 0 {
-			currentProgress := 15 + int(float64(i+1)/float64(len(lines))*20)
-			if currentProgress > 35 {
-				currentProgress = 35
-			}
-			if currentProgress != rec.Progress {
-				rec.Progress = currentProgress
-				_ = w.store.UpdateJob(rec)
-			}
+	var audioParts []string
+	lead, err := silenceFor(leadIn)
+	if err != nil {
+		return nil, "", err
+	}
+	if lead.path != "" {
+		audioParts = append(audioParts, lead.path)
+	}
+	speech := make([]float64, len(results))
+	for i, r := range results {
+		audioParts = append(audioParts, r.path)
+		speech[i] = r.dur
+		gap, err := silenceFor(gaps[i])
+		if err != nil {
+			return nil, "", err
 		}
+		if gap.path != "" {
+			audioParts = append(audioParts, gap.path)
+		}
+		// 以實際靜音檔長度計算時間軸
+		gaps[i] = gap.dur
 	}
 
 	rec.Progress = 35
-	_ = w.store.UpdateJob(rec)
+	w.saveProgress(rec)
 
 	concatTxt := filepath.Join(base, "voice_list.txt")
 	var list []string
@@ -175,15 +252,15 @@ func (w *Worker) process(rec *job.Record) error {
 	voiceOut := filepath.Join(base, "voice.wav")
 	// 合併語音，使用 copy 模式避免重編碼 (前面已統一格式)
 	if out, err := utils.RunCmd("ffmpeg", "-y", "-f", "concat", "-safe", "0", "-i", concatTxt, "-c:a", "copy", voiceOut); err != nil {
-		return fmt.Errorf("合併語音失敗: %v / %s", err, out)
+		return nil, "", fmt.Errorf("合併語音失敗: %v / %s", err, out)
 	}
 
 	totalVoiceDur, _ := utils.AudioDurationSeconds(voiceOut)
 	log.Info().Str("job", rec.ID).Float64("duration_sec", totalVoiceDur).Msg("語音合併完成")
 
-	var sumDur float64
-	for _, d := range durations {
-		sumDur += d
+	sumDur := lead.dur
+	for i := range speech {
+		sumDur += speech[i] + gaps[i]
 	}
 	log.Info().Str("job", rec.ID).Float64("sum_durations", sumDur).Float64("total_voice_dur", totalVoiceDur).Msg("時間軸校對")
 
@@ -193,18 +270,401 @@ func (w *Worker) process(rec *job.Record) error {
 	}
 	log.Info().Str("job", rec.ID).Float64("scale_factor", scaleFactor).Msg("時間軸縮放")
 
-	subs := utils.BuildTimelineFloat(lines, durations)
-	subLines := []media.SubtitleLine{}
-	for _, s := range subs {
-		start := int(float64(s.Start) * scaleFactor)
-		end := int(float64(s.End) * scaleFactor)
-		subLines = append(subLines, media.SubtitleLine{
-			Text:  s.Text,
-			Start: start,
-			End:   end,
+	// 字幕在短停頓中持續顯示，長停頓 (例如段落之間) 則先清空畫面
+	subs := utils.BuildTimelinePaced(display, speech, gaps, subtitleHold)
+	leadMs := int(lead.dur * 1000)
+	tl := &media.Timeline{}
+	if tempo != 1 {
+		tl.Tempo = tempo
+	}
+	for i, s := range subs {
+		speech := ""
+		if lines[i] != s.Text {
+			speech = lines[i]
+		}
+		start := int(float64(s.Start+leadMs) * scaleFactor)
+		end := int(float64(s.End+leadMs) * scaleFactor)
+		tl.Lines = append(tl.Lines, media.TimelineLine{
+			SubtitleLine: media.SubtitleLine{
+				Text:  s.Text,
+				Start: start,
+				End:   end,
+			},
+			Audio:    results[i].path,
+			AudioSec: results[i].dur,
+			Provider: results[i].provider,
+			Speech:   speech,
 		})
 	}
-	subPath, _, err := media.BuildASS(base, rec.Request.SubtitleStyle, subLines, rec.Request.Video.Resolution)
+	return tl, voiceOut, nil
+}
+
+// subtitleHold 句後停頓中字幕最多再停留的秒數
+const subtitleHold = 0.5
+
+// lineMarkup 回傳句子的行內標記形式；未開啟行內標記時只有 [pause=…] 是標記，其餘方括號都是文字
+func lineMarkup(rec *job.Record, line string) string {
+	if rec.Request.TTS.Markup {
+		return line
+	}
+	return tts.PauseMarkup(line)
+}
+
+// pauseFor 依句子結尾的斷點類型決定句後停頓；強制切開的長句只留短暫換氣
+func pauseFor(p job.PacingSetting, b utils.Boundary) float64 {
+	switch b {
+	case utils.BoundaryParagraph:
+		return *p.ParagraphPause
+	case utils.BoundarySentence:
+		return *p.SentencePause
+	case utils.BoundaryComma:
+		return *p.CommaPause
+	}
+	return *p.CommaPause / 2
+}
+
+// paceLines 計算每句的句後停頓 (秒) 與開頭靜音：預設依標點類型決定，
+// 句首或句尾的 [pause=…] 會從語音中移除 (修剪靜音時會被切掉)，改為指定長度的句間停頓；
+// 只有停頓標記的空句併入前一句的停頓
+func (w *Worker) paceLines(rec *job.Record, p job.PacingSetting, script []string, bounds []utils.Boundary) ([]string, []float64, float64) {
+	var lines []string
+	var gaps []float64
+	var leadIn, pending float64
+	explicit := false
+	for i, line := range script {
+		lead, trail, rest := tts.TrimPauses(lineMarkup(rec, line))
+		if lead > 0 {
+			pending += lead.Seconds()
+			explicit = true
+		}
+		if strings.TrimSpace(tts.StripMarkup(rest)) == "" {
+			if trail > 0 {
+				pending += trail.Seconds()
+				explicit = true
+			}
+			continue
+		}
+		if explicit {
+			if n := len(gaps); n > 0 {
+				gaps[n-1] = pending
+			} else {
+				leadIn = pending
+			}
+		}
+		pending, explicit = 0, false
+		if !rec.Request.TTS.Markup {
+			rest = tts.PlainPauses(rest)
+		}
+		lines = append(lines, rest)
+		gaps = append(gaps, pauseFor(p, bounds[i]))
+		if trail > 0 {
+			pending, explicit = trail.Seconds(), true
+		}
+	}
+	if n := len(gaps); explicit && n > 0 {
+		gaps[n-1] = pending
+	}
+	return lines, gaps, leadIn
+}
+
+// silence 句間停頓用的靜音檔與實際長度
+type silence struct {
+	path string
+	dur  float64
+}
+
+// makeSilence 建立指定毫秒數的靜音檔 (PCM 24k, Mono)，與修剪後的語音格式一致以便 concat
+func makeSilence(base string, ms int) (silence, error) {
+	path := filepath.Join(base, fmt.Sprintf("silence_%dms.wav", ms))
+	sec := float64(ms) / 1000
+	if out, err := utils.RunCmd("ffmpeg", "-y", "-f", "lavfi", "-i", "anullsrc=r=24000:cl=mono", "-t", fmt.Sprintf("%.3f", sec), "-c:a", "pcm_s16le", path); err != nil {
+		return silence{}, fmt.Errorf("%v / %s", err, out)
+	}
+	if dur, _ := utils.AudioDurationSeconds(path); dur > 0 {
+		sec = dur
+	}
+	return silence{path: path, dur: sec}, nil
+}
+
+// matchTempo 依合成結果的實際語速 (不含停頓) 計算達到目標每分鐘字數的 atempo 倍率，
+// 限制在 0.7~1.4 之間避免失真；差距很小時不調整
+func matchTempo(results []lineAudio, texts []string, target float64) float64 {
+	var units int
+	var sec float64
+	for i, r := range results {
+		units += utils.SpeechUnits(texts[i])
+		sec += r.dur
+	}
+	if units == 0 || sec == 0 {
+		return 1
+	}
+	tempo := target / (float64(units) / sec * 60)
+	tempo = math.Max(0.7, math.Min(1.4, tempo))
+	if math.Abs(tempo-1) <= 0.02 {
+		return 1
+	}
+	return tempo
+}
+
+// applyTempo 將語速倍率套用到工單目錄內的單句語音並更新長度；
+// 修剪失敗而沿用的原始檔 (可能是 TTS 快取) 不修改
+func (w *Worker) applyTempo(rec *job.Record, results []lineAudio, tempo float64) {
+	voiceDir := filepath.Join(rec.BasePath, "voice") + string(filepath.Separator)
+	for i, r := range results {
+		if !strings.HasPrefix(r.path, voiceDir) {
+			continue
+		}
+		dur, err := media.ChangeTempo(r.path, tempo)
+		if err != nil {
+			log.Warn().Err(err).Str("job", rec.ID).Str("audio", r.path).Msg("調整語速失敗，保留原語速")
+			continue
+		}
+		results[i].dur = dur
+	}
+}
+
+// segmentScript 以 AI 斷句 (失敗重試後降級為依語系的規則斷句)，並處理中英文間距；
+// 同時回傳每句在原腳本的結尾斷點類型，供決定句間停頓
+func (w *Worker) segmentScript(rec *job.Record) ([]string, []utils.Boundary) {
+	var err error
+	log.Info().Str("job", rec.ID).Msg("AI 斷句中...")
+	// 行內標記先換成佔位字元，避免斷句切開標記或在標記內加空白；
+	// 未開啟行內標記時只保護停頓標記
+	markup := rec.Request.TTS.Markup
+	script := rec.Request.Script
+	if !markup {
+		script = tts.PauseMarkup(script)
+	}
+	script, tags := tts.ProtectMarkup(script)
+	seg := segment.ForText(rec.Request.TTS.Locale, script)
+	maxCols := segment.Columns(rec.Request.SubtitleStyle.MaxLineWidth, script)
+	var lines []string
+	// 規則斷句直接取自腳本，品質以滿分記錄
+	quality := &job.SegmentQuality{Source: "rules", Report: segment.Report{Score: 1, Similarity: 1}}
+	if w.aiClient != nil {
+		maxRetries := 3
+		for i := 0; i <= maxRetries; i++ {
+			var report segment.Report
+			quality.Attempts = i + 1
+			lines, report, err = w.aiClient.SegmentText(script, rec.Request.TTS.Locale, rec.Request.SubtitleStyle.MaxLineWidth)
+			if err == nil {
+				quality.Source, quality.Report = "ai", report
+				break
+			}
+			if i == maxRetries {
+				break
+			}
+			// 偏離原文時立即重新產生，其他錯誤 (例如限流) 稍後重試
+			var drift *ai.DriftError
+			if errors.As(err, &drift) {
+				log.Warn().Float64("similarity", drift.Report.Similarity).Int("retry", i+1).Msg("AI 斷句偏離原文，重新產生")
+				continue
+			}
+			log.Warn().Err(err).Int("retry", i+1).Msg("AI 斷句失敗，5秒後重試")
+			time.Sleep(5 * time.Second)
+		}
+	}
+	if w.aiClient == nil || err != nil {
+		if err != nil {
+			log.Error().Err(err).Msg("AI 斷句失敗，降級使用規則斷句")
+		} else {
+			log.Info().Msg("無 AI 客戶端，使用規則斷句")
+		}
+		lines = seg.Split(script, maxCols)
+	}
+	for i, line := range lines {
+		lines[i] = utils.AutoSpacing(line)
+	}
+	restored := lines
+	if len(tags) > 0 {
+		var ok bool
+		if restored, ok = tts.RestoreMarkup(lines, tags); !ok {
+			// AI 斷句可能改寫或遺漏佔位字元，改用規則斷句確保標記完整
+			log.Warn().Str("job", rec.ID).Msg("斷句後行內標記不完整，改用規則斷句")
+			lines = seg.Split(script, maxCols)
+			for i, line := range lines {
+				lines[i] = utils.AutoSpacing(line)
+			}
+			restored, _ = tts.RestoreMarkup(lines, tags)
+			quality.Source, quality.Report = "rules", segment.Report{Score: 1, Similarity: 1}
+		}
+	}
+	rec.Segmentation = quality
+	log.Info().Str("job", rec.ID).Str("source", quality.Source).Float64("score", quality.Score).
+		Int("missing", quality.Missing).Int("inserted", quality.Inserted).Int("overlong", quality.Overlong).Msg("斷句完成")
+	// 斷句會移除標點，需對回原腳本才知道每句結尾是逗號、句號還是分段
+	bounds := utils.LineBoundaries(script, lines)
+	if !markup {
+		for i, line := range restored {
+			restored[i] = tts.PlainPauses(line)
+		}
+	}
+	return restored, bounds
+}
+
+// ttsProvider 建立任務使用的 TTS 備援鏈 (各 provider 含限流、重試與快取)
+func (w *Worker) ttsProvider(rec *job.Record) (*tts.Chain, error) {
+	if w.chain != nil {
+		return w.chain, nil
+	}
+	fallback := rec.Request.TTS.Fallback
+	if fallback == nil {
+		fallback = w.cfg.TTSFallback
+	}
+	if rec.Request.TTS.NoFallback {
+		fallback = nil
+	}
+	chain, err := tts.NewChain(w.cfg, rec.Request.TTS.Provider, fallback, rec.Request.TTS.Gender)
+	if err != nil {
+		return nil, err
+	}
+	if team := rec.Request.TTS.Lexicon; team != "" {
+		if chain.Lexicon, err = w.store.GetLexicon(team); err != nil {
+			// 字典在建立任務後被刪除或損毀時，仍照原文合成
+			log.Warn().Err(err).Str("job", rec.ID).Str("team", team).Msg("讀取發音字典失敗，略過")
+		}
+	}
+	return chain, nil
+}
+
+// lineAudio 單句語音合成結果
+type lineAudio struct {
+	path     string
+	dur      float64
+	provider string
+}
+
+// synthesizeLines 以 provider 的並行上限同時合成多句語音 (含修剪)，結果維持原本順序；
+// 任一句失敗後不再開始新的句子，並回傳第一個錯誤。indices 為各句在整份腳本的索引 (決定輸出檔名)，
+// onDone 在呼叫端的 goroutine 以已完成句數呼叫，可安全更新任務進度
+func (w *Worker) synthesizeLines(provider *tts.Chain, rec *job.Record, indices []int, texts []string, onDone func(done int)) ([]lineAudio, error) {
+	results := make([]lineAudio, len(texts))
+	done := make(chan error, len(texts))
+	sem := make(chan struct{}, w.cfg.Concurrency(rec.Request.TTS.Provider))
+	var failed atomic.Bool
+
+	go func() {
+		for i, text := range texts {
+			sem <- struct{}{}
+			if failed.Load() {
+				<-sem
+				done <- errLineSkipped
+				continue
+			}
+			go func(i int, text string) {
+				defer func() { <-sem }()
+				path, dur, served, err := w.synthesizeLine(provider, rec, indices[i], text)
+				if err != nil {
+					failed.Store(true)
+				} else {
+					results[i] = lineAudio{path: path, dur: dur, provider: served}
+				}
+				done <- err
+			}(i, text)
+		}
+	}()
+
+	var firstErr error
+	for n := 1; n <= len(texts); n++ {
+		err := <-done
+		if err != nil && !errors.Is(err, errLineSkipped) && firstErr == nil {
+			firstErr = err
+		}
+		if firstErr == nil && onDone != nil {
+			onDone(n)
+		}
+	}
+	return results, firstErr
+}
+
+// lineRange 回傳 [start, end) 的句子索引
+func lineRange(start, end int) []int {
+	indices := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// errLineSkipped 前面的句子失敗後略過的句子
+var errLineSkipped = errors.New("略過合成")
+
+// ttsProgress 回傳依完成句數更新進度的函式 (15% -> 35%)，offset 為這一批之前已完成的句數
+func (w *Worker) ttsProgress(rec *job.Record, offset, total int) func(int) {
+	return func(done int) {
+		if total == 0 {
+			return
+		}
+		currentProgress := min(15+int(float64(offset+done)/float64(total)*20), 35)
+		if currentProgress != rec.Progress {
+			rec.Progress = currentProgress
+			w.saveProgress(rec)
+		}
+	}
+}
+
+// synthesizeLine 合成單句語音並修剪前後靜音，輸出到工單目錄 voice/line_NNN.wav
+// 回傳修剪後的檔案、長度與實際合成的 provider
+func (w *Worker) synthesizeLine(provider *tts.Chain, rec *job.Record, i int, line string) (string, float64, string, error) {
+	// 1. 文本清洗 (Sanitization)
+	// 只清洗標記外的文字；未開啟行內標記時方括號一律視為文字
+	sanitize := func(s string) string {
+		s = strings.ReplaceAll(s, "\n", ", ")
+		s = strings.ReplaceAll(s, "\\", "")
+		s = strings.ReplaceAll(s, "/", "")
+		return strings.ReplaceAll(s, "*", "")
+	}
+	ttsText := tts.MapText(lineMarkup(rec, line), sanitize)
+
+	res, err := provider.SynthesizeLine(ttsText, rec.Request.TTS.Voice, rec.Request.TTS.Locale, rec.Request.TTS.Speed, rec.Request.TTS.Pitch)
+	if err != nil {
+		return "", 0, "", err
+	}
+	path := res.Path
+
+	// 2. 強制重編碼與修剪 (Re-encode & Trim)
+	// 強制轉為 pcm_s16le 24000Hz mono，確保與靜音檔一致以便 concat 拼接
+	// 單句語音保存在工單目錄，審閱模式需要個別試聽與重新合成
+	voiceDir := filepath.Join(rec.BasePath, "voice")
+	if err := os.MkdirAll(voiceDir, 0o755); err != nil {
+		return "", 0, "", err
+	}
+	trimmedPath := filepath.Join(voiceDir, fmt.Sprintf("line_%03d.wav", i))
+	// 保留 50ms 原有靜音，避免字首字尾的氣音被切掉
+	trim := fmt.Sprintf("silenceremove=start_periods=1:start_duration=0:start_threshold=%gdB:start_silence=0.05:detection=peak", rec.Request.Pacing.WithDefaults().TrimDB)
+	filter := trim + ",areverse," + trim + ",areverse"
+
+	if out, err := utils.RunCmd("ffmpeg", "-y", "-i", path, "-af", filter, "-c:a", "pcm_s16le", "-ar", "24000", "-ac", "1", trimmedPath); err != nil {
+		log.Warn().Err(err).Str("output", out).Msg("音訊處理失敗，使用原始檔")
+		trimmedPath = path
+	} else if !tts.IsCached(w.cfg, path) {
+		// provider 的暫存檔已轉存到工單目錄 (快取中的檔案由快取管理)
+		_ = os.Remove(path)
+	}
+
+	dur, err := utils.AudioDurationSeconds(trimmedPath)
+	if err != nil {
+		// 無法量測時改用 provider 回報的長度
+		dur = res.Duration
+	}
+	return trimmedPath, dur, res.Provider, nil
+}
+
+// render 製作影片片段、混音並燒錄字幕，輸出 output.mp4
+func (w *Worker) render(rec *job.Record, materials []media.PreparedMaterial, tl *media.Timeline, voiceOut string) error {
+	base := rec.BasePath
+	totalVoiceDur, _ := utils.AudioDurationSeconds(voiceOut)
+	// 草稿預覽以低解析度、低幀率與最快編碼輸出，可只取前 N 秒
+	video := rec.Request.RenderVideo()
+	enc := media.FinalEncoding
+	if rec.Request.Preview.Enabled {
+		enc = media.DraftEncoding
+		if limit := rec.Request.Preview.MaxSeconds; limit > 0 && totalVoiceDur > limit {
+			totalVoiceDur = limit
+		}
+	}
+
+	subPath, _, err := media.BuildASS(base, rec.Request.SubtitleStyle, tl.SubtitleLines(), video.Resolution)
 	if err != nil {
 		return err
 	}
@@ -213,9 +673,9 @@ func (w *Worker) process(rec *job.Record) error {
 	// 4. 製作影片片段 (MakeSegments)
 	// 這裡處理每個素材轉檔、縮放、加黑邊、合併音訊
 	segments := media.BuildVideoTimeline(materials, rec.Request.Materials, int(totalVoiceDur*1000))
-	log.Debug().Str("job", rec.ID).Str("resolution", rec.Request.Video.Resolution).Msg("製作影片片段")
+	log.Debug().Str("job", rec.ID).Str("resolution", video.Resolution).Msg("製作影片片段")
 
-	videoPath, err := media.MakeSegments(base, rec.Request.Video.Resolution, rec.Request.Video.FPS, rec.Request.Video.Background, segments, rec.Request.Video.Transition, rec.Request.Video.BlurBackground, func(percent int) {
+	videoPath, err := media.MakeSegments(base, video.Resolution, video.FPS, video.Background, segments, video.Transition, video.BlurBackground, enc, func(percent int) {
 		// Video Generation: 35% -> 70%
 		currentProgress := 35 + int(float64(percent)*0.35)
 		if currentProgress > 70 {
@@ -223,7 +683,7 @@ func (w *Worker) process(rec *job.Record) error {
 		}
 		if currentProgress != rec.Progress {
 			rec.Progress = currentProgress
-			_ = w.store.UpdateJob(rec)
+			w.saveProgress(rec)
 		}
 	})
 	if err != nil {
@@ -231,7 +691,7 @@ func (w *Worker) process(rec *job.Record) error {
 	}
 
 	rec.Progress = 70
-	_ = w.store.UpdateJob(rec)
+	w.saveProgress(rec)
 
 	// 2. 準備背景音樂 (BGM)
 	var bgmInput string
@@ -283,7 +743,11 @@ func (w *Worker) process(rec *job.Record) error {
 	subPathFF = strings.ReplaceAll(subPathFF, "'", "\\'")
 
 	// 移除 setpts=PTS/%f，避免開始速度
-	videoFilter := fmt.Sprintf("subtitles='%s'", subPathFF)
+	// 字幕模式：burn 燒錄進畫面；soft/none 不燒錄 (soft 另外封裝 mov_text 字幕軌)
+	videoFilter := "null"
+	if rec.Request.SubtitleMode == "" || rec.Request.SubtitleMode == job.SubtitleModeBurn {
+		videoFilter = fmt.Sprintf("subtitles='%s'", subPathFF)
+	}
 
 	var args []string
 	log.Info().Str("job", rec.ID).Msg("執行 ffmpeg 合成")
@@ -320,29 +784,184 @@ func (w *Worker) process(rec *job.Record) error {
 	if videoDur < finalDuration {
 		finalDuration = videoDur
 	}
+	if limit := rec.Request.Preview.MaxSeconds; limit > 0 && finalDuration > limit {
+		finalDuration = limit
+	}
+
+	// 疊加層 (浮水印、標題、片尾卡)：圖片接在音訊輸入之後
+	overlays, err := media.PrepareOverlays(base, scaleOverlays(rec.Request.Overlays, rec.Request.PreviewScale()), video.Resolution, video.FPS, finalDuration)
+	if err != nil {
+		return fmt.Errorf("準備疊加層失敗: %w", err)
+	}
 
 	if bgmInput != "" {
 		// 3 inputs: VideoAudio, BGM, TTS
 		// 使用 duration=first，以第一個輸入 (video_audio) 為基準
 		// video_audio 會被 trim 到 finalDuration
-		filter := fmt.Sprintf(`[0:v]%s,trim=0:%.3f,setpts=PTS-STARTPTS[vout];[1:a]volume=%.2f,aloop=-1:size=0,atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[bgm];[2:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[tts];[0:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[video_audio];[video_audio][bgm][tts]amix=inputs=3:duration=first[aout]`,
-			videoFilter, finalDuration, rec.Request.BGM.Volume, finalDuration, voiceSeconds, finalDuration)
+		// 人聲分成兩路：一路進混音，一路作為 sidechain 壓低 BGM
+		bgmChain := media.BGMFilter(rec.Request.BGM, finalDuration)
+		ttsChain := fmt.Sprintf("[2:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[tts]", voiceSeconds)
+		if duck := media.DuckFilter(rec.Request.BGM.DuckRatio); duck != "" {
+			bgmChain = fmt.Sprintf("[1:a]%s[bgm_raw];", bgmChain)
+			ttsChain = fmt.Sprintf("[2:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo,asplit=2[tts][tts_sc];[bgm_raw][tts_sc]%s[bgm]", voiceSeconds, duck)
+		} else {
+			bgmChain = fmt.Sprintf("[1:a]%s[bgm];", bgmChain)
+		}
+		filter := fmt.Sprintf(`%s;%s%s;[0:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[video_audio];[video_audio][bgm][tts]amix=inputs=3:duration=first[aout]`,
+			overlayChain(overlays, videoFilter, finalDuration, 3), bgmChain, ttsChain, finalDuration)
 
-		args = []string{"-y", "-i", videoPath, "-i", bgmInput, "-i", voiceOut, "-filter_complex", filter, "-map", "[vout]", "-map", "[aout]", "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-shortest", output}
+		args = []string{"-y", "-i", videoPath, "-i", bgmInput, "-i", voiceOut}
+		args = append(args, overlays.Inputs()...)
+		args = append(args, "-filter_complex", filter, "-map", "[vout]", "-map", "[aout]", "-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-shortest", output)
 	} else {
 		// 2 inputs: VideoAudio, TTS
 		// 使用 duration=first，以 video_audio 為基準
-		filter := fmt.Sprintf(`[0:v]%s,trim=0:%.3f,setpts=PTS-STARTPTS[vout];[1:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[tts];[0:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[video_audio];[video_audio][tts]amix=inputs=2:duration=first[aout]`,
-			videoFilter, finalDuration, voiceSeconds, finalDuration)
+		filter := fmt.Sprintf(`%s;[1:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[tts];[0:a]atrim=0:%.3f,aformat=sample_rates=44100:channel_layouts=stereo[video_audio];[video_audio][tts]amix=inputs=2:duration=first[aout]`,
+			overlayChain(overlays, videoFilter, finalDuration, 2), voiceSeconds, finalDuration)
 
-		args = []string{"-y", "-i", videoPath, "-i", voiceOut, "-filter_complex", filter, "-map", "[vout]", "-map", "[aout]", "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-shortest", output}
+		args = []string{"-y", "-i", videoPath, "-i", voiceOut}
+		args = append(args, overlays.Inputs()...)
+		args = append(args, "-filter_complex", filter, "-map", "[vout]", "-map", "[aout]", "-c:v", "libx264", "-preset", enc.Preset, "-crf", enc.CRF, "-threads", "2", "-c:a", "aac", "-b:a", "128k", "-shortest", output)
+	}
+	if rec.Request.SubtitleMode == job.SubtitleModeSoft {
+		srtPath, err := media.WriteSRT(base, tl.SubtitleLines())
+		if err != nil {
+			return fmt.Errorf("產生 SRT 字幕失敗: %w", err)
+		}
+		inputs := 2
+		if bgmInput != "" {
+			inputs = 3
+		}
+		args = withSoftSubtitle(args, srtPath, inputs+overlays.InputCount())
 	}
 	if out, err := utils.RunCmdTimeout(5*time.Minute, "ffmpeg", args...); err != nil {
 		return fmt.Errorf("合成最終影片失敗: %v / %s", err, out)
 	} else if out != "" {
 		log.Debug().Str("job", rec.ID).Msg(out)
 	}
+
+	// 3. 響度正規化 (EBU R128 loudnorm 兩階段)
+	// 草稿預覽只為確認節奏，略過耗時的兩階段分析
+	if !rec.Request.Audio.SkipNormalize && !rec.Request.Preview.Enabled {
+		target := rec.Request.Audio.TargetLUFS
+		if target == 0 {
+			target = w.cfg.LoudnessLUFS
+		}
+		if err := media.NormalizeLoudness(output, output, target, w.cfg.TruePeak); err != nil {
+			// 正規化失敗不影響成品，保留未正規化的版本
+			log.Warn().Err(err).Str("job", rec.ID).Msg("響度正規化失敗，保留原始混音")
+		}
+	}
+	// 4. 額外輸出 (動圖預告、純音訊)，重用已產生的 video.mp4、voice.wav 與 BGM
+	if len(rec.Request.Exports) > 0 {
+		w.exportExtras(rec, videoPath, voiceOut, bgmInput, tl, finalDuration, voiceSeconds)
+	}
+
+	// 5. 縮圖與封面：使用尚未燒錄字幕的畫面，失敗不影響成品
+	if !rec.Request.Cover.Disabled {
+		if err := w.makeCover(rec, videoPath, finalDuration, video.Resolution, tl); err != nil {
+			log.Warn().Err(err).Str("job", rec.ID).Msg("產生縮圖失敗")
+		}
+	}
 	rec.Progress = 95
-	_ = w.store.UpdateJob(rec)
+	w.saveProgress(rec)
 	return nil
 }
+
+// exportExtras 依 exports 設定輸出額外格式，單一格式失敗只記錄在結果中
+func (w *Worker) exportExtras(rec *job.Record, videoPath, voiceOut, bgmInput string, tl *media.Timeline, videoDur, voiceDur float64) {
+	rec.Exports = nil
+	for _, e := range rec.Request.Exports {
+		out := filepath.Join(rec.BasePath, "export."+e.Format)
+		var err error
+		if e.IsAnimated() {
+			if e.StartSec >= videoDur {
+				e.StartSec = 0
+			}
+			e.DurationSec = min(e.DurationSec, videoDur-e.StartSec)
+			err = media.AnimatedTeaser(videoPath, out, e)
+		} else {
+			target := 0.0
+			if !rec.Request.Audio.SkipNormalize && !rec.Request.Preview.Enabled {
+				target = rec.Request.Audio.TargetLUFS
+				if target == 0 {
+					target = w.cfg.LoudnessLUFS
+				}
+			}
+			title := rec.Request.Cover.Title
+			if title == "" && len(tl.Lines) > 0 {
+				title = tl.Lines[0].Text
+			}
+			err = media.AudioExport(voiceOut, bgmInput, rec.Request.BGM, tl.SubtitleLines(), title, out, voiceDur, target, w.cfg.TruePeak)
+		}
+		result := job.ExportResult{Format: e.Format}
+		if err != nil {
+			log.Warn().Err(err).Str("job", rec.ID).Str("format", e.Format).Msg("額外輸出失敗")
+			result.Error = err.Error()
+		} else {
+			result.URL = fmt.Sprintf("/api/v1/jobs/%s/exports/%s", rec.ID, e.Format)
+		}
+		rec.Exports = append(rec.Exports, result)
+	}
+}
+
+// makeCover 挑選最佳畫面作為縮圖 (thumbnail.jpg)，再加上標題產生封面 (cover.jpg)
+func (w *Worker) makeCover(rec *job.Record, videoPath string, duration float64, resolution string, tl *media.Timeline) error {
+	thumb := filepath.Join(rec.BasePath, "thumbnail.jpg")
+	at, err := media.BestFrame(videoPath, duration, resolution, thumb)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("job", rec.ID).Float64("at", at).Msg("已挑選縮圖畫面")
+	rec.ThumbnailURL = fmt.Sprintf("/api/v1/jobs/%s/thumbnail", rec.ID)
+
+	title := rec.Request.Cover.Title
+	if title == "" && len(tl.Lines) > 0 {
+		title = tl.Lines[0].Text
+	}
+	if rec.Request.Cover.NoTitle || strings.TrimSpace(title) == "" {
+		return utils.CopyFile(thumb, filepath.Join(rec.BasePath, "cover.jpg"))
+	}
+	return media.MakeCover(thumb, title, rec.Request.CoverStyle(), resolution, filepath.Join(rec.BasePath, "cover.jpg"))
+}
+
+// scaleOverlays 將以像素指定的疊加層座標與寬度換算到草稿預覽解析度
+func scaleOverlays(overlays []job.Overlay, scale float64) []job.Overlay {
+	if scale == 1 {
+		return overlays
+	}
+	out := make([]job.Overlay, len(overlays))
+	for i, o := range overlays {
+		o.X = int(float64(o.X) * scale)
+		o.Y = int(float64(o.Y) * scale)
+		o.Width = int(float64(o.Width) * scale)
+		out[i] = o
+	}
+	return out
+}
+
+// overlayChain 產生影片濾鏡：沒有疊加層時維持原本的 [0:v] 字幕 + trim；
+// 有疊加層時先 trim，再疊圖片與文字，最後燒錄字幕，讓字幕在最上層
+func overlayChain(overlays *media.OverlayPlan, videoFilter string, duration float64, firstInput int) string {
+	if overlays.Empty() {
+		return fmt.Sprintf("[0:v]%s,trim=0:%.3f,setpts=PTS-STARTPTS[vout]", videoFilter, duration)
+	}
+	return fmt.Sprintf("[0:v]trim=0:%.3f,setpts=PTS-STARTPTS[vbase];%s;[vov]%s[vout]",
+		duration, overlays.Filter("vbase", "vov", firstInput), videoFilter)
+}
+
+// withSoftSubtitle 在 ffmpeg 參數中加入字幕輸入，並以 mov_text 封裝為可開關的字幕軌
+func withSoftSubtitle(args []string, srtPath string, inputIndex int) []string {
+	var out []string
+	for i, a := range args {
+		if a == "-filter_complex" {
+			out = append(out, "-i", srtPath)
+		}
+		if i == len(args)-1 {
+			// 最後一個參數為輸出檔
+			out = append(out, "-map", fmt.Sprintf("%d:s", inputIndex), "-c:s", "mov_text")
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -1,8 +1,10 @@
 package worker
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/Reggie-pan/go-shorts-generator/internal/service/job"
 	"github.com/Reggie-pan/go-shorts-generator/internal/utils"
 )
 
@@ -59,3 +61,76 @@ func abs(x int) int {
 	}
 	return x
 }
+
+func TestWithSoftSubtitle(t *testing.T) {
+	args := []string{"-y", "-i", "video.mp4", "-i", "voice.wav", "-filter_complex", "F", "-map", "[vout]", "-map", "[aout]", "out.mp4"}
+	got := withSoftSubtitle(args, "sub.srt", 2)
+	want := []string{"-y", "-i", "video.mp4", "-i", "voice.wav", "-i", "sub.srt", "-filter_complex", "F", "-map", "[vout]", "-map", "[aout]", "-map", "2:s", "-c:s", "mov_text", "out.mp4"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestAlignParallel(t *testing.T) {
+	script := "今天天氣很好，我們去公園散步。下午回家。"
+	lines := []string{"今天天氣很好", "我們去公園散步", "下午回家"}
+	got, err := alignParallel(script, lines, "The weather is nice today, let's walk in the park. Home in the afternoon.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"The weather is nice today,", "let's walk in the park.", "Home in the afternoon."}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 行 = %q，期望 %q", i, got[i], want[i])
+		}
+	}
+
+	// 句數相同時逐句對應
+	got, err = alignParallel(script, lines, "A.\nB.\nC.")
+	if err != nil || got[1] != "B." {
+		t.Errorf("逐句對應失敗: %q %v", got, err)
+	}
+	if _, err := alignParallel(script, lines, "Only one."); err == nil {
+		t.Error("句數無法對應時應回傳錯誤")
+	}
+}
+
+func TestSynthesizeLineKeepsSpans(t *testing.T) {
+	w, fake, rec := fakeWorker(t, 1)
+	rec.Request.TTS.Markup = true
+	if _, _, _, err := w.synthesizeLine(w.chain, rec, 0, `日期是[say-as=date]2024/5/1[/say-as]，*重點* A\B`); err != nil {
+		t.Fatal(err)
+	}
+	// 只清洗標記外的文字，say-as 內的日期原樣送出
+	want := "日期是[say-as=date]2024/5/1[/say-as]，重點 AB"
+	if len(fake.texts) != 1 || fake.texts[0] != want {
+		t.Errorf("送往 TTS 的文字 = %q，期望 %q", fake.texts, want)
+	}
+}
+
+func TestZeroPauseSurvivesValidate(t *testing.T) {
+	var req job.JobCreateRequest
+	body := `{"script":"一。二。","materials":[{"type":"image","duration_sec":3}],"bgm":{"source":"none"},
+		"pacing":{"comma_pause":0,"sentence_pause":0}}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// 明確指定的 0 保留，未填的段落停頓使用預設值
+	p := req.Pacing.WithDefaults()
+	if *p.CommaPause != 0 || *p.SentencePause != 0 || *p.ParagraphPause != job.DefaultParagraphPause {
+		t.Fatalf("停頓 = %v %v %v", *p.CommaPause, *p.SentencePause, *p.ParagraphPause)
+	}
+	gaps := []float64{pauseFor(p, utils.BoundarySentence), pauseFor(p, utils.BoundaryComma)}
+	segs := utils.BuildTimelinePaced([]string{"一", "二"}, []float64{1, 1}, gaps, 0.5)
+	if segs[0].End != 1000 || segs[1].Start != 1000 {
+		t.Errorf("句間不應有停頓: %+v", segs)
+	}
+}